@@ -0,0 +1,177 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+package goavro
+
+import (
+	"io"
+	"sync"
+)
+
+// EncodeAppend appends the binary encoding of datum to dst, and returns
+// the extended buffer, in the manner of strconv.AppendInt. When dst has
+// the capacity to hold the encoding, EncodeAppend does not allocate a
+// buffer of its own, so a caller reusing dst encodes each datum without
+// allocating for the output. On error the returned buffer holds dst
+// followed by whatever was encoded before the error.
+//
+//   buf := make([]byte, 0, 1024)
+//   for _, datum := range data {
+//       var err error
+//       if buf, err = codec.EncodeAppend(buf[:0], datum); err != nil {
+//           return err
+//       }
+//       send(buf)
+//   }
+func (c codec) EncodeAppend(dst []byte, datum interface{}) ([]byte, error) {
+	aw := appendWriters.Get().(*appendWriter)
+	aw.buf = dst
+	err := c.Encode(aw, datum)
+	dst, aw.buf = aw.buf, nil
+	appendWriters.Put(aw)
+	return dst, err
+}
+
+// appendWriters pools the writers of EncodeAppend, which would
+// otherwise escape to the heap, one per call.
+var appendWriters = sync.Pool{New: func() interface{} { return new(appendWriter) }}
+
+// DecodeScratch decodes the binary datum at the start of buf, and
+// returns it along with the bytes of buf following it. The records,
+// arrays, and maps of scratch, a datum previously returned by
+// DecodeScratch or Decode, are reused to hold the datum where their
+// shapes match, as DecodeReuse does, so a caller passing back each
+// datum it is done with decodes a stream of data with few allocations.
+// A nil scratch allocates anew. Byte and string values are always
+// copied out of buf. Codecs created by NewJSONCodec return an error.
+//
+//   var datum interface{}
+//   for len(buf) > 0 {
+//       var err error
+//       if datum, buf, err = codec.DecodeScratch(buf, datum); err != nil {
+//           return err
+//       }
+//       process(datum)
+//   }
+func (c codec) DecodeScratch(buf []byte, scratch interface{}) (interface{}, []byte, error) {
+	if c.jdf != nil {
+		// JSON decoding reads ahead of the datum
+		return nil, buf, newDecoderError(c.schemaName(), "DecodeScratch ought to be used with NewCodec")
+	}
+	sr := &sliceReader{buf: buf}
+	datum, err := c.decode(sr, scratch)
+	if err != nil {
+		return nil, buf, err
+	}
+	return datum, buf[sr.off:], nil
+}
+
+// AliasDecodedBytes returns a CodecSetter which causes DecodeScratch
+// to return bytes values, and the Value of Fixed values, as slices of
+// the buffer it decodes rather than copies of them, to avoid an
+// allocation and a copy for each. Those slices alias the buffer: they
+// are only valid for as long as the caller leaves its contents alone,
+// so a caller reusing the buffer for the next packet ought to be done
+// with the data decoded from it, or to copy what it keeps, beforehand.
+// Writing to those slices writes to the buffer. Other methods of
+// decoding, which read from an io.Reader, copy as usual.
+//
+//   codec, err := goavro.NewCodec(someSchema, goavro.AliasDecodedBytes())
+//   datum, _, err := codec.DecodeScratch(packet, nil)
+func AliasDecodedBytes() CodecSetter {
+	return func(c Codec) error {
+		someCodec, ok := c.(*codec)
+		if !ok || someCodec.opts == nil || someCodec.jdf != nil {
+			return newCodecBuildError("codec", "AliasDecodedBytes ought to be used with NewCodec")
+		}
+		someCodec.opts.aliasBytes = true
+		return nil
+	}
+}
+
+// readAliased returns the next size bytes of r as a slice of the
+// buffer r reads, and true, when r reads from the buffer of
+// DecodeScratch holding that many more bytes, and the options of the
+// codec allow it.
+func (opts *codecOptions) readAliased(r io.Reader, size int64) ([]byte, bool) {
+	if opts == nil || !opts.aliasBytes {
+		return nil, false
+	}
+	cr, ok := r.(*countingReader)
+	if !ok {
+		return nil, false
+	}
+	sr, ok := cr.r.(*sliceReader)
+	if !ok || size > int64(len(sr.buf)-sr.off) {
+		return nil, false
+	}
+	end := sr.off + int(size)
+	buf := sr.buf[sr.off:end:end] // appending to it leaves sr.buf alone
+	sr.off = end
+	cr.n += size
+	return buf, true
+}
+
+// appendWriter is an io.Writer appending to a byte slice. It implements
+// ByteWriter and StringWriter, so encoders write to it directly rather
+// than through temporary buffers.
+type appendWriter struct {
+	buf []byte
+}
+
+func (aw *appendWriter) Write(p []byte) (int, error) {
+	aw.buf = append(aw.buf, p...)
+	return len(p), nil
+}
+
+func (aw *appendWriter) WriteByte(b byte) error {
+	aw.buf = append(aw.buf, b)
+	return nil
+}
+
+func (aw *appendWriter) WriteString(s string) (int, error) {
+	aw.buf = append(aw.buf, s...)
+	return len(s), nil
+}
+
+// Grow ensures room for n more bytes without another allocation.
+func (aw *appendWriter) Grow(n int) {
+	if cap(aw.buf)-len(aw.buf) < n {
+		grown := make([]byte, len(aw.buf), 2*cap(aw.buf)+n)
+		copy(grown, aw.buf)
+		aw.buf = grown
+	}
+}
+
+// sliceReader is an io.Reader of a byte slice, which tracks how much
+// of it has been read.
+type sliceReader struct {
+	buf []byte
+	off int
+}
+
+func (sr *sliceReader) Read(p []byte) (int, error) {
+	if sr.off == len(sr.buf) {
+		if len(p) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+	n := copy(p, sr.buf[sr.off:])
+	sr.off += n
+	return n, nil
+}
@@ -0,0 +1,132 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+package goavro
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCodecEncodeAppend(t *testing.T) {
+	codec, err := NewCodec(`{"type":"record","name":"r","fields":[{"name":"s","type":"string"},{"name":"n","type":"long"},{"name":"b","type":"boolean"},{"name":"d","type":"double"}]}`)
+	checkErrorFatal(t, err, nil)
+	datum := map[string]interface{}{"s": "hi", "n": int64(-65), "b": true, "d": float64(1)}
+
+	buf, err := codec.(BufferCodec).EncodeAppend([]byte("prefix"), datum)
+	checkErrorFatal(t, err, nil)
+	expected := []byte("prefix\x04hi\x81\x01\x01\x00\x00\x00\x00\x00\x00\xf0\x3f")
+	if !bytes.Equal(buf, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", buf, expected)
+	}
+
+	_, err = codec.(BufferCodec).EncodeAppend(nil, map[string]interface{}{"s": 3})
+	checkError(t, err, "cannot encode record (r)")
+
+	long, err := NewCodec(`"long"`)
+	checkErrorFatal(t, err, nil)
+	dst := make([]byte, 0, 16)
+	var value interface{} = int64(1 << 40)
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, err := long.(BufferCodec).EncodeAppend(dst, value); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("Actual: %v allocations; Expected: 0", allocs)
+	}
+}
+
+func TestCodecDecodeScratch(t *testing.T) {
+	codec, err := NewCodec(`{"type":"record","name":"r","fields":[{"name":"s","type":"string"},{"name":"a","type":{"type":"array","items":"int"}}]}`)
+	checkErrorFatal(t, err, nil)
+	buf := []byte("\x02x\x04\x02\x04\x00\x02y\x02\x06\x00")
+
+	first, rest, err := codec.(BufferCodec).DecodeScratch(buf, nil)
+	checkErrorFatal(t, err, nil)
+	if actual, _ := first.(*Record).Get("s"); actual != "x" {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, "x")
+	}
+	if actual, expected := len(rest), 5; actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+
+	second, rest, err := codec.(BufferCodec).DecodeScratch(rest, first)
+	checkErrorFatal(t, err, nil)
+	if second != first {
+		t.Errorf("Actual: %p; Expected: %p", second, first)
+	}
+	if actual, _ := second.(*Record).Get("a"); len(actual.([]interface{})) != 1 || actual.([]interface{})[0] != int32(3) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, []interface{}{int32(3)})
+	}
+	if len(rest) != 0 {
+		t.Errorf("Actual: %#v; Expected: %#v", rest, []byte{})
+	}
+
+	jsonCodec, err := NewJSONCodec(`"long"`)
+	checkErrorFatal(t, err, nil)
+	_, _, err = jsonCodec.(BufferCodec).DecodeScratch([]byte("1 2"), nil)
+	checkError(t, err, "ought to be used with NewCodec")
+
+	_, rest, err = codec.(BufferCodec).DecodeScratch(buf[:3], nil)
+	checkError(t, err, "unexpected EOF")
+	if len(rest) != 3 {
+		t.Errorf("Actual: %#v; Expected: %#v", rest, buf[:3])
+	}
+}
+
+func TestCodecAliasDecodedBytes(t *testing.T) {
+	schema := `{"type":"record","name":"r","fields":[{"name":"b","type":"bytes"},{"name":"f","type":{"type":"fixed","name":"f4","size":4}},{"name":"s","type":"string"}]}`
+	codec, err := NewCodec(schema, AliasDecodedBytes())
+	checkErrorFatal(t, err, nil)
+	buf := []byte("\x04ab" + "wxyz" + "\x02s")
+
+	datum, rest, err := codec.(BufferCodec).DecodeScratch(buf, nil)
+	checkErrorFatal(t, err, nil)
+	if len(rest) != 0 {
+		t.Errorf("Actual: %#v; Expected: %#v", rest, []byte{})
+	}
+	b, _ := datum.(*Record).Get("b")
+	f, _ := datum.(*Record).Get("f")
+	if !bytes.Equal(b.([]byte), []byte("ab")) || !bytes.Equal(f.(Fixed).Value, []byte("wxyz")) {
+		t.Errorf("Actual: %#v, %#v; Expected: %#v, %#v", b, f, []byte("ab"), []byte("wxyz"))
+	}
+	// the values alias the buffer, but appending to them does not
+	buf[1], buf[3] = 'A', 'W'
+	if !bytes.Equal(b.([]byte), []byte("Ab")) || !bytes.Equal(f.(Fixed).Value, []byte("Wxyz")) {
+		t.Errorf("Actual: %#v, %#v; Expected: %#v, %#v", b, f, []byte("Ab"), []byte("Wxyz"))
+	}
+	_ = append(b.([]byte), '!')
+	if buf[3] != 'W' {
+		t.Errorf("Actual: %#v; Expected: %#v", buf[3], 'W')
+	}
+
+	// decoding from a reader copies
+	datum, err = codec.Decode(bytes.NewReader(buf))
+	checkErrorFatal(t, err, nil)
+	b, _ = datum.(*Record).Get("b")
+	buf[1] = 'a'
+	if !bytes.Equal(b.([]byte), []byte("Ab")) {
+		t.Errorf("Actual: %#v; Expected: %#v", b, []byte("Ab"))
+	}
+
+	_, _, err = codec.(BufferCodec).DecodeScratch(buf[:5], nil)
+	checkError(t, err, "buffer underrun")
+
+	_, err = NewJSONCodec(schema, AliasDecodedBytes())
+	checkError(t, err, "ought to be used with NewCodec")
+}
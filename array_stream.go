@@ -0,0 +1,151 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+package goavro
+
+import (
+	"bytes"
+	"io"
+)
+
+// defaultArrayBlockSize is the number of items per block with which
+// arrays are encoded, unless changed with ArrayBlockSize.
+const defaultArrayBlockSize = 10
+
+func (opts *codecOptions) blockSize() int {
+	if opts == nil || opts.arrayBlockSize <= 0 {
+		return defaultArrayBlockSize
+	}
+	return opts.arrayBlockSize
+}
+
+// writeArrayBlock writes one block of array items to w, the first of
+// which is item index of the array.
+func writeArrayBlock(w io.Writer, friendlyName string, opts *codecOptions, itemsCodec *codec, items []interface{}, index int) error {
+	out := w
+	var block *bytes.Buffer
+	if opts != nil && opts.blockSizes {
+		block = new(bytes.Buffer)
+		out = block
+	} else if err := longEncoder(w, int64(len(items))); err != nil {
+		return newEncoderError(friendlyName, err)
+	}
+	for idx, item := range items {
+		if err := itemsCodec.ef(out, item); err != nil {
+			return newEncoderPathError(friendlyName, itemPath("", index+idx), err)
+		}
+	}
+	if block != nil {
+		if err := writeSizedBlock(w, len(items), block.Bytes()); err != nil {
+			return newEncoderError(friendlyName, err)
+		}
+	}
+	return nil
+}
+
+// EncodeArrayStream encodes an array, whose items are pulled from next
+// until it returns false, to the specified io.Writer. Only one block of
+// items is held in memory at a time, so arrays too large to hold in a
+// slice may be encoded. The Codec ought to be for an array schema.
+//
+//   rows, err := db.Query("SELECT name FROM users")
+//   if err != nil {
+//       return err
+//   }
+//   next := func() (interface{}, bool) {
+//       var name string
+//       if !rows.Next() || rows.Scan(&name) != nil {
+//           return nil, false
+//       }
+//       return name, true
+//   }
+//   err = codec.EncodeArrayStream(w, next)
+func (c codec) EncodeArrayStream(w io.Writer, next func() (interface{}, bool)) error {
+	friendlyName := c.schemaName()
+	if c.ic == nil {
+		return newEncoderError(friendlyName, "EncodeArrayStream ought to be used with array codec")
+	}
+	blockSize := c.opts.blockSize()
+	items := make([]interface{}, 0, blockSize)
+	var index int
+	for {
+		item, ok := next()
+		if ok {
+			items = append(items, item)
+		}
+		if len(items) == blockSize || (!ok && len(items) > 0) {
+			if err := writeArrayBlock(w, friendlyName, c.opts, c.ic, items, index); err != nil {
+				return err
+			}
+			index += len(items)
+			items = items[:0]
+		}
+		if !ok {
+			break
+		}
+	}
+	if err := longEncoder(w, int64(0)); err != nil {
+		return newEncoderError(friendlyName, err)
+	}
+	return nil
+}
+
+// DecodeArrayStream decodes an array from the specified io.Reader,
+// calling fn with each item as it is decoded, rather than returning
+// the items in a slice, so arrays too large to hold in memory may be
+// processed. When fn returns an error, decoding stops, and that error
+// is returned as is. The Codec ought to be for an array schema.
+//
+//   var total int64
+//   err := codec.DecodeArrayStream(r, func(item interface{}) error {
+//       total += item.(int64)
+//       return nil
+//   })
+func (c codec) DecodeArrayStream(r io.Reader, fn func(interface{}) error) error {
+	friendlyName := c.schemaName()
+	if c.ic == nil {
+		return newDecoderError(friendlyName, "DecodeArrayStream ought to be used with array codec")
+	}
+	cr, ok := r.(*countingReader)
+	if !ok {
+		cr = &countingReader{r: r}
+	}
+	var index int
+	for {
+		blockCount, err := readBlockCount(cr)
+		if err != nil {
+			return newDecoderError(friendlyName, err)
+		}
+		if blockCount == 0 {
+			return nil
+		}
+		for i := int64(0); i < blockCount; i++ {
+			offset := itemOffset(cr)
+			item, err := c.ic.df(cr)
+			if err == nil {
+				err = checkItem(cr, offset)
+			}
+			if err != nil {
+				return newDecoderPathError(friendlyName, itemPath("", index), err)
+			}
+			if err = fn(item); err != nil {
+				return err
+			}
+			index++
+		}
+	}
+}
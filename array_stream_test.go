@@ -0,0 +1,109 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+package goavro
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCodecEncodeArrayStream(t *testing.T) {
+	codec, err := NewCodec(`{"type":"array","items":"long"}`, ArrayBlockSize(2))
+	checkErrorFatal(t, err, nil)
+
+	var pulled int64
+	next := func() (interface{}, bool) {
+		if pulled == 5 {
+			return nil, false
+		}
+		pulled++
+		return pulled, true
+	}
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, codec.(ArrayStreamCodec).EncodeArrayStream(bb, next), nil)
+	expected := []byte("\x04\x02\x04\x04\x06\x08\x02\x0a\x00")
+	if actual := bb.Bytes(); !bytes.Equal(actual, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+
+	// the same as encoding the slice
+	bb.Reset()
+	checkErrorFatal(t, codec.Encode(bb, []interface{}{int64(1), int64(2), int64(3), int64(4), int64(5)}), nil)
+	if actual := bb.Bytes(); !bytes.Equal(actual, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+
+	// empty stream
+	bb.Reset()
+	checkErrorFatal(t, codec.(ArrayStreamCodec).EncodeArrayStream(bb, func() (interface{}, bool) { return nil, false }), nil)
+	if actual := bb.Bytes(); !bytes.Equal(actual, []byte("\x00")) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, []byte("\x00"))
+	}
+
+	items := []interface{}{int64(1), int64(2), "three"}
+	next = func() (interface{}, bool) {
+		if len(items) == 0 {
+			return nil, false
+		}
+		item := items[0]
+		items = items[1:]
+		return item, true
+	}
+	checkError(t, codec.(ArrayStreamCodec).EncodeArrayStream(new(bytes.Buffer), next), "at [2]")
+
+	longCodec, err := NewCodec(`"long"`)
+	checkErrorFatal(t, err, nil)
+	checkError(t, longCodec.(ArrayStreamCodec).EncodeArrayStream(new(bytes.Buffer), next), "EncodeArrayStream ought to be used with array codec")
+
+	_, err = NewCodec(`{"type":"array","items":"long"}`, ArrayBlockSize(0))
+	checkError(t, err, "ArrayBlockSize ought to be positive: 0")
+}
+
+func TestCodecDecodeArrayStream(t *testing.T) {
+	codec, err := NewCodec(`{"type":"array","items":"long"}`)
+	checkErrorFatal(t, err, nil)
+
+	// second block in the negative count form, with its size
+	bits := []byte("\x04\x02\x04\x05\x06\x06\x08\x0a\x00")
+	var items []interface{}
+	checkErrorFatal(t, codec.(ArrayStreamCodec).DecodeArrayStream(bytes.NewReader(bits), func(item interface{}) error {
+		items = append(items, item)
+		return nil
+	}), nil)
+	expected := []interface{}{int64(1), int64(2), int64(3), int64(4), int64(5)}
+	if !reflect.DeepEqual(items, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", items, expected)
+	}
+
+	stop := errors.New("stop")
+	var seen int
+	err = codec.(ArrayStreamCodec).DecodeArrayStream(bytes.NewReader(bits), func(item interface{}) error {
+		if seen++; seen == 3 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || seen != 3 {
+		t.Errorf("Actual: %#v, %d; Expected: %#v, %d", err, seen, stop, 3)
+	}
+
+	err = codec.(ArrayStreamCodec).DecodeArrayStream(bytes.NewReader([]byte("\x04\x02")), func(interface{}) error { return nil })
+	checkError(t, err, "cannot decode array at [1]")
+}
@@ -0,0 +1,53 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+
+// Command avrogen writes Go type declarations corresponding to an Avro
+// record schema, read from the named file or from standard input.
+//
+//   avrogen -package models user.avsc > models/user.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/linkedin/goavro"
+	"io/ioutil"
+	"log"
+	"os"
+)
+
+func main() {
+	pkg := flag.String("package", "main", "package name of the generated file")
+	flag.Parse()
+
+	var schema []byte
+	var err error
+	if flag.NArg() > 0 {
+		schema, err = ioutil.ReadFile(flag.Arg(0))
+	} else {
+		schema, err = ioutil.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	source, err := goavro.GenerateGoStruct(string(schema), *pkg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(source)
+}
@@ -24,11 +24,19 @@
 package goavro
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"math"
+	"math/big"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 const (
@@ -50,17 +58,35 @@ func (e ErrSchemaParse) Error() string {
 	return "cannot parse schema: " + e.Message + ": " + e.Err.Error()
 }
 
-// ErrCodecBuild is returned when the encoder encounters an error.
+// Unwrap returns the error that caused the schema parse error, if any.
+func (e ErrSchemaParse) Unwrap() error {
+	return e.Err
+}
+
+// ErrCodecBuild is returned when the encoder encounters an error. Path
+// identifies the record field whose schema could not be built, for
+// instance `addresses.zip`, and is empty when the error is not
+// specific to a field.
 type ErrCodecBuild struct {
 	Message string
 	Err     error
+	Path    string
 }
 
 func (e ErrCodecBuild) Error() string {
+	message := "cannot build " + e.Message
+	if e.Path != "" {
+		message += " at " + e.Path
+	}
 	if e.Err == nil {
-		return "cannot build " + e.Message
+		return message
 	}
-	return "cannot build " + e.Message + ": " + e.Err.Error()
+	return message + ": " + e.Err.Error()
+}
+
+// Unwrap returns the error that caused the codec build error, if any.
+func (e ErrCodecBuild) Unwrap() error {
+	return e.Err
 }
 
 func newCodecBuildError(dataType string, a ...interface{}) *ErrCodecBuild {
@@ -68,7 +94,7 @@ func newCodecBuildError(dataType string, a ...interface{}) *ErrCodecBuild {
 	var format, message string
 	var ok bool
 	if len(a) == 0 {
-		return &ErrCodecBuild{dataType + ": no reason given", nil}
+		return &ErrCodecBuild{Message: dataType + ": no reason given"}
 	}
 	// if last item is error: save it
 	if err, ok = a[len(a)-1].(error); ok {
@@ -84,7 +110,22 @@ func newCodecBuildError(dataType string, a ...interface{}) *ErrCodecBuild {
 	if message != "" {
 		message = ": " + message
 	}
-	return &ErrCodecBuild{dataType + message, err}
+	return &ErrCodecBuild{Message: dataType + message, Err: err}
+}
+
+// newCodecBuildPathError wraps err, which was returned while building
+// the codec for the child found at segment of a complex schema. The
+// path of err is hoisted to the returned error, so only the outermost
+// error reports the path.
+func newCodecBuildPathError(dataType, segment string, err error, a ...interface{}) *ErrCodecBuild {
+	path := segment
+	if eb, ok := err.(*ErrCodecBuild); ok {
+		path = joinPath(segment, eb.Path)
+		eb.Path = ""
+	}
+	e := newCodecBuildError(dataType, append(a, err)...)
+	e.Path = path
+	return e
 }
 
 // Decoder interface specifies structures that may be decoded.
@@ -97,7 +138,21 @@ type Encoder interface {
 	Encode(io.Writer, interface{}) error
 }
 
+// Validator interface specifies structures that may check a datum
+// without encoding it.
+type Validator interface {
+	Validate(interface{}) error
+}
+
 // The Codec interface supports both Decode and Encode operations.
+// Codecs created by this package also implement the Validator,
+// StrictDecoder, ReuseDecoder, BufferCodec, StructCodec,
+// ArrayStreamCodec, Comparer, SchemaInspector and JSONTranscoder
+// interfaces, which may be reached with a type assertion.
+//
+//   if v, ok := someCodec.(goavro.Validator); ok {
+//       err = v.Validate(datum)
+//   }
 type Codec interface {
 	Decoder
 	Encoder
@@ -105,18 +160,351 @@ type Codec interface {
 	NewWriter(...WriterSetter) (*Writer, error)
 }
 
+// StrictDecoder interface specifies structures that may decode a
+// stream holding exactly one datum.
+type StrictDecoder interface {
+	DecodeStrict(io.Reader) (interface{}, error)
+}
+
+// ReuseDecoder interface specifies structures that may decode a record
+// into the storage of a previously decoded one.
+type ReuseDecoder interface {
+	DecodeReuse(io.Reader, *Record) error
+}
+
+// BufferCodec interface specifies structures that may decode from and
+// encode to byte slices without an intervening io.Reader or io.Writer.
+type BufferCodec interface {
+	DecodeScratch([]byte, interface{}) (interface{}, []byte, error)
+	EncodeAppend([]byte, interface{}) ([]byte, error)
+}
+
+// StructCodec interface specifies structures that may decode into and
+// encode from Go structs.
+type StructCodec interface {
+	DecodeInto(io.Reader, interface{}) error
+	EncodeStruct(io.Writer, interface{}) error
+}
+
+// ArrayStreamCodec interface specifies structures that may encode and
+// decode an array one item at a time.
+type ArrayStreamCodec interface {
+	EncodeArrayStream(io.Writer, func() (interface{}, bool)) error
+	DecodeArrayStream(io.Reader, func(interface{}) error) error
+}
+
+// Comparer interface specifies structures that may order two encoded
+// data without decoding them.
+type Comparer interface {
+	Compare([]byte, []byte) (int, error)
+}
+
+// SchemaInspector interface specifies structures that may describe
+// their schema.
+type SchemaInspector interface {
+	ExpandedSchema() (string, error)
+	InlinedSchema() string
+	OriginalSchema() string
+	SchemaTree() SchemaNode
+	Kind() string
+	Name() (string, bool)
+	FieldCodec(string) (Codec, error)
+	Field(string) (Codec, error)
+}
+
+// JSONTranscoder interface specifies structures that may convert data
+// between the Avro JSON and Avro binary encodings.
+type JSONTranscoder interface {
+	JSONDecodeNative(io.Reader) (interface{}, error)
+	JSONEncodeIndent(io.Writer, interface{}, string, string) error
+	JSONToBinary(io.Reader, io.Writer) error
+	BinaryToJSON(io.Reader, io.Writer) error
+}
+
 // CodecSetter functions are those those which are used to modify a
 // newly instantiated Codec.
 type CodecSetter func(Codec) error
 
 type decoderFunction func(io.Reader) (interface{}, error)
+type reuseDecoderFunction func(io.Reader, interface{}) (interface{}, error)
 type encoderFunction func(io.Writer, interface{}) error
+type validatorFunction func(string, interface{}) error
+type nativeFunction func(reflect.Value) (interface{}, error)
+
+// codecOptions holds the options of a codec, set by CodecSetter
+// functions after the codec is built. It is shared by the codec and all
+// of its descendant codecs, which consult it while encoding and
+// decoding.
+type codecOptions struct {
+	emptyStringAsNull   bool            // JSON decode "" as null in unions including null
+	recordsAsMaps       bool            // decode records as OrderedMap
+	unionValues         bool            // decode non-null union members as UnionValue
+	numericStrings      bool            // encode strings spelling numbers as int, long, float, or double
+	sortMapKeys         bool            // encode map entries in key order
+	blockSizes          bool            // encode array and map blocks with their byte sizes
+	arrayBlockSize      int             // items per array block; zero for defaultArrayBlockSize
+	maxDecodeDepth      int             // nesting depth limit when decoding; zero for defaultMaxDecodeDepth
+	maxDecodeLength     int64           // length limit of decoded strings and bytes; zero for MaxDecodeSize
+	encodeTimes         bool            // encode time.Time as epoch days for int, and epoch millis for long
+	relaxedUnions       bool            // JSON decode union values without a wrapper object
+	bareOptionals       bool            // JSON encode values of unions of null and one type without a wrapper object
+	decimals            bool            // decode and encode decimal logical type values as *big.Rat
+	ignoreUnknownFields bool            // skip map keys and JSON members naming no record field
+	nonFiniteFloats     NonFiniteFloats // JSON encoding of NaN and infinite floats and doubles
+	aliasBytes          bool            // DecodeScratch returns bytes and fixed values as slices of its buffer
+	converters          map[string]ConverterFunction
+	generalRecords      bool // build records of primitives as other records; benchmarks compare the two
+}
+
+// EncodeNumericStrings returns a CodecSetter which causes the codec to
+// accept a string spelling a number, such as "42" or "1.5", wherever
+// it accepts a json.Number, that is, for int, long, float, and double
+// values. The same range checks apply.
+//
+//   codec, err := goavro.NewCodec(`"long"`, goavro.EncodeNumericStrings())
+func EncodeNumericStrings() CodecSetter {
+	return func(c Codec) error {
+		someCodec, ok := c.(*codec)
+		if !ok || someCodec.opts == nil {
+			return newCodecBuildError("codec", "EncodeNumericStrings ought to be used with NewCodec or NewJSONCodec")
+		}
+		someCodec.opts.numericStrings = true
+		return nil
+	}
+}
+
+// EncodeTimes returns a CodecSetter which causes the codec to accept a
+// time.Time wherever it accepts an int, encoding the number of days
+// since the Unix epoch, and wherever it accepts a long, encoding the
+// number of milliseconds since the Unix epoch, whether or not the
+// schema declares a logical type. It bridges schemas whose timestamps
+// lack a logicalType. Decoding is unaffected. A union member is not
+// selected by a time.Time, so wrap it with Union to encode it as a
+// member of a union.
+//
+//   codec, err := goavro.NewCodec(`"long"`, goavro.EncodeTimes())
+//   err = codec.Encode(w, time.Now())
+//   err = unionCodec.Encode(w, goavro.Union("long", time.Now()))
+func EncodeTimes() CodecSetter {
+	return func(c Codec) error {
+		someCodec, ok := c.(*codec)
+		if !ok || someCodec.opts == nil {
+			return newCodecBuildError("codec", "EncodeTimes ought to be used with NewCodec or NewJSONCodec")
+		}
+		someCodec.opts.encodeTimes = true
+		return nil
+	}
+}
+
+// SortMapKeys returns a CodecSetter which causes the codec to encode
+// the entries of maps sorted by key, so that equal values always
+// encode to the same bytes, at the cost of sorting the keys of each
+// map. By default map entries are encoded in the random order Go
+// iterates over them. JSON codecs always write map keys sorted.
+//
+//   codec, err := goavro.NewCodec(someSchema, goavro.SortMapKeys())
+func SortMapKeys() CodecSetter {
+	return func(c Codec) error {
+		someCodec, ok := c.(*codec)
+		if !ok || someCodec.opts == nil {
+			return newCodecBuildError("codec", "SortMapKeys ought to be used with NewCodec or NewJSONCodec")
+		}
+		someCodec.opts.sortMapKeys = true
+		return nil
+	}
+}
+
+// EncodeBlockSizes returns a CodecSetter which causes the codec to
+// encode each block of array items and map entries preceded by its
+// negated item count and its size in bytes, as the Avro specification
+// permits, rather than by its item count alone, so readers may skip
+// over arrays and maps without decoding them. Each block is buffered
+// while it is encoded, in order to learn its size.
+//
+//   codec, err := goavro.NewCodec(someSchema, goavro.EncodeBlockSizes())
+func EncodeBlockSizes() CodecSetter {
+	return func(c Codec) error {
+		someCodec, ok := c.(*codec)
+		if !ok || someCodec.opts == nil {
+			return newCodecBuildError("codec", "EncodeBlockSizes ought to be used with NewCodec")
+		}
+		someCodec.opts.blockSizes = true
+		return nil
+	}
+}
+
+// ArrayBlockSize returns a CodecSetter which causes the codec to
+// encode arrays in blocks of at most the specified number of items,
+// rather than defaultArrayBlockSize. Larger blocks encode fewer block
+// counts; smaller blocks hold fewer items in memory while streaming
+// with EncodeArrayStream.
+//
+//   codec, err := goavro.NewCodec(someSchema, goavro.ArrayBlockSize(1000))
+func ArrayBlockSize(size int) CodecSetter {
+	return func(c Codec) error {
+		someCodec, ok := c.(*codec)
+		if !ok || someCodec.opts == nil {
+			return newCodecBuildError("codec", "ArrayBlockSize ought to be used with NewCodec")
+		}
+		if size <= 0 {
+			return newCodecBuildError("codec", "ArrayBlockSize ought to be positive: %d", size)
+		}
+		someCodec.opts.arrayBlockSize = size
+		return nil
+	}
+}
+
+// MaxDecodeDepth returns a CodecSetter which limits how deeply records,
+// arrays, maps and unions may nest within a datum decoded from Avro
+// binary, rather than allowing defaultMaxDecodeDepth levels. Decoding
+// a datum nested more deeply fails rather than exhausting the stack,
+// which hardens decoding of untrusted schemas and data.
+//
+//   codec, err := goavro.NewCodec(someSchema, goavro.MaxDecodeDepth(32))
+func MaxDecodeDepth(depth int) CodecSetter {
+	return func(c Codec) error {
+		someCodec, ok := c.(*codec)
+		if !ok || someCodec.opts == nil {
+			return newCodecBuildError("codec", "MaxDecodeDepth ought to be used with NewCodec")
+		}
+		if depth <= 0 {
+			return newCodecBuildError("codec", "MaxDecodeDepth ought to be positive: %d", depth)
+		}
+		someCodec.opts.maxDecodeDepth = depth
+		return nil
+	}
+}
+
+// IgnoreUnknownFields returns a CodecSetter which causes the codec to
+// skip the keys of a map[string]interface{} encoded as a record, and
+// the members of an Avro JSON object decoded as a record, which name no
+// field of the record, rather than return an error for them, so that
+// data from producers which have adopted a newer schema are accepted.
+//
+//   codec, err := goavro.NewCodec(someSchema, goavro.IgnoreUnknownFields())
+func IgnoreUnknownFields() CodecSetter {
+	return func(c Codec) error {
+		someCodec, ok := c.(*codec)
+		if !ok || someCodec.opts == nil {
+			return newCodecBuildError("codec", "IgnoreUnknownFields ought to be used with NewCodec or NewJSONCodec")
+		}
+		someCodec.opts.ignoreUnknownFields = true
+		return nil
+	}
+}
+
+// MaxDecodeLength returns a CodecSetter which limits the length of
+// strings and bytes the codec decodes, overriding MaxDecodeSize for
+// this codec alone. Decoding a string or bytes whose length prefix
+// exceeds the limit returns an error rather than reading the datum,
+// so corrupt data cannot cause a huge allocation.
+//
+//   codec, err := goavro.NewCodec(schema, goavro.MaxDecodeLength(1<<20))
+func MaxDecodeLength(length int64) CodecSetter {
+	return func(c Codec) error {
+		someCodec, ok := c.(*codec)
+		if !ok || someCodec.opts == nil {
+			return newCodecBuildError("codec", "MaxDecodeLength ought to be used with NewCodec")
+		}
+		if length <= 0 {
+			return newCodecBuildError("codec", "MaxDecodeLength ought to be positive: %d", length)
+		}
+		someCodec.opts.maxDecodeLength = length
+		return nil
+	}
+}
+
+// numericStringCodec wraps the encoder of someCodec, a numeric codec,
+// so that when opts.numericStrings is set it treats a string datum as
+// the json.Number it spells.
+func numericStringCodec(opts *codecOptions, someCodec *codec) *codec {
+	ef := someCodec.ef
+	someCodec.ef = func(w io.Writer, datum interface{}) error {
+		if someString, ok := datum.(string); ok && opts.numericStrings {
+			datum = json.Number(someString)
+		}
+		return ef(w, datum)
+	}
+	if someCodec.vf != nil {
+		someCodec.vf = validatorFromEncoder(someCodec.ef)
+	}
+	return someCodec
+}
+
+// timeCodec wraps the encoder of someCodec, an int or long codec, so
+// that when opts.encodeTimes is set it treats a time.Time datum as the
+// number returned by convert.
+func timeCodec(opts *codecOptions, someCodec *codec, convert func(time.Time) interface{}) *codec {
+	ef := someCodec.ef
+	someCodec.ef = func(w io.Writer, datum interface{}) error {
+		if someTime, ok := datum.(time.Time); ok && opts.encodeTimes {
+			datum = convert(someTime)
+		}
+		return ef(w, datum)
+	}
+	if someCodec.vf != nil {
+		someCodec.vf = validatorFromEncoder(someCodec.ef)
+	}
+	return someCodec
+}
+
+// epochDays returns the number of whole days between the Unix epoch
+// and t, rounded down.
+func epochDays(t time.Time) interface{} {
+	seconds := t.Unix()
+	days := seconds / 86400
+	if seconds%86400 < 0 {
+		days--
+	}
+	return int(days)
+}
+
+// epochMillis returns the number of whole milliseconds between the Unix
+// epoch and t, rounded down.
+func epochMillis(t time.Time) interface{} {
+	return t.Unix()*1000 + int64(t.Nanosecond())/int64(time.Millisecond)
+}
+
+// DecodeRecordsAsMaps returns a CodecSetter which causes the codec to
+// decode records, including nested records, as an OrderedMap of field
+// names to field values in schema order, rather than as *Record.
+//
+//   codec, err := goavro.NewCodec(someSchema, goavro.DecodeRecordsAsMaps())
+func DecodeRecordsAsMaps() CodecSetter {
+	return func(c Codec) error {
+		someCodec, ok := c.(*codec)
+		if !ok || someCodec.opts == nil {
+			return newCodecBuildError("codec", "DecodeRecordsAsMaps ought to be used with NewCodec or NewJSONCodec")
+		}
+		someCodec.opts.recordsAsMaps = true
+		return nil
+	}
+}
 
 type codec struct {
-	nm     *name
-	df     decoderFunction
-	ef     encoderFunction
-	schema string
+	nm       *name
+	df       decoderFunction
+	rdf      reuseDecoderFunction // decoder reusing the storage of a previous datum, if any
+	ef       encoderFunction
+	vf       validatorFunction
+	nf       nativeFunction
+	jdf      decoderFunction   // JSON decoder, set by NewJSONCodec
+	fc       map[string]*codec // record field codecs by field name
+	ic       *codec            // array items codec
+	opts     *codecOptions
+	schema   string
+	original string     // schema text the codec was created from, if any
+	tree     SchemaNode // set by NewCodec for Compare
+	twin     *twinCodec // codec for the same schema in the other encoding
+}
+
+// twinCodec holds the codec for the schema of a codec in the other
+// encoding, Avro binary for codecs created with NewJSONCodec and Avro
+// JSON for others, built at most once and sharing the codec options.
+type twinCodec struct {
+	once  sync.Once
+	codec *codec
+	err   error
 }
 
 // String returns a string representation of the codec.
@@ -128,27 +516,41 @@ func (c codec) String() string {
 // union member, it gets the Go type name of the datum sent to
 // the union encoder, and uses that string as a key into the
 // encoders map
-func newSymbolTable() *symtab {
+func newSymbolTable(opts *codecOptions) *symtab {
 	return &symtab{
 		name:         make(map[string]*codec),
-		nullCodec:    &codec{nm: &name{n: "null"}, df: nullDecoder, ef: nullEncoder},
-		booleanCodec: &codec{nm: &name{n: "bool"}, df: booleanDecoder, ef: booleanEncoder},
-		intCodec:     &codec{nm: &name{n: "int32"}, df: intDecoder, ef: intEncoder},
-		longCodec:    longCodec(),
-		floatCodec:   &codec{nm: &name{n: "float32"}, df: floatDecoder, ef: floatEncoder},
-		doubleCodec:  &codec{nm: &name{n: "float64"}, df: doubleDecoder, ef: doubleEncoder},
-		bytesCodec:   &codec{nm: &name{n: "[]uint8"}, df: bytesDecoder, ef: bytesEncoder},
-		stringCodec:  &codec{nm: &name{n: "string"}, df: stringDecoder, ef: stringEncoder},
+		defs:         make(map[string]namedDefinition),
+		opts:         opts,
+		nullCodec:    &codec{nm: &name{n: "null"}, df: nullDecoder, ef: nullEncoder, vf: validatorFromEncoder(nullEncoder), nf: nullNative},
+		booleanCodec: &codec{nm: &name{n: "bool"}, df: booleanDecoder, ef: booleanEncoder, vf: validatorFromEncoder(booleanEncoder), nf: booleanNative},
+		intCodec:     timeCodec(opts, numericStringCodec(opts, &codec{nm: &name{n: "int32"}, df: intDecoder, ef: intEncoder, vf: validatorFromEncoder(intEncoder), nf: intNative}), epochDays),
+		longCodec:    timeCodec(opts, numericStringCodec(opts, longCodec()), epochMillis),
+		floatCodec:   numericStringCodec(opts, &codec{nm: &name{n: "float32"}, df: floatDecoder, ef: floatEncoder, vf: validatorFromEncoder(floatEncoder), nf: floatNative}),
+		doubleCodec:  numericStringCodec(opts, &codec{nm: &name{n: "float64"}, df: doubleDecoder, ef: doubleEncoder, vf: validatorFromEncoder(doubleEncoder), nf: doubleNative}),
+		bytesCodec:   &codec{nm: &name{n: "[]uint8"}, df: func(r io.Reader) (interface{}, error) { return decodeBytes(opts, r) }, ef: bytesEncoder, vf: validatorFromEncoder(bytesEncoder), nf: bytesNative},
+		stringCodec:  &codec{nm: &name{n: "string"}, df: func(r io.Reader) (interface{}, error) { return decodeString(opts, r) }, ef: stringEncoder, vf: validatorFromEncoder(stringEncoder), nf: stringNative},
 	}
 
 }
 
+// reusing sets the decoder of someCodec to its reuse decoder given no
+// previous datum, so the two decode alike.
+func reusing(someCodec *codec) *codec {
+	rdf := someCodec.rdf
+	someCodec.df = func(r io.Reader) (interface{}, error) {
+		return rdf(r, nil)
+	}
+	return someCodec
+}
+
 func longCodec() *codec {
-	return &codec{nm: &name{n: "int64"}, df: longDecoder, ef: longEncoder}
+	return &codec{nm: &name{n: "int64"}, df: longDecoder, ef: longEncoder, vf: validatorFromEncoder(longEncoder), nf: longNative}
 }
 
 type symtab struct {
 	name map[string]*codec // map full name to codec
+	defs map[string]namedDefinition
+	opts *codecOptions
 
 	//cache primitive codecs
 	nullCodec    *codec
@@ -206,6 +608,123 @@ func NewCodec(someJSONSchema string, setters ...CodecSetter) (Codec, error) {
 	if err := json.Unmarshal([]byte(someJSONSchema), &schema); err != nil {
 		return nil, &ErrSchemaParse{"cannot unmarshal JSON", err}
 	}
+	newCodec, err := newCodecFromValue(schema, setters...)
+	if err != nil {
+		return nil, err
+	}
+	newCodec.(*codec).original = someJSONSchema
+	return newCodec, nil
+}
+
+// NewCodecLenient is like NewCodec, but accepts a schema authored by
+// hand which has `//` line comments, `/* */` block comments, or commas
+// after the last member of an object or the last item of an array,
+// which strict JSON forbids. It removes them before parsing the schema.
+// OriginalSchema returns the schema as given, comments included.
+//
+//   codec, err := goavro.NewCodecLenient(`{
+//       "type": "record",
+//       "name": "user",
+//       "fields": [
+//           {"name": "id", "type": "long"}, // primary key
+//       ],
+//   }`)
+func NewCodecLenient(someJSONSchema string, setters ...CodecSetter) (Codec, error) {
+	newCodec, err := NewCodec(strictJSON(someJSONSchema), setters...)
+	if err != nil {
+		return nil, err
+	}
+	newCodec.(*codec).original = someJSONSchema
+	return newCodec, nil
+}
+
+// NewCodecFromValue is like NewCodec, but takes a schema which has
+// already been parsed, such as by json.Unmarshal into an interface{},
+// sparing the cost of encoding it as JSON only for NewCodec to parse
+// it again. Besides the types json.Unmarshal produces, the schema may
+// hold any Go integer or floating point type for numbers, and []string
+// for arrays of strings, such as symbols and aliases. The schema is
+// copied, so it may be modified afterwards without affecting the
+// codec.
+//
+//   var schema map[string]interface{}
+//   // schema loaded from a database, definition not shown
+//   codec, err := goavro.NewCodecFromValue(schema)
+func NewCodecFromValue(schema interface{}, setters ...CodecSetter) (Codec, error) {
+	normalized, err := normalizeSchemaValue(schema)
+	if err != nil {
+		return nil, &ErrSchemaParse{"cannot use schema value", err}
+	}
+	return newCodecFromValue(normalized, setters...)
+}
+
+// normalizeSchemaValue returns a copy of schema holding only the types
+// json.Unmarshal produces for an interface{}.
+func normalizeSchemaValue(schema interface{}) (interface{}, error) {
+	switch v := schema.(type) {
+	case nil, bool, string, float64:
+		return v, nil
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			someValue, err := normalizeSchemaValue(value)
+			if err != nil {
+				return nil, err
+			}
+			normalized[key] = someValue
+		}
+		return normalized, nil
+	case []interface{}:
+		normalized := make([]interface{}, len(v))
+		for idx, value := range v {
+			someValue, err := normalizeSchemaValue(value)
+			if err != nil {
+				return nil, err
+			}
+			normalized[idx] = someValue
+		}
+		return normalized, nil
+	case []string:
+		normalized := make([]interface{}, len(v))
+		for idx, value := range v {
+			normalized[idx] = value
+		}
+		return normalized, nil
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return f, nil
+	case int:
+		return float64(v), nil
+	case int8:
+		return float64(v), nil
+	case int16:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint:
+		return float64(v), nil
+	case uint8:
+		return float64(v), nil
+	case uint16:
+		return float64(v), nil
+	case uint32:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case float32:
+		return float64(v), nil
+	}
+	return nil, fmt.Errorf("unsupported type: %T", schema)
+}
+
+// newCodecFromValue returns a Codec for a schema holding only the types
+// json.Unmarshal produces.
+func newCodecFromValue(schema interface{}, setters ...CodecSetter) (Codec, error) {
 	// remarshal back into compressed json
 	compressedSchema, err := json.Marshal(schema)
 	if err != nil {
@@ -214,12 +733,14 @@ func NewCodec(someJSONSchema string, setters ...CodecSetter) (Codec, error) {
 
 	// each codec gets a unified namespace of symbols to
 	// respective codecs
-	st := newSymbolTable()
+	st := newSymbolTable(new(codecOptions))
 
 	newCodec, err := st.buildCodec(nullNamespace, schema)
 	if err != nil {
 		return nil, err
 	}
+	newCodec.opts = st.opts
+	newCodec.twin = new(twinCodec)
 
 	for _, setter := range setters {
 		err = setter(newCodec)
@@ -228,27 +749,292 @@ func NewCodec(someJSONSchema string, setters ...CodecSetter) (Codec, error) {
 		}
 	}
 	newCodec.schema = string(compressedSchema)
+	newCodec.tree = newCodec.SchemaTree()
+	return newCodec, nil
+}
+
+// MustNewCodec is like NewCodec but panics if the schema cannot be
+// compiled, or a setter fails. It simplifies the initialization of
+// package level variables holding codecs for schemas known at compile
+// time.
+//
+//   var userCodec = goavro.MustNewCodec(userSchema)
+func MustNewCodec(someJSONSchema string, setters ...CodecSetter) Codec {
+	c, err := NewCodec(someJSONSchema, setters...)
+	if err != nil {
+		panic(`goavro: NewCodec(` + strconv.Quote(someJSONSchema) + `): ` + err.Error())
+	}
+	return c
+}
+
+// NewCodecWithTypes returns a Codec for mainSchema, which may refer
+// by name to record, enum, and fixed types defined in any of
+// otherSchemas. The named types of the auxiliary schemas are
+// registered first, in any order, so they may also refer to each
+// other. It is an error for two schemas to define the same named type
+// differently. The Codec's Schema embeds each externally defined type
+// at its first reference, so it stands on its own.
+//
+//   codec, err := goavro.NewCodecWithTypes(`{"type":"array","items":"com.example.Address"}`,
+//       `{"type":"record","name":"com.example.Address","fields":[{"name":"city","type":"string"}]}`)
+//   if err != nil {
+//       return nil, err
+//   }
+func NewCodecWithTypes(mainSchema string, otherSchemas ...string) (Codec, error) {
+	var schema interface{}
+	if err := json.Unmarshal([]byte(mainSchema), &schema); err != nil {
+		return nil, &ErrSchemaParse{"cannot unmarshal JSON", err}
+	}
+	others := make([]interface{}, len(otherSchemas))
+	for idx, otherSchema := range otherSchemas {
+		if err := json.Unmarshal([]byte(otherSchema), &others[idx]); err != nil {
+			return nil, &ErrSchemaParse{"cannot unmarshal JSON", err}
+		}
+	}
+	newCodec, err := newCodecWithTypes(nullNamespace, schema, others)
+	if err != nil {
+		return nil, err
+	}
+	newCodec.original = mainSchema
+	return newCodec, nil
+}
+
+// newCodecWithTypes returns a codec for schema, found in the specified
+// enclosing namespace, after registering the named types of others.
+func newCodecWithTypes(enclosingNamespace string, schema interface{}, others []interface{}) (*codec, error) {
+	st := newSymbolTable(new(codecOptions))
+
+	// an auxiliary schema may refer to types of one listed after it,
+	// so keep making passes while at least one more of them builds
+	pending := others
+	for len(pending) > 0 {
+		var failed []interface{}
+		var err error
+		for _, other := range pending {
+			if _, err = st.buildCodec(nullNamespace, other); err != nil {
+				failed = append(failed, other)
+			}
+		}
+		if len(failed) == len(pending) {
+			return nil, err
+		}
+		pending = failed
+	}
+
+	newCodec, err := st.buildCodec(enclosingNamespace, schema)
+	if err != nil {
+		return nil, err
+	}
+	newCodec.opts = st.opts
+	newCodec.twin = new(twinCodec)
+
+	external := make(map[string]interface{})
+	for _, other := range others {
+		inlineSchema(nullNamespace, other, external)
+	}
+	compressedSchema, err := json.Marshal(embedNamedTypes(enclosingNamespace, schema, external, make(map[string]bool)))
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal schema: %v", err)
+	}
+	newCodec.schema = string(compressedSchema)
+	newCodec.tree = newCodec.SchemaTree()
 	return newCodec, nil
 }
 
 // Decode will read from the specified io.Reader, and return the next
 // datum from the stream, or an error explaining why the stream cannot
-// be converted into the Codec's schema.
+// be converted into the Codec's schema. Decode reads no further than
+// the end of the datum, so it may be called repeatedly to read a
+// stream of data. Use DecodeStrict when the input ought to hold
+// exactly one datum.
+//
+// When the io.Reader is exhausted before the first byte of the datum,
+// Decode returns io.EOF itself, so a stream of data ending cleanly
+// between data is recognized as by other readers. Other errors are
+// always of type *ErrDecoder, and when the io.Reader is exhausted part
+// way through the datum, the error they wrap is io.ErrUnexpectedEOF.
 func (c codec) Decode(r io.Reader) (interface{}, error) {
+	return c.decode(r, nil)
+}
+
+// decode is Decode, reusing the storage of old, a previously decoded
+// datum, where its shape matches the datum read from r.
+func (c codec) decode(r io.Reader, old interface{}) (interface{}, error) {
+	cr, ok := r.(*countingReader)
+	if !ok {
+		cr = &countingReader{r: r}
+	}
+	if cr.depth == 0 {
+		cr.empty = 0 // a new datum begins
+	}
+	start := cr.n
+	datum, err := c.decodeReusing(cr, old)
+	if err != nil {
+		return nil, c.decoderError(cr, start, err)
+	}
+	return datum, nil
+}
+
+// decoderError decorates err, returned while decoding a datum which
+// began at offset start of cr, with the schema name and the offset of
+// the failure, or returns io.EOF when cr ended before the datum began.
+func (c codec) decoderError(cr *countingReader, start int64, err error) error {
+	if causedByEOF(err) {
+		if cr.n == start {
+			return io.EOF
+		}
+		err = replaceEOF(err)
+	}
+	ed, ok := err.(*ErrDecoder)
+	if !ok {
+		ed = newDecoderError(c.schemaName(), err)
+	}
+	if ed.SchemaName == "" {
+		ed.SchemaName = c.schemaName()
+	}
+	ed.Offset = cr.n - start
+	return ed
+}
+
+// decodeReusing decodes a datum without decorating errors as Decode
+// does, reusing the storage of old when c is able to.
+func (c codec) decodeReusing(r io.Reader, old interface{}) (interface{}, error) {
+	if c.rdf != nil && old != nil {
+		return c.rdf(r, old)
+	}
 	return c.df(r)
 }
 
+// DecodeReuse decodes a record from the specified io.Reader into rec,
+// rather than allocating a new Record, so steady-state decoding of a
+// record type allocates less. Nested records, arrays, and maps reuse
+// the storage of the data rec holds from a previous decode where
+// their shapes match. Fields of rec are overwritten, and data
+// returned by prior calls to rec.Get ought not be used afterwards.
+//
+//   rec := new(goavro.Record)
+//   for {
+//       if err := codec.DecodeReuse(r, rec); err != nil {
+//           return err
+//       }
+//       process(rec)
+//   }
+func (c codec) DecodeReuse(r io.Reader, rec *Record) error {
+	if c.fc == nil {
+		return newDecoderError(c.schemaName(), "DecodeReuse ought to be used with record codec")
+	}
+	if rec == nil {
+		return newDecoderError(c.schemaName(), "expected: non-nil *Record")
+	}
+	datum, err := c.decode(r, rec)
+	if err != nil {
+		return err
+	}
+	someRecord, ok := datum.(*Record)
+	if !ok {
+		return newDecoderError(c.schemaName(), "expected: *Record; received: %T", datum)
+	}
+	if someRecord != rec {
+		*rec = *someRecord
+	}
+	return nil
+}
+
+// DecodeStrict is like Decode, but reads through to the end of the
+// specified io.Reader, and returns an error if any bytes follow the
+// datum. It is meant for input holding a single encoded datum, such
+// as a message body or a database column, where trailing bytes reveal
+// data written with a different schema or several data concatenated.
+func (c codec) DecodeStrict(r io.Reader) (interface{}, error) {
+	cr := &countingReader{r: r}
+	datum, err := c.Decode(cr)
+	if err != nil {
+		return nil, err
+	}
+	end := cr.n
+	trailing, err := io.Copy(ioutil.Discard, cr)
+	if err != nil {
+		return nil, newDecoderError(c.schemaName(), err)
+	}
+	if trailing > 0 {
+		ed := newDecoderError(c.schemaName(), "%d trailing bytes after datum", trailing)
+		ed.Offset = end
+		return nil, ed
+	}
+	return datum, nil
+}
+
 // Encode will write the specified datum to the specified io.Writer,
 // or return an error explaining why the datum cannot be converted
-// into the Codec's schema.
+// into the Codec's schema. Errors are always of type *ErrEncoder.
 func (c codec) Encode(w io.Writer, datum interface{}) error {
-	return c.ef(w, datum)
+	if err := c.ef(w, datum); err != nil {
+		return c.encoderError(err)
+	}
+	return nil
+}
+
+// encoderError decorates err, returned while encoding a datum, with the
+// schema name.
+func (c codec) encoderError(err error) error {
+	ee, ok := err.(*ErrEncoder)
+	if !ok {
+		ee = newEncoderError(c.schemaName(), err)
+	}
+	if ee.SchemaName == "" {
+		ee.SchemaName = c.schemaName()
+	}
+	return ee
+}
+
+// schemaName returns the fullname of a named type, or the Avro type
+// name of other types. Codec names of primitive types are Go type
+// names, because they are used to resolve union members.
+func (c codec) schemaName() string {
+	switch c.nm.n {
+	case "bool":
+		return "boolean"
+	case "int32":
+		return "int"
+	case "int64":
+		return "long"
+	case "float32":
+		return "float"
+	case "float64":
+		return "double"
+	case "[]uint8":
+		return "bytes"
+	default:
+		return c.nm.n
+	}
+}
+
+// Validate will check the specified datum against the Codec's schema
+// without encoding it, returning an error explaining the first
+// portion of the datum that cannot be converted into the schema.
+//
+//   if err := codec.Validate(datum); err != nil {
+//       return err // datum would fail to encode
+//   }
+func (c codec) Validate(datum interface{}) error {
+	return c.vf("", datum)
 }
 
 func (c codec) Schema() string {
 	return c.schema
 }
 
+// OriginalSchema returns the schema text the codec was created from,
+// verbatim, with the formatting and member order of its author. It is
+// the same as Schema for codecs created from a schema value rather
+// than from text, such as by NewCodecFromValue or FieldCodec.
+func (c codec) OriginalSchema() string {
+	if c.original == "" {
+		return c.schema
+	}
+	return c.original
+}
+
 // NewWriter creates a new Writer that encodes using the given Codec.
 //
 // The following two code examples produce identical results:
@@ -293,6 +1079,11 @@ func (st symtab) buildMap(enclosingNamespace string, schema map[string]interface
 	case string:
 		// EXAMPLE: "type":"int"
 		// EXAMPLE: "type":"enum"
+		if t == "bytes" {
+			if precision, scale, ok := decimalSchema(schema, -1); ok {
+				return decimalCodec(st.opts, st.bytesCodec, "bytes", precision, scale, -1), nil
+			}
+		}
 		return st.buildString(enclosingNamespace, t.(string), schema)
 	case map[string]interface{}, []interface{}:
 		// EXAMPLE: "type":{"type":fixed","name":"fixed_16","size":16}
@@ -321,7 +1112,7 @@ func (st symtab) buildString(enclosingNamespace, typeName string, schema interfa
 		return st.bytesCodec, nil
 	case "string":
 		return st.stringCodec, nil
-	case "record":
+	case "record", "error":
 		return st.makeRecordCodec(enclosingNamespace, schema)
 	case "enum":
 		return st.makeEnumCodec(enclosingNamespace, schema)
@@ -346,6 +1137,7 @@ func (st symtab) buildString(enclosingNamespace, typeName string, schema interfa
 
 type unionEncoder struct {
 	ef    encoderFunction
+	vf    validatorFunction
 	index int32
 }
 
@@ -367,86 +1159,225 @@ func (st symtab) makeUnionCodec(enclosingNamespace string, schema interface{}) (
 
 	// setup
 	nameToUnionEncoder := make(map[string]unionEncoder)
-	indexToDecoder := make([]decoderFunction, len(schemaArray))
+	typeNameToUnionEncoder := make(map[string]unionEncoder)
+	indexToTypeName := make([]string, len(schemaArray))
 	allowedNames := make([]string, len(schemaArray))
+	memberCodecs := make([]*codec, len(schemaArray))
 
 	for idx, unionMemberSchema := range schemaArray {
+		if _, ok := unionMemberSchema.([]interface{}); ok {
+			return nil, newCodecBuildError(friendlyName, "member ought not to be union: %d", idx)
+		}
 		c, err := st.buildCodec(enclosingNamespace, unionMemberSchema)
 		if err != nil {
-			return nil, newCodecBuildError(friendlyName, "member ought to be decodable: %s", err)
+			return nil, newCodecBuildPathError(friendlyName, "", err, "member ought to be decodable")
+		}
+		unionTypeName, err := getUnionTypeName(friendlyName, enclosingNamespace, unionMemberSchema)
+		if err != nil {
+			return nil, newCodecBuildError(friendlyName, "Can't get union type name: %s", err)
+		}
+		if _, ok := typeNameToUnionEncoder[unionTypeName]; ok {
+			return nil, newCodecBuildError(friendlyName, "duplicate member type: %s", unionTypeName)
 		}
 		allowedNames[idx] = c.nm.n
-		indexToDecoder[idx] = c.df
-		nameToUnionEncoder[c.nm.n] = unionEncoder{ef: c.ef, index: int32(idx)}
+		memberCodecs[idx] = c
+		indexToTypeName[idx] = unionTypeName
+		nameToUnionEncoder[c.nm.n] = unionEncoder{ef: c.ef, vf: c.vf, index: int32(idx)}
+		typeNameToUnionEncoder[unionTypeName] = nameToUnionEncoder[c.nm.n]
 	}
 
 	invalidType := "datum ought match schema: expected: "
 	invalidType += strings.Join(allowedNames, ", ")
 	invalidType += "; received: "
 
+	var recordNames []string
+	for _, c := range memberCodecs {
+		if c.fc != nil {
+			recordNames = append(recordNames, c.nm.n)
+		}
+	}
+	invalidRecord := "datum ought match schema: expected record: " + strings.Join(recordNames, ", ") + "; received record: "
+	if len(recordNames) == 0 {
+		invalidRecord = "datum ought match schema: expected no record; received record: "
+	}
+
+	// memberByValidator resolves a map or OrderedMap datum, for a union
+	// without a map member, to the record member which accepts it, and
+	// likewise a *big.Rat to a member of the decimal logical type.
+	memberByValidator := func(datum interface{}) (unionEncoder, bool) {
+		switch datum.(type) {
+		case map[string]interface{}, OrderedMap:
+			idx, ok := recordMemberForKeys(memberCodecs, datum, st.opts.ignoreUnknownFields, func(idx int) bool {
+				return memberCodecs[idx].vf("", datum) == nil
+			})
+			if ok {
+				return nameToUnionEncoder[memberCodecs[idx].nm.n], true
+			}
+		case *big.Rat:
+			for _, c := range memberCodecs {
+				if c.nm.n != "null" && c.vf("", datum) == nil {
+					return nameToUnionEncoder[c.nm.n], true
+				}
+			}
+		}
+		return unionEncoder{}, false
+	}
+
+	// memberForDatum returns the member encoder for datum, the datum the
+	// member encodes, which differs from datum for a UnionValue or a
+	// pointer, and the message to report when there is no match.
+	memberForDatum := func(datum interface{}) (unionEncoder, interface{}, string, bool) {
+		if uv, ok := datum.(UnionValue); ok {
+			ue, ok := typeNameToUnionEncoder[uv.TypeName]
+			return ue, uv.Value, invalidType + uv.TypeName, ok
+		}
+		datum = unionMemberDatum(datum)
+		name := unionMemberName(datum)
+		ue, ok := nameToUnionEncoder[name]
+		for _, alternate := range unionMemberAlternates(datum) {
+			if ok {
+				break
+			}
+			ue, ok = nameToUnionEncoder[alternate]
+		}
+		if !ok {
+			ue, ok = memberByValidator(datum)
+		}
+		if _, isRecord := datum.(*Record); isRecord {
+			return ue, datum, invalidRecord + name, ok
+		}
+		return ue, datum, invalidType + name, ok
+	}
+
 	nm, _ := newName(nameName("union"))
 	friendlyName = fmt.Sprintf("union (%s)", nm.n)
 
-	return &codec{
+	return reusing(&codec{
 		nm: nm,
-		df: func(r io.Reader) (interface{}, error) {
+		rdf: func(r io.Reader, old interface{}) (interface{}, error) {
+			if err := st.opts.descend(r); err != nil {
+				return nil, newDecoderError(friendlyName, err)
+			}
+			defer ascend(r)
 			i, err := intDecoder(r)
 			if err != nil {
-				return nil, newEncoderError(friendlyName, err)
+				return nil, newDecoderError(friendlyName, err)
 			}
 			idx, ok := i.(int32)
 			if !ok {
-				return nil, newEncoderError(friendlyName, "expected: int; received: %T", i)
+				return nil, newDecoderError(friendlyName, "expected: int; received: %T", i)
 			}
 			index := int(idx)
-			if index < 0 || index >= len(indexToDecoder) {
-				return nil, newEncoderError(friendlyName, "index must be between 0 and %d; read index: %d", len(indexToDecoder)-1, index)
+			if index < 0 || index >= len(memberCodecs) {
+				return nil, newDecoderError(friendlyName, "index must be between 0 and %d; read index: %d", len(memberCodecs)-1, index)
+			}
+			if uv, ok := old.(UnionValue); ok {
+				old = uv.Value
+			}
+			datum, err := memberCodecs[index].decodeReusing(r, old)
+			if err != nil || !st.opts.unionValues || indexToTypeName[index] == "null" {
+				return datum, err
 			}
-			return indexToDecoder[index](r)
+			return UnionValue{Branch: index, TypeName: indexToTypeName[index], Value: datum}, nil
 		},
 		ef: func(w io.Writer, datum interface{}) error {
 			var err error
-			var name string
-			switch datum.(type) {
-			default:
-				name = reflect.TypeOf(datum).String()
-			case map[string]interface{}:
-				name = "map"
-			case []interface{}:
-				name = "array"
-			case nil:
-				name = "null"
-			case Enum:
-				name = datum.(Enum).Name
-			case Fixed:
-				name = datum.(Fixed).Name
-			case *Record:
-				name = datum.(*Record).Name
-			}
-
-			ue, ok := nameToUnionEncoder[name]
+			ue, datum, message, ok := memberForDatum(datum)
 			if !ok {
-				return newEncoderError(friendlyName, invalidType+name)
+				return newEncoderError(friendlyName, message)
 			}
 			if err = intEncoder(w, ue.index); err != nil {
 				return newEncoderError(friendlyName, err)
 			}
 			if err = ue.ef(w, datum); err != nil {
-				return newEncoderError(friendlyName, err)
+				return newEncoderPathError(friendlyName, "", err)
 			}
 			return nil
 		},
-	}, nil
+		vf: func(path string, datum interface{}) error {
+			ue, datum, message, ok := memberForDatum(datum)
+			if !ok {
+				return newValidationError(path, friendlyName, message)
+			}
+			return ue.vf(path, datum)
+		},
+		nf: func(v reflect.Value) (interface{}, error) {
+			// a member matching the Go kind of the value, as Encode would
+			// pick, wins; otherwise the first member able to represent it
+			for _, memberName := range nativeMemberNames(v) {
+				for _, c := range memberCodecs {
+					if c.nm.n != memberName {
+						continue
+					}
+					if datum, err := c.nf(v); err == nil {
+						return datum, nil
+					}
+				}
+			}
+			for _, c := range memberCodecs {
+				if datum, err := c.nf(v); err == nil {
+					return datum, nil
+				}
+			}
+			return nil, newEncoderError(friendlyName, invalidType+nativeTypeName(v))
+		},
+	}), nil
+}
+
+// UnionValue holds a datum along with the type name of the union member
+// it is to be encoded as, for unions whose members cannot be told apart
+// by the Go type of the datum, such as ["int","long"]. TypeName is the
+// name of a primitive type, "array", "map", or the fullname of a named
+// type, as used to key union values in Avro JSON. Branch is the index
+// of the member in the union schema; it is set when decoding, and
+// ignored when encoding, which selects the member by TypeName.
+type UnionValue struct {
+	Branch   int
+	TypeName string
+	Value    interface{}
+}
+
+// Union returns a UnionValue which causes value to be encoded as the
+// member of a union with the specified type name, rather than the
+// member selected by the Go type of value.
+//
+//   err = codec.Encode(w, goavro.Union("long", int64(13)))
+func Union(typeName string, value interface{}) interface{} {
+	return UnionValue{TypeName: typeName, Value: value}
+}
+
+// DecodeUnionValues returns a CodecSetter which causes the codec to
+// decode each non-null union value as a UnionValue naming the member
+// it was encoded as, along with its index in the union, so members
+// decoding to the same Go type may be told apart. Null union values
+// still decode as nil.
+//
+//   codec, err := goavro.NewCodec(someSchema, goavro.DecodeUnionValues())
+func DecodeUnionValues() CodecSetter {
+	return func(c Codec) error {
+		someCodec, ok := c.(*codec)
+		if !ok || someCodec.opts == nil {
+			return newCodecBuildError("codec", "DecodeUnionValues ought to be used with NewCodec or NewJSONCodec")
+		}
+		someCodec.opts.unionValues = true
+		return nil
+	}
 }
 
 // Enum is an abstract data type used to hold data corresponding to an Avro enum. Whenever an Avro
 // schema specifies an enum, this library's Decode method will return an Enum initialized to the
 // enum's name and value read from the io.Reader. Likewise, when using Encode to convert data to an
 // Avro record, it is necessary to create and send an Enum instance to the Encode method.
+//
+// The symbol is carried by Value; Name holds the name of the enum type.
 type Enum struct {
 	Name, Value string
 }
 
+// emptyEnumValue explains the most frequent misuse of Enum, where the
+// symbol is expected to be inferred.
+const emptyEnumValue = "Enum Value ought to be the symbol to encode; received empty Value with Name: %q"
+
 func (st symtab) makeEnumCodec(enclosingNamespace string, schema interface{}) (*codec, error) {
 	errorNamespace := "null namespace"
 	if enclosingNamespace != nullNamespace {
@@ -473,11 +1404,19 @@ func (st symtab) makeEnumCodec(enclosingNamespace string, schema interface{}) (*
 	if !ok || len(symtab) == 0 {
 		return nil, newCodecBuildError(friendlyName, "symbols ought to be non-empty array")
 	}
+	seenSymbols := make(map[string]bool, len(symtab))
 	for _, v := range symtab {
-		_, ok := v.(string)
+		symbol, ok := v.(string)
 		if !ok {
 			return nil, newCodecBuildError(friendlyName, "symbols array member ought to be string")
 		}
+		if err := checkName(symbol); err != nil {
+			return nil, newCodecBuildError(friendlyName, &ErrSchemaParse{"invalid symbol", err})
+		}
+		if seenSymbols[symbol] {
+			return nil, newCodecBuildError(friendlyName, "duplicate symbol: %s", symbol)
+		}
+		seenSymbols[symbol] = true
 	}
 	c := &codec{
 		nm: nm,
@@ -500,6 +1439,9 @@ func (st symtab) makeEnumCodec(enclosingNamespace string, schema interface{}) (*
 			switch datum.(type) {
 			case Enum:
 				someString = datum.(Enum).Value
+				if someString == "" {
+					return newEncoderError(friendlyName, emptyEnumValue, datum.(Enum).Name)
+				}
 			case string:
 				someString = datum.(string)
 			default:
@@ -515,8 +1457,26 @@ func (st symtab) makeEnumCodec(enclosingNamespace string, schema interface{}) (*
 			}
 			return newEncoderError(friendlyName, "symbol not defined: %s", someString)
 		},
+		nf: func(v reflect.Value) (interface{}, error) {
+			v = indirectNative(v)
+			if !v.IsValid() || v.Kind() != reflect.String {
+				if someEnum, ok := nativeInterface(v).(Enum); ok {
+					return someEnum, nil
+				}
+				return nil, newEncoderError(friendlyName, "expected: Enum or string; received: %s", nativeTypeName(v))
+			}
+			for _, symbol := range symtab {
+				if symbol == v.String() {
+					return Enum{nm.n, v.String()}, nil
+				}
+			}
+			return nil, newEncoderError(friendlyName, "symbol not defined: %s", v.String())
+		},
+	}
+	c.vf = validatorFromEncoder(c.ef)
+	if err := registerNamed(st.name, st.defs, friendlyName, nm, enclosingNamespace, schema, c); err != nil {
+		return nil, err
 	}
-	st.name[nm.n] = c
 	return c, nil
 }
 
@@ -556,18 +1516,23 @@ func (st symtab) makeFixedCodec(enclosingNamespace string, schema interface{}) (
 	if !ok {
 		return nil, newCodecBuildError(friendlyName, "size ought to be number: %T", s)
 	}
+	if fs < 0 || fs > math.MaxInt32 {
+		return nil, newCodecBuildError(friendlyName, "size ought to be between 0 and %d: %v", math.MaxInt32, fs)
+	}
 	size := int32(fs)
 	c := &codec{
 		nm: nm,
 		df: func(r io.Reader) (interface{}, error) {
+			if buf, ok := st.opts.readAliased(r, int64(size)); ok {
+				return Fixed{Name: nm.n, Value: buf}, nil
+			}
 			buf := make([]byte, size)
-			n, err := r.Read(buf)
-			if err != nil {
+			if _, err := io.ReadFull(r, buf); err != nil {
+				if err == io.ErrUnexpectedEOF {
+					return nil, newDecoderError(friendlyName, "buffer underrun")
+				}
 				return nil, newDecoderError(friendlyName, err)
 			}
-			if n < int(size) {
-				return nil, newDecoderError(friendlyName, "buffer underrun")
-			}
 			return Fixed{Name: nm.n, Value: buf}, nil
 		},
 		ef: func(w io.Writer, datum interface{}) error {
@@ -587,11 +1552,95 @@ func (st symtab) makeFixedCodec(enclosingNamespace string, schema interface{}) (
 			}
 			return nil
 		},
+		nf: func(v reflect.Value) (interface{}, error) {
+			v = indirectNative(v)
+			if someFixed, ok := nativeInterface(v).(Fixed); ok {
+				return someFixed, nil
+			}
+			buf, ok := nativeBytes(v)
+			if !ok {
+				return nil, newEncoderError(friendlyName, "expected: Fixed or bytes; received: %s", nativeTypeName(v))
+			}
+			if len(buf) != int(size) {
+				return nil, newEncoderError(friendlyName, "expected: %d bytes; received: %d", size, len(buf))
+			}
+			return Fixed{Name: nm.n, Value: buf}, nil
+		},
+	}
+	c.vf = validatorFromEncoder(c.ef)
+	if precision, scale, ok := decimalSchema(schemaMap, int(size)); ok {
+		c = decimalCodec(st.opts, c, friendlyName, precision, scale, int(size))
+	}
+	if err := registerNamed(st.name, st.defs, friendlyName, nm, enclosingNamespace, schema, c); err != nil {
+		return nil, err
 	}
-	st.name[nm.n] = c
 	return c, nil
 }
 
+// registerNamed records c, the codec of a named type, under the
+// fullname of the type and the fullnames of its aliases, so that later
+// references to the type by any of them resolve to c. Aliases are
+// relative to the namespace of the type. A type may be defined more
+// than once only when each definition has the same Parsing Canonical
+// Form.
+func registerNamed(names map[string]*codec, defs map[string]namedDefinition, friendlyName string, nm *name, enclosingNamespace string, schema interface{}, c *codec) error {
+	definition := namedDefinition{enclosingNamespace, schema}
+	if existing, ok := defs[nm.n]; ok && existing.canonical() != definition.canonical() {
+		return newCodecBuildError(friendlyName, "named type redefined differently: %s", nm.n)
+	}
+	names[nm.n] = c
+	defs[nm.n] = definition
+	aliases, err := namedAliases(nm, schema)
+	if err != nil {
+		return newCodecBuildError(friendlyName, err)
+	}
+	for _, alias := range aliases {
+		if existing, ok := names[alias]; ok && existing != c {
+			return newCodecBuildError(friendlyName, "alias conflicts with named type: %s", alias)
+		}
+		names[alias] = c
+		defs[alias] = namedDefinition{enclosingNamespace, schema}
+	}
+	return nil
+}
+
+// namedAliases returns the fullnames of the aliases of the named type
+// nm, whose schema is the specified one.
+func namedAliases(nm *name, schema interface{}) ([]string, error) {
+	schemaMap, _ := schema.(map[string]interface{})
+	val, ok := schemaMap["aliases"]
+	if !ok {
+		return nil, nil
+	}
+	aliases, ok := stringsFromArray(val)
+	if !ok {
+		return nil, fmt.Errorf("aliases ought to be array of strings")
+	}
+	fullnames := make([]string, len(aliases))
+	for idx, alias := range aliases {
+		an, err := newName(nameName(alias), nameEnclosingNamespace(nm.namespace()))
+		if err != nil {
+			return nil, err
+		}
+		fullnames[idx] = an.n
+	}
+	return fullnames, nil
+}
+
+// scalarRecordWriters pools the buffers records whose fields are all
+// primitives are encoded into before being written.
+var scalarRecordWriters = sync.Pool{New: func() interface{} { return new(appendWriter) }}
+
+// isPrimitiveCodec returns whether c is the codec of one of the
+// primitive types.
+func (st symtab) isPrimitiveCodec(c *codec) bool {
+	switch c {
+	case st.nullCodec, st.booleanCodec, st.intCodec, st.longCodec, st.floatCodec, st.doubleCodec, st.bytesCodec, st.stringCodec:
+		return true
+	}
+	return false
+}
+
 func (st symtab) makeRecordCodec(enclosingNamespace string, schema interface{}) (*codec, error) {
 	errorNamespace := "null namespace"
 	if enclosingNamespace != nullNamespace {
@@ -610,56 +1659,258 @@ func (st symtab) makeRecordCodec(enclosingNamespace string, schema interface{})
 	}
 
 	fieldCodecs := make([]*codec, len(recordTemplate.Fields))
+	fieldCodecMap := make(map[string]*codec, len(recordTemplate.Fields))
 	for idx, field := range recordTemplate.Fields {
 		var err error
+		if _, ok := fieldCodecMap[name{n: field.Name}.basename()]; ok {
+			return nil, newCodecBuildError(friendlyName, "duplicate field name: %s", name{n: field.Name}.basename())
+		}
 		fieldCodecs[idx], err = st.buildCodec(recordTemplate.n.namespace(), field.schema)
 		if err != nil {
-			return nil, newCodecBuildError(friendlyName, "record field ought to be codec: %+v", st, err)
+			return nil, newCodecBuildPathError(friendlyName, name{n: field.Name}.basename(), err, "record field ought to be codec")
 		}
+		fieldCodecMap[name{n: field.Name}.basename()] = fieldCodecs[idx]
 	}
 
 	friendlyName = fmt.Sprintf("record (%s)", recordTemplate.Name)
 
-	c := &codec{
+	// convert defaults to data the field codecs accept, such as *Record
+	// for a record default; a default that does not convert is left as
+	// parsed, for the field codec to report should it be used, except
+	// for unions, whose defaults ought to match their first member
+	for _, field := range recordTemplate.Fields {
+		fieldSchema := field.schema.(map[string]interface{})
+		if val, ok := fieldSchema["default"]; ok {
+			defval, err := nativeDefault(st.defs, recordTemplate.n.namespace(), fieldSchema["type"], val)
+			if err == nil {
+				field.defval = defval
+			} else if _, isUnion := fieldSchema["type"].([]interface{}); isUnion {
+				return nil, newCodecBuildPathError(friendlyName, name{n: field.Name}.basename(), &ErrSchemaParse{"union default ought to match first member", err})
+			}
+		}
+	}
+	converterKeys := fieldConverterKeys(recordTemplate)
+
+	// records are decoded into, and built from maps and structs as,
+	// copies of a blank record, rather than records NewRecord parses
+	// from the schema anew
+	blank, _ := NewRecord(recordSchemaRaw(schema), RecordEnclosingNamespace(enclosingNamespace))
+	keys := recordMapKeys(blank)
+
+	// records whose fields are all primitives, however their types are
+	// written, encode and decode each field with its codec directly,
+	// skipping the bookkeeping that fields of complex types need
+	scalar := !st.opts.generalRecords
+	fieldEncoders := make([]encoderFunction, len(fieldCodecs))
+	for idx, fieldCodec := range fieldCodecs {
+		scalar = scalar && st.isPrimitiveCodec(fieldCodec)
+		fieldEncoders[idx] = fieldCodec.ef
+	}
+	encodeScalarFields := func(w io.Writer, someRecord *Record) error {
+		out := w
+		var aw *appendWriter
+		if _, ok := w.(ByteWriter); !ok {
+			// gather the fields, so that w sees one write, rather than a
+			// write and a temporary buffer for each field
+			aw = scalarRecordWriters.Get().(*appendWriter)
+			defer scalarRecordWriters.Put(aw)
+			aw.buf = aw.buf[:0]
+			out = aw
+		}
+		for idx, field := range someRecord.Fields {
+			value := field.Datum
+			if value == nil {
+				template := recordTemplate.Fields[idx]
+				if !template.hasDefault {
+					return newEncoderError(friendlyName, "field has no data and no default set: %v", field.Name)
+				}
+				value = template.defval
+			}
+			if err := fieldEncoders[idx](out, value); err != nil {
+				return newEncoderPathError(friendlyName, name{n: field.Name}.basename(), fieldCodecs[idx].encoderError(err))
+			}
+		}
+		if aw != nil {
+			if _, err := w.Write(aw.buf); err != nil {
+				return newEncoderError(friendlyName, err)
+			}
+		}
+		return nil
+	}
+
+	// structFields maps struct types to the index of the struct field for
+	// each record field, or -1 when the record field default applies.
+	var structFields sync.Map
+	structFieldIndexes := func(t reflect.Type) ([]int, error) {
+		if indexes, ok := structFields.Load(t); ok {
+			return indexes.([]int), nil
+		}
+		indexes := make([]int, len(recordTemplate.Fields))
+		for idx, field := range recordTemplate.Fields {
+			basename := name{n: field.Name}.basename()
+			indexes[idx] = structFieldIndex(t, basename)
+			if indexes[idx] == -1 && !field.hasDefault {
+				return nil, newEncoderError(friendlyName, "%s has no field for %s, which has no default", t, basename)
+			}
+		}
+		structFields.Store(t, indexes)
+		return indexes, nil
+	}
+
+	c := reusing(&codec{
 		nm: recordTemplate.n,
-		df: func(r io.Reader) (interface{}, error) {
-			someRecord, _ := NewRecord(recordSchemaRaw(schema), RecordEnclosingNamespace(enclosingNamespace))
+		fc: fieldCodecMap,
+		rdf: func(r io.Reader, old interface{}) (interface{}, error) {
+			if err := st.opts.descend(r); err != nil {
+				return nil, newDecoderError(friendlyName, err)
+			}
+			defer ascend(r)
+			if st.opts.recordsAsMaps {
+				fields := make(OrderedMap, len(fieldCodecs))
+				for idx, codec := range fieldCodecs {
+					basename := name{n: recordTemplate.Fields[idx].Name}.basename()
+					value, err := codec.Decode(r)
+					if err == nil {
+						value, err = st.opts.convertField(converterKeys[idx], value)
+					}
+					if err != nil {
+						return nil, newDecoderPathError(friendlyName, basename, err)
+					}
+					fields[idx] = KeyVal{basename, value}
+				}
+				return fields, nil
+			}
+			someRecord, ok := old.(*Record)
+			if !ok || someRecord.Name != recordTemplate.Name || len(someRecord.Fields) != len(fieldCodecs) {
+				someRecord = blank.shallowCopy()
+			}
+			if scalar {
+				cr, ok := r.(*countingReader)
+				if !ok {
+					cr = &countingReader{r: r}
+				}
+				for idx, codec := range fieldCodecs {
+					start := cr.n
+					value, err := codec.df(cr)
+					if err != nil {
+						err = codec.decoderError(cr, start, err)
+					} else {
+						value, err = st.opts.convertField(converterKeys[idx], value)
+					}
+					if err != nil {
+						return nil, newDecoderPathError(friendlyName, name{n: someRecord.Fields[idx].Name}.basename(), err)
+					}
+					someRecord.Fields[idx].Datum = value
+				}
+				return someRecord, nil
+			}
 			for idx, codec := range fieldCodecs {
-				value, err := codec.Decode(r)
+				value, err := codec.decode(r, someRecord.Fields[idx].Datum)
+				if err == nil {
+					value, err = st.opts.convertField(converterKeys[idx], value)
+				}
 				if err != nil {
-					return nil, newDecoderError(friendlyName, err)
+					return nil, newDecoderPathError(friendlyName, name{n: someRecord.Fields[idx].Name}.basename(), err)
 				}
 				someRecord.Fields[idx].Datum = value
 			}
 			return someRecord, nil
 		},
 		ef: func(w io.Writer, datum interface{}) error {
-			someRecord, ok := datum.(*Record)
+			someRecord, ok, err := recordFromDatum(blank, keys, datum, st.opts.ignoreUnknownFields)
+			if err != nil {
+				return newEncoderError(friendlyName, err)
+			}
 			if !ok {
-				return newEncoderError(friendlyName, "expected: Record; received: %T", datum)
+				return newEncoderError(friendlyName, "expected: Record or map[string]interface{}; received: %T", datum)
 			}
 			if someRecord.Name != recordTemplate.Name {
 				return newEncoderError(friendlyName, "expected: %v; received: %v", recordTemplate.Name, someRecord.Name)
 			}
+			if err = checkRecordFields(recordTemplate, someRecord); err != nil {
+				return newEncoderError(friendlyName, err)
+			}
+			if scalar {
+				return encodeScalarFields(w, someRecord)
+			}
 			for idx, field := range someRecord.Fields {
 				var value interface{}
 				// check whether field datum is valid
 				if reflect.ValueOf(field.Datum).IsValid() {
 					value = field.Datum
-				} else if field.hasDefault {
-					value = field.defval
+				} else if template := recordTemplate.Fields[idx]; template.hasDefault {
+					value = template.defval
 				} else {
 					return newEncoderError(friendlyName, "field has no data and no default set: %v", field.Name)
 				}
 				err = fieldCodecs[idx].Encode(w, value)
 				if err != nil {
-					return newEncoderError(friendlyName, err)
+					return newEncoderPathError(friendlyName, name{n: field.Name}.basename(), err)
 				}
 			}
 			return nil
 		},
+		vf: func(path string, datum interface{}) error {
+			someRecord, ok, err := recordFromDatum(blank, keys, datum, st.opts.ignoreUnknownFields)
+			if err != nil {
+				return newValidationError(path, friendlyName, err)
+			}
+			if !ok {
+				return newValidationError(path, friendlyName, "expected: Record or map[string]interface{}; received: %T", datum)
+			}
+			if someRecord.Name != recordTemplate.Name {
+				return newValidationError(path, friendlyName, "expected: %v; received: %v", recordTemplate.Name, someRecord.Name)
+			}
+			if len(someRecord.Fields) != len(fieldCodecs) {
+				return newValidationError(path, friendlyName, "expected: %d fields; received: %d", len(fieldCodecs), len(someRecord.Fields))
+			}
+			for idx, field := range someRecord.Fields {
+				var value interface{}
+				childPath := fieldPath(path, name{n: field.Name}.basename())
+				// check whether field datum is valid
+				if reflect.ValueOf(field.Datum).IsValid() {
+					value = field.Datum
+				} else if template := recordTemplate.Fields[idx]; template.hasDefault {
+					value = template.defval
+				} else {
+					return newValidationError(childPath, friendlyName, "field has no data and no default set: %v", field.Name)
+				}
+				if err := fieldCodecs[idx].vf(childPath, value); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		nf: func(v reflect.Value) (interface{}, error) {
+			switch someRecord := nativeInterface(v).(type) {
+			case *Record, map[string]interface{}, OrderedMap:
+				return someRecord, nil
+			}
+			v = indirectNative(v)
+			if !v.IsValid() || v.Kind() != reflect.Struct {
+				return nil, newEncoderError(friendlyName, "expected: struct; received: %s", nativeTypeName(v))
+			}
+			indexes, err := structFieldIndexes(v.Type())
+			if err != nil {
+				return nil, err
+			}
+			someRecord := blank.shallowCopy()
+			for idx, field := range someRecord.Fields {
+				if indexes[idx] == -1 {
+					continue // encoder uses field default
+				}
+				datum, err := fieldCodecs[idx].nf(v.Field(indexes[idx]))
+				if err != nil {
+					return nil, newEncoderPathError(friendlyName, name{n: field.Name}.basename(), err)
+				}
+				field.Datum = datum
+			}
+			return someRecord, nil
+		},
+	})
+	if err := registerNamed(st.name, st.defs, friendlyName, recordTemplate.n, enclosingNamespace, schema, c); err != nil {
+		return nil, err
 	}
-	st.name[recordTemplate.Name] = c
 	return c, nil
 }
 
@@ -681,33 +1932,35 @@ func (st symtab) makeMapCodec(enclosingNamespace string, schema interface{}) (*c
 	}
 	valuesCodec, err := st.buildCodec(enclosingNamespace, v)
 	if err != nil {
-		return nil, newCodecBuildError(friendlyName, err)
+		return nil, newCodecBuildPathError(friendlyName, "", err)
 	}
 
 	nm := &name{n: "map"}
 	friendlyName = fmt.Sprintf("map (%s)", nm.n)
 
-	return &codec{
+	return reusing(&codec{
 		nm: nm,
-		df: func(r io.Reader) (interface{}, error) {
-			data := make(map[string]interface{})
-			someValue, err := longDecoder(r)
+		rdf: func(r io.Reader, old interface{}) (interface{}, error) {
+			if err := st.opts.descend(r); err != nil {
+				return nil, newDecoderError(friendlyName, err)
+			}
+			defer ascend(r)
+			data, ok := old.(map[string]interface{})
+			if ok {
+				for key := range data {
+					delete(data, key)
+				}
+			} else {
+				data = make(map[string]interface{})
+			}
+			blockCount, err := readBlockCount(r)
 			if err != nil {
 				return nil, newDecoderError(friendlyName, err)
 			}
-			blockCount := someValue.(int64)
 
 			for blockCount != 0 {
-				if blockCount < 0 {
-					blockCount = -blockCount
-					// next long is size of block, for which we have no use
-					_, err := longDecoder(r)
-					if err != nil {
-						return nil, newDecoderError(friendlyName, err)
-					}
-				}
 				for i := int64(0); i < blockCount; i++ {
-					someValue, err := stringDecoder(r)
+					someValue, err := decodeString(st.opts, r)
 					if err != nil {
 						return nil, newDecoderError(friendlyName, err)
 					}
@@ -717,16 +1970,15 @@ func (st symtab) makeMapCodec(enclosingNamespace string, schema interface{}) (*c
 					}
 					datum, err := valuesCodec.df(r)
 					if err != nil {
-						return nil, err
+						return nil, newDecoderPathError(friendlyName, itemPath("", mapKey), err)
 					}
 					data[mapKey] = datum
 				}
 				// decode next blockcount
-				someValue, err = longDecoder(r)
+				blockCount, err = readBlockCount(r)
 				if err != nil {
 					return nil, newDecoderError(friendlyName, err)
 				}
-				blockCount = someValue.(int64)
 			}
 			return data, nil
 		},
@@ -735,15 +1987,45 @@ func (st symtab) makeMapCodec(enclosingNamespace string, schema interface{}) (*c
 			if !ok {
 				return newEncoderError(friendlyName, "expected: map[string]interface{}; received: %T", datum)
 			}
+			var err error
 			if len(dict) > 0 {
-				if err = longEncoder(w, int64(len(dict))); err != nil {
+				out := w
+				var block *bytes.Buffer
+				if st.opts.blockSizes {
+					block = new(bytes.Buffer)
+					out = block
+				} else if err = longEncoder(w, int64(len(dict))); err != nil {
 					return newEncoderError(friendlyName, err)
 				}
-				for k, v := range dict {
-					if err = stringEncoder(w, k); err != nil {
+				encodeEntry := func(k string, v interface{}) error {
+					if err := stringEncoder(out, k); err != nil {
 						return newEncoderError(friendlyName, err)
 					}
-					if err = valuesCodec.ef(w, v); err != nil {
+					if err := valuesCodec.ef(out, v); err != nil {
+						return newEncoderPathError(friendlyName, itemPath("", k), err)
+					}
+					return nil
+				}
+				if st.opts.sortMapKeys {
+					keys := make([]string, 0, len(dict))
+					for k := range dict {
+						keys = append(keys, k)
+					}
+					sort.Strings(keys)
+					for _, k := range keys {
+						if err = encodeEntry(k, dict[k]); err != nil {
+							return err
+						}
+					}
+				} else {
+					for k, v := range dict {
+						if err = encodeEntry(k, v); err != nil {
+							return err
+						}
+					}
+				}
+				if block != nil {
+					if err = writeSizedBlock(w, len(dict), block.Bytes()); err != nil {
 						return newEncoderError(friendlyName, err)
 					}
 				}
@@ -753,7 +2035,34 @@ func (st symtab) makeMapCodec(enclosingNamespace string, schema interface{}) (*c
 			}
 			return nil
 		},
-	}, nil
+		vf: func(path string, datum interface{}) error {
+			dict, ok := datum.(map[string]interface{})
+			if !ok {
+				return newValidationError(path, friendlyName, "expected: map[string]interface{}; received: %T", datum)
+			}
+			for k, v := range dict {
+				if err := valuesCodec.vf(itemPath(path, k), v); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		nf: func(v reflect.Value) (interface{}, error) {
+			v = indirectNative(v)
+			if !v.IsValid() || v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+				return nil, newEncoderError(friendlyName, "expected: map with string keys; received: %s", nativeTypeName(v))
+			}
+			dict := make(map[string]interface{}, v.Len())
+			for _, key := range v.MapKeys() {
+				datum, err := valuesCodec.nf(v.MapIndex(key))
+				if err != nil {
+					return nil, newEncoderPathError(friendlyName, itemPath("", key.String()), err)
+				}
+				dict[key.String()] = datum
+			}
+			return dict, nil
+		},
+	}), nil
 }
 
 func (st symtab) makeArrayCodec(enclosingNamespace string, schema interface{}) (*codec, error) {
@@ -774,71 +2083,112 @@ func (st symtab) makeArrayCodec(enclosingNamespace string, schema interface{}) (
 	}
 	valuesCodec, err := st.buildCodec(enclosingNamespace, v)
 	if err != nil {
-		return nil, newCodecBuildError(friendlyName, err)
+		return nil, newCodecBuildPathError(friendlyName, "", err)
 	}
 
-	const itemsPerArrayBlock = 10
 	nm := &name{n: "array"}
 	friendlyName = fmt.Sprintf("array (%s)", nm.n)
 
-	return &codec{
+	return reusing(&codec{
 		nm: nm,
-		df: func(r io.Reader) (interface{}, error) {
-			var data []interface{}
+		ic: valuesCodec,
+		rdf: func(r io.Reader, old interface{}) (interface{}, error) {
+			if err := st.opts.descend(r); err != nil {
+				return nil, newDecoderError(friendlyName, err)
+			}
+			defer ascend(r)
+			data, _ := old.([]interface{})
+			data = data[:0]
 
-			someValue, err := longDecoder(r)
+			blockCount, err := readBlockCount(r)
 			if err != nil {
 				return nil, newDecoderError(friendlyName, err)
 			}
-			blockCount := someValue.(int64)
 
 			for blockCount != 0 {
-				if blockCount < 0 {
-					blockCount = -blockCount
-					// read and discard number of bytes in block
-					_, err = longDecoder(r)
-					if err != nil {
-						return nil, newDecoderError(friendlyName, err)
-					}
-				}
 				for i := int64(0); i < blockCount; i++ {
-					datum, err := valuesCodec.df(r)
+					var previous interface{}
+					if len(data) < cap(data) {
+						previous = data[:len(data)+1][len(data)]
+					}
+					offset := itemOffset(r)
+					datum, err := valuesCodec.decodeReusing(r, previous)
+					if err == nil {
+						err = checkItem(r, offset)
+					}
 					if err != nil {
-						return nil, newDecoderError(friendlyName, err)
+						return nil, newDecoderPathError(friendlyName, itemPath("", len(data)), err)
 					}
 					data = append(data, datum)
 				}
-				someValue, err = longDecoder(r)
+				blockCount, err = readBlockCount(r)
 				if err != nil {
 					return nil, newDecoderError(friendlyName, err)
 				}
-				blockCount = someValue.(int64)
 			}
 			return data, nil
 		},
 		ef: func(w io.Writer, datum interface{}) error {
+			blockSize := st.opts.blockSize()
 			someArray, ok := datum.([]interface{})
 			if !ok {
-				return newEncoderError(friendlyName, "expected: []interface{}; received: %T", datum)
+				count, item, ok := typedSlice(datum)
+				if !ok {
+					return newEncoderError(friendlyName, "expected: []interface{}; received: %T", datum)
+				}
+				// box one block of items at a time
+				block := make([]interface{}, 0, blockSize)
+				for leftIndex := 0; leftIndex < count; leftIndex += blockSize {
+					block = block[:0]
+					for idx := leftIndex; idx < count && idx < leftIndex+blockSize; idx++ {
+						block = append(block, item(idx))
+					}
+					if err := writeArrayBlock(w, friendlyName, st.opts, valuesCodec, block, leftIndex); err != nil {
+						return err
+					}
+				}
+				return longEncoder(w, int64(0))
 			}
-			for leftIndex := 0; leftIndex < len(someArray); leftIndex += itemsPerArrayBlock {
-				rightIndex := leftIndex + itemsPerArrayBlock
+			for leftIndex := 0; leftIndex < len(someArray); leftIndex += blockSize {
+				rightIndex := leftIndex + blockSize
 				if rightIndex > len(someArray) {
 					rightIndex = len(someArray)
 				}
-				items := someArray[leftIndex:rightIndex]
-				err = longEncoder(w, int64(len(items)))
-				if err != nil {
-					return newEncoderError(friendlyName, err)
-				}
-				for _, item := range items {
-					err = valuesCodec.ef(w, item)
-					if err != nil {
-						return newEncoderError(friendlyName, err)
-					}
+				if err := writeArrayBlock(w, friendlyName, st.opts, valuesCodec, someArray[leftIndex:rightIndex], leftIndex); err != nil {
+					return err
 				}
 			}
 			return longEncoder(w, int64(0))
 		},
-	}, nil
+		vf: func(path string, datum interface{}) error {
+			someArray, ok := datum.([]interface{})
+			count, item := len(someArray), func(i int) interface{} { return someArray[i] }
+			if !ok {
+				if count, item, ok = typedSlice(datum); !ok {
+					return newValidationError(path, friendlyName, "expected: []interface{}; received: %T", datum)
+				}
+			}
+			for idx := 0; idx < count; idx++ {
+				if err := valuesCodec.vf(itemPath(path, idx), item(idx)); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		nf: func(v reflect.Value) (interface{}, error) {
+			v = indirectNative(v)
+			if !v.IsValid() || (v.Kind() != reflect.Slice && v.Kind() != reflect.Array) {
+				return nil, newEncoderError(friendlyName, "expected: slice or array; received: %s", nativeTypeName(v))
+			}
+			items := make([]interface{}, v.Len())
+			for idx := range items {
+				datum, err := valuesCodec.nf(v.Index(idx))
+				if err != nil {
+					return nil, newEncoderPathError(friendlyName, itemPath("", idx), err)
+				}
+				items[idx] = datum
+			}
+			return items, nil
+		},
+	}), nil
 }
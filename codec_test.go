@@ -23,11 +23,15 @@ import (
 	"bytes"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"io"
 	"io/ioutil"
 	"math"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 ////////////////////////////////////////
@@ -179,6 +183,46 @@ func checkCodecRoundTripLong(t *testing.T, number int64) {
 
 ////////////////////////////////////////
 
+func TestCodecSchemaErrorsUnwrap(t *testing.T) {
+	_, err := NewCodec(`{"type":`)
+	var se *json.SyntaxError
+	if !errors.As(err, &se) {
+		t.Errorf("Actual: %#v; Expected: %T", err, se)
+	}
+
+	_, err = NewCodec(`{"type":"record","name":"Foo","fields":[{"name":"field1","type":"flubber"}]}`)
+	var eb *ErrCodecBuild
+	if !errors.As(err, &eb) {
+		t.Fatalf("Actual: %#v; Expected: %T", err, eb)
+	}
+	if errors.Unwrap(eb) == nil {
+		t.Errorf("Actual: %#v; Expected: wrapped cause", errors.Unwrap(eb))
+	}
+}
+
+func TestCodecCodingErrorsUnwrap(t *testing.T) {
+	codec, err := NewCodec(`{"type":"record","name":"r","fields":[{"name":"a","type":"long"},{"name":"b","type":"string"}]}`)
+	checkErrorFatal(t, err, nil)
+	// a record truncated within its second field
+	_, err = codec.Decode(bytes.NewReader([]byte("\x02\x0aab")))
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Actual: %#v; Expected: %#v", err, io.ErrUnexpectedEOF)
+	}
+
+	sentinel := errors.New("sentinel")
+	err = codec.Encode(failingWriter{sentinel}, map[string]interface{}{"a": int64(1), "b": "x"})
+	if !errors.Is(err, sentinel) {
+		t.Errorf("Actual: %#v; Expected: %#v", err, sentinel)
+	}
+}
+
+// failingWriter returns its error from every call to Write.
+type failingWriter struct {
+	err error
+}
+
+func (w failingWriter) Write([]byte) (int, error) { return 0, w.err }
+
 func TestCodecRoundTrip(t *testing.T) {
 	// null
 	checkCodecRoundTrip(t, `"null"`, nil)
@@ -241,11 +285,11 @@ func TestCodecDecoderPrimitives(t *testing.T) {
 	checkCodecDecoderResult(t, `"null"`, []byte("\x01"), nil)
 	// boolean
 	checkCodecDecoderError(t, `"boolean"`, []byte("\x02"), "cannot decode boolean")
-	checkCodecDecoderError(t, `"boolean"`, []byte(""), "cannot decode boolean: EOF")
+	checkCodecDecoderError(t, `"boolean"`, []byte(""), io.EOF)
 	checkCodecDecoderResult(t, `"boolean"`, []byte("\x00"), false)
 	checkCodecDecoderResult(t, `"boolean"`, []byte("\x01"), true)
 	// int
-	checkCodecDecoderError(t, `"int"`, []byte(""), "cannot decode int: EOF")
+	checkCodecDecoderError(t, `"int"`, []byte(""), io.EOF)
 	checkCodecDecoderResult(t, `"int"`, []byte("\x00"), int32(0))
 	checkCodecDecoderResult(t, `"int"`, []byte("\x05"), int32(-3))
 	checkCodecDecoderResult(t, `"int"`, []byte("\x06"), int32(3))
@@ -255,7 +299,7 @@ func TestCodecDecoderPrimitives(t *testing.T) {
 	checkCodecDecoderResult(t, `"int"`, []byte("\x88\x88\x08"), int32(66052))
 	checkCodecDecoderResult(t, `"int"`, []byte("\x88\x88\x88\x08"), int32(8454660))
 	// long
-	checkCodecDecoderError(t, `"long"`, []byte(""), "cannot decode long: EOF")
+	checkCodecDecoderError(t, `"long"`, []byte(""), io.EOF)
 	checkCodecDecoderResult(t, `"long"`, []byte("\x00"), int64(0))
 	checkCodecDecoderResult(t, `"long"`, []byte("\x05"), int64(-3))
 	checkCodecDecoderResult(t, `"long"`, []byte("\x06"), int64(3))
@@ -269,25 +313,25 @@ func TestCodecDecoderPrimitives(t *testing.T) {
 	checkCodecDecoderResult(t, `"long"`, []byte("\x88\x88\x88\x88\x88\x88\x88\x08"), int64(2269530520879620))
 	checkCodecDecoderResult(t, `"long"`, []byte("\x9f\xdf\x9f\x8f\xc7\xde\xde\x83\x99\x01"), int64(-5513458701470791632)) // https://github.com/linkedin/goavro/issues/49
 	// float
-	checkCodecDecoderError(t, `"float"`, []byte(""), "cannot decode float: EOF")
+	checkCodecDecoderError(t, `"float"`, []byte(""), io.EOF)
 	checkCodecDecoderResult(t, `"float"`, []byte("\x00\x00\x60\x40"), float32(3.5))
 	checkCodecDecoderResult(t, `"float"`, []byte("\x00\x00\x80\u007f"), float32(math.Inf(1)))
 	checkCodecDecoderResult(t, `"float"`, []byte("\x00\x00\x80\xff"), float32(math.Inf(-1)))
 	// double
-	checkCodecDecoderError(t, `"double"`, []byte(""), "cannot decode double: EOF")
+	checkCodecDecoderError(t, `"double"`, []byte(""), io.EOF)
 	checkCodecDecoderResult(t, `"double"`, []byte("\x00\x00\x00\x00\x00\x00\f@"), float64(3.5))
 	checkCodecDecoderResult(t, `"double"`, []byte("\x00\x00\x00\x00\x00\x00\xf0\u007f"), float64(math.Inf(1)))
 	checkCodecDecoderResult(t, `"double"`, []byte("\x00\x00\x00\x00\x00\x00\xf0\xff"), float64(math.Inf(-1)))
 	// bytes
-	checkCodecDecoderError(t, `"bytes"`, []byte(""), "cannot decode bytes: cannot decode long: EOF")
+	checkCodecDecoderError(t, `"bytes"`, []byte(""), io.EOF)
 	checkCodecDecoderError(t, `"bytes"`, []byte("\x01"), "cannot decode bytes: negative length: -1")
-	checkCodecDecoderError(t, `"bytes"`, []byte("\x02"), "cannot decode bytes: EOF")
+	checkCodecDecoderError(t, `"bytes"`, []byte("\x02"), "cannot decode bytes: unexpected EOF")
 	checkCodecDecoderResult(t, `"bytes"`, []byte("\x00"), []byte(""))
 	checkCodecDecoderResult(t, `"bytes"`, []byte("\x14some bytes"), []byte("some bytes"))
 	// string
-	checkCodecDecoderError(t, `"string"`, []byte(""), "cannot decode string: cannot decode long: EOF")
+	checkCodecDecoderError(t, `"string"`, []byte(""), io.EOF)
 	checkCodecDecoderError(t, `"string"`, []byte("\x01"), "cannot decode string: negative length: -1")
-	checkCodecDecoderError(t, `"string"`, []byte("\x02"), "cannot decode string: EOF")
+	checkCodecDecoderError(t, `"string"`, []byte("\x02"), "cannot decode string: unexpected EOF")
 	checkCodecDecoderResult(t, `"string"`, []byte("\x00"), "")
 	checkCodecDecoderResult(t, `"string"`, []byte("\x16some string"), "some string")
 }
@@ -392,6 +436,39 @@ func TestCodecUnionChecksSchema(t *testing.T) {
 	checkErrorFatal(t, err, "member ought to be decodable")
 }
 
+func TestCodecUnionNested(t *testing.T) {
+	for _, build := range []func(string, ...CodecSetter) (Codec, error){NewCodec, NewJSONCodec} {
+		_, err := build(`["int",["long","string"]]`)
+		checkError(t, err, "member ought not to be union: 1")
+		// a union within an array within a union is fine
+		_, err = build(`["int",{"type":"array","items":["long","string"]}]`)
+		checkError(t, err, nil)
+	}
+}
+
+func TestCodecUnionDuplicateMembers(t *testing.T) {
+	for _, schema := range []string{
+		`["int","int"]`,
+		`["null","string",{"type":"string"}]`,
+		`["int",{"type":"int","logicalType":"date"}]`,
+		`[{"type":"map","values":"int"},{"type":"map","values":"string"}]`,
+		`[{"type":"array","items":"int"},{"type":"array","items":"int"}]`,
+		`[{"type":"fixed","name":"f","size":1},"f"]`,
+		`[{"type":"record","name":"com.example.r","fields":[{"name":"a","type":"int"}]},"com.example.r"]`,
+	} {
+		for _, build := range []func(string, ...CodecSetter) (Codec, error){NewCodec, NewJSONCodec} {
+			_, err := build(schema)
+			checkError(t, err, "duplicate member type")
+		}
+	}
+
+	// named types of distinct names may share a type
+	for _, build := range []func(string, ...CodecSetter) (Codec, error){NewCodec, NewJSONCodec} {
+		_, err := build(`[{"type":"fixed","name":"f","size":1},{"type":"fixed","name":"g","size":1},{"type":"fixed","name":"x.f","size":1}]`)
+		checkError(t, err, nil)
+	}
+}
+
 func TestCodecUnionPrimitives(t *testing.T) {
 	// null
 	checkCodecEncoderResult(t, `["null"]`, nil, []byte("\x00"))
@@ -422,6 +499,21 @@ func TestCodecUnionPrimitives(t *testing.T) {
 	checkCodecEncoderResult(t, `["string","float"]`, "filibuster", []byte("\x00\x14filibuster"))
 }
 
+func TestCodecUnionSingleMember(t *testing.T) {
+	checkCodecEncoderResult(t, `["int"]`, int32(3), []byte("\x00\x06"))
+	checkCodecEncoderResult(t, `["int"]`, Union("int", int32(3)), []byte("\x00\x06"))
+	checkCodecRoundTrip(t, `["int"]`, int32(3))
+	checkCodecEncoderError(t, `["int"]`, "three", "cannot encode union (union): datum ought match schema")
+	checkCodecEncoderError(t, `["int"]`, nil, "cannot encode union (union): datum ought match schema")
+	checkCodecDecoderError(t, `["int"]`, []byte("\x02\x06"), "index must be between 0 and 0; read index: 1")
+	checkCodecDecoderError(t, `["int"]`, []byte("\x01\x06"), "index must be between 0 and 0; read index: -1")
+	checkCodecRoundTrip(t, `[{"type":"array","items":"int"}]`, []interface{}{int32(1)})
+
+	checkCodecJSONEncoderResult(t, `["int"]`, int32(3), []byte(`{"int":3}`))
+	checkCodecJSONDecoderResult(t, `["int"]`, []byte(`{"int":3}`), int32(3))
+	checkCodecJSONDecoderError(t, `["int"]`, []byte(`{"long":3}`), "long")
+}
+
 func TestCodecDecoderUnion(t *testing.T) {
 	checkCodecDecoderResult(t, `["string","float"]`, []byte("\x00\x14filibuster"), "filibuster")
 	checkCodecDecoderResult(t, `["string","int"]`, []byte("\x02\x1a"), int32(13))
@@ -468,7 +560,7 @@ func TestCodecDecoderUnionErrorYieldsName(t *testing.T) {
 }
 `
 	bits := []byte("\x04")
-	checkCodecDecoderError(t, schema, bits, "cannot encode union (union): index must be between 0 and 1; read index: 2")
+	checkCodecDecoderError(t, schema, bits, "cannot decode union (union): index must be between 0 and 1; read index: 2")
 }
 
 func TestCodecEncoderUnionRecord(t *testing.T) {
@@ -517,6 +609,7 @@ func TestCodecEncoderEnum(t *testing.T) {
 	schema := `{"type":"enum","name":"cards","symbols":["HEARTS","DIAMONDS","SPADES","CLUBS"]}`
 	checkCodecEncoderResult(t, schema, Enum{"cards", "SPADES"}, []byte("\x04"))
 	checkCodecEncoderError(t, schema, Enum{"cards", "PINEAPPLE"}, "symbol not defined")
+	checkCodecEncoderError(t, schema, Enum{Name: "cards"}, `Enum Value ought to be the symbol to encode; received empty Value with Name: "cards"`)
 	checkCodecEncoderError(t, schema, []byte("\x01"), "expected: Enum or string; received: []uint8")
 	checkCodecEncoderError(t, schema, "some symbol not in schema", "symbol not defined: some symbol not in schema")
 }
@@ -539,7 +632,7 @@ func TestCodecFixedChecksSchema(t *testing.T) {
 
 func TestCodecFixed(t *testing.T) {
 	schema := `{"type":"fixed","name":"fixed1","size":5}`
-	checkCodecDecoderError(t, schema, []byte(""), "EOF")
+	checkCodecDecoderError(t, schema, []byte(""), io.EOF)
 	checkCodecDecoderError(t, schema, []byte("hap"), "buffer underrun")
 	checkCodecEncoderError(t, schema, "happy day", "expected: Fixed; received: string")
 	checkCodecEncoderError(t, schema, Fixed{Name: "fixed1", Value: []byte("day")}, "expected: 5 bytes; received: 3")
@@ -608,7 +701,7 @@ func TestCodecEncoderArrayChecksSchema(t *testing.T) {
 
 func TestCodecDecoderArrayEOF(t *testing.T) {
 	schema := `{"type":"array","items":"string"}`
-	checkCodecDecoderError(t, schema, []byte(""), "cannot decode long: EOF")
+	checkCodecDecoderError(t, schema, []byte(""), io.EOF)
 }
 
 func TestCodecDecoderArrayEmpty(t *testing.T) {
@@ -793,7 +886,7 @@ func TestCodecMapChecksSchema(t *testing.T) {
 
 func TestCodecDecoderMapEOF(t *testing.T) {
 	schema := `{"type":"map","values":"string"}`
-	checkCodecDecoderError(t, schema, []byte(""), "cannot decode long: EOF")
+	checkCodecDecoderError(t, schema, []byte(""), io.EOF)
 }
 
 func TestCodecDecoderMapZeroBlocks(t *testing.T) {
@@ -981,6 +1074,48 @@ func TestCodecEncoderRecordWithFieldDefaultLong(t *testing.T) {
 	checkCodecEncoderResult(t, recordSchemaJSON, someRecord, bits)
 }
 
+func TestCodecFloatLittleEndian(t *testing.T) {
+	// byte sequences produced by the Avro reference implementation
+	cases := []struct {
+		datum float32
+		bits  []byte
+	}{
+		{0, []byte("\x00\x00\x00\x00")},
+		{1, []byte("\x00\x00\x80\x3f")},
+		{-2, []byte("\x00\x00\x00\xc0")},
+		{3.5, []byte("\x00\x00\x60\x40")},
+		{0.1, []byte("\xcd\xcc\xcc\x3d")},
+		{float32(math.Inf(1)), []byte("\x00\x00\x80\x7f")},
+		{math.MaxFloat32, []byte("\xff\xff\x7f\x7f")},
+		{math.SmallestNonzeroFloat32, []byte("\x01\x00\x00\x00")},
+	}
+	for _, c := range cases {
+		checkCodecEncoderResult(t, `"float"`, c.datum, c.bits)
+		checkCodecDecoderResult(t, `"float"`, c.bits, c.datum)
+	}
+}
+
+func TestCodecDoubleLittleEndian(t *testing.T) {
+	// byte sequences produced by the Avro reference implementation
+	cases := []struct {
+		datum float64
+		bits  []byte
+	}{
+		{0, []byte("\x00\x00\x00\x00\x00\x00\x00\x00")},
+		{1, []byte("\x00\x00\x00\x00\x00\x00\xf0\x3f")},
+		{-2, []byte("\x00\x00\x00\x00\x00\x00\x00\xc0")},
+		{3.5, []byte("\x00\x00\x00\x00\x00\x00\x0c\x40")},
+		{0.1, []byte("\x9a\x99\x99\x99\x99\x99\xb9\x3f")},
+		{math.Inf(-1), []byte("\x00\x00\x00\x00\x00\x00\xf0\xff")},
+		{math.MaxFloat64, []byte("\xff\xff\xff\xff\xff\xff\xef\x7f")},
+		{math.SmallestNonzeroFloat64, []byte("\x01\x00\x00\x00\x00\x00\x00\x00")},
+	}
+	for _, c := range cases {
+		checkCodecEncoderResult(t, `"double"`, c.datum, c.bits)
+		checkCodecDecoderResult(t, `"double"`, c.bits, c.datum)
+	}
+}
+
 func TestCodecEncoderRecordWithFieldDefaultFloat(t *testing.T) {
 	recordSchemaJSON := `{"type":"record","name":"Foo","fields":[{"name":"field1","type":"float","default":3.5}]}`
 	someRecord, err := NewRecord(RecordSchema(recordSchemaJSON))
@@ -999,6 +1134,15 @@ func TestCodecEncoderRecordWithFieldDefaultDouble(t *testing.T) {
 	checkCodecEncoderResult(t, recordSchemaJSON, someRecord, bits)
 }
 
+func TestCodecEncoderRecordWithFieldDefaultIntegralDouble(t *testing.T) {
+	recordSchemaJSON := `{"type":"record","name":"Foo","fields":[{"name":"field1","type":"double","default":1},{"name":"field2","type":"float","default":2},{"name":"field3","type":"long","default":3.0}]}`
+	someRecord, err := NewRecord(RecordSchema(recordSchemaJSON))
+	checkErrorFatal(t, err, nil)
+
+	bits := []byte("\x00\x00\x00\x00\x00\x00\xf0\x3f\x00\x00\x00\x40\x06")
+	checkCodecEncoderResult(t, recordSchemaJSON, someRecord, bits)
+}
+
 func TestCodecEncoderRecordWithFieldDefaultBytes(t *testing.T) {
 	recordSchemaJSON := `{"type":"record","name":"Foo","fields":[{"name":"field1","type":"int"},{"name":"field2","type":"bytes","default":"happy"}]}`
 	someRecord, err := NewRecord(RecordSchema(recordSchemaJSON))
@@ -1010,6 +1154,15 @@ func TestCodecEncoderRecordWithFieldDefaultBytes(t *testing.T) {
 	checkCodecEncoderResult(t, recordSchemaJSON, someRecord, bits)
 }
 
+func TestCodecEncoderRecordWithFieldDefaultHighBytes(t *testing.T) {
+	recordSchemaJSON := `{"type":"record","name":"Foo","fields":[{"name":"field1","type":"bytes","default":"\u00ff\u00fe"},{"name":"field2","type":{"type":"fixed","name":"two","size":2},"default":"\u0080\u0000"}]}`
+	someRecord, err := NewRecord(RecordSchema(recordSchemaJSON))
+	checkErrorFatal(t, err, nil)
+
+	bits := []byte("\x04\xff\xfe\x80\x00")
+	checkCodecEncoderResult(t, recordSchemaJSON, someRecord, bits)
+}
+
 func TestCodecEncoderRecordWithFieldDefaultString(t *testing.T) {
 	recordSchemaJSON := `{"type":"record","name":"Foo","fields":[{"name":"field1","type":"int"},{"name":"field2","type":"string","default":"happy"}]}`
 	someRecord, err := NewRecord(RecordSchema(recordSchemaJSON))
@@ -1021,6 +1174,171 @@ func TestCodecEncoderRecordWithFieldDefaultString(t *testing.T) {
 	checkCodecEncoderResult(t, recordSchemaJSON, someRecord, bits)
 }
 
+func checkCodecValidate(t *testing.T, schema string, datum interface{}, expectedError interface{}) {
+	codec, err := NewCodec(schema)
+	checkErrorFatal(t, err, nil)
+	checkError(t, codec.(Validator).Validate(datum), expectedError)
+}
+
+func TestCodecValidatePrimitives(t *testing.T) {
+	checkCodecValidate(t, `"null"`, nil, nil)
+	checkCodecValidate(t, `"boolean"`, true, nil)
+	checkCodecValidate(t, `"boolean"`, 1, "expected: bool; received: int")
+	checkCodecValidate(t, `"int"`, int32(3), nil)
+	checkCodecValidate(t, `"int"`, int64(3), "expected: int32; received: int64")
+	checkCodecValidate(t, `"long"`, int64(3), nil)
+	checkCodecValidate(t, `"float"`, float32(3.5), nil)
+	checkCodecValidate(t, `"double"`, float32(3.5), "expected: float64; received: float32")
+	checkCodecValidate(t, `"bytes"`, []byte("some bytes"), nil)
+	checkCodecValidate(t, `"string"`, []byte("some bytes"), "expected: string; received: []uint8")
+}
+
+func TestCodecValidateNamedTypes(t *testing.T) {
+	enumSchema := `{"type":"enum","name":"cards","symbols":["HEARTS","DIAMONDS","SPADES","CLUBS"]}`
+	checkCodecValidate(t, enumSchema, Enum{"cards", "SPADES"}, nil)
+	checkCodecValidate(t, enumSchema, "CLUBS", nil)
+	checkCodecValidate(t, enumSchema, Enum{"cards", "PINEAPPLE"}, "symbol not defined: PINEAPPLE")
+	checkCodecValidate(t, enumSchema, Enum{Name: "cards"}, "Enum Value ought to be the symbol to encode")
+
+	fixedSchema := `{"type":"fixed","name":"fixed1","size":5}`
+	checkCodecValidate(t, fixedSchema, Fixed{Name: "fixed1", Value: []byte("happy")}, nil)
+	checkCodecValidate(t, fixedSchema, Fixed{Name: "fixed1", Value: []byte("day")}, "expected: 5 bytes; received: 3")
+
+	unionSchema := `["null",` + enumSchema + `]`
+	checkCodecValidate(t, unionSchema, nil, nil)
+	checkCodecValidate(t, unionSchema, Enum{"cards", "HEARTS"}, nil)
+	checkCodecValidate(t, unionSchema, int32(13), "expected: null, cards; received: int32")
+}
+
+func TestCodecValidateRecordReportsPath(t *testing.T) {
+	recordSchemaJSON := `{"type":"record","name":"user","namespace":"com.example","fields":[{"name":"name","type":"string"},{"name":"addresses","type":{"type":"array","items":{"type":"record","name":"address","fields":[{"name":"zip","type":"int"},{"name":"tags","type":{"type":"map","values":"string"},"default":{}}]}}}]}`
+	codec, err := NewCodec(recordSchemaJSON)
+	checkErrorFatal(t, err, nil)
+
+	newAddress := func(zip interface{}) *Record {
+		address, err := NewRecord(RecordSchema(`{"type":"record","name":"address","namespace":"com.example","fields":[{"name":"zip","type":"int"},{"name":"tags","type":{"type":"map","values":"string"},"default":{}}]}`))
+		checkErrorFatal(t, err, nil)
+		address.Set("zip", zip)
+		address.Set("tags", map[string]interface{}{"kind": "home"})
+		return address
+	}
+
+	someRecord, err := NewRecord(RecordSchema(recordSchemaJSON))
+	checkErrorFatal(t, err, nil)
+	someRecord.Set("name", "Aquaman")
+	someRecord.Set("addresses", []interface{}{newAddress(int32(12345)), newAddress(int32(23456))})
+	checkError(t, codec.(Validator).Validate(someRecord), nil)
+
+	someRecord.Set("addresses", []interface{}{newAddress(int32(12345)), newAddress("23456")})
+	err = codec.(Validator).Validate(someRecord)
+	checkError(t, err, "invalid datum at addresses[1].zip: int: expected: int32; received: string")
+	if ev, ok := err.(*ErrValidation); !ok || ev.Path != "addresses[1].zip" {
+		t.Errorf("Actual: %#v; Expected: %#v", err, "addresses[1].zip")
+	}
+
+	tagged := newAddress(int32(12345))
+	tagged.Set("tags", map[string]interface{}{"kind": 5})
+	someRecord.Set("addresses", []interface{}{tagged})
+	checkError(t, codec.(Validator).Validate(someRecord), "invalid datum at addresses[0].tags[kind]")
+
+	someRecord.Set("name", nil)
+	checkError(t, codec.(Validator).Validate(someRecord), "invalid datum at name: record (com.example.user): field has no data and no default set")
+
+	otherRecord, err := NewRecord(RecordSchema(`{"type":"record","name":"other","fields":[{"name":"field1","type":"int"}]}`))
+	checkErrorFatal(t, err, nil)
+	checkError(t, codec.(Validator).Validate(otherRecord), "expected: com.example.user; received: other")
+}
+
+func TestCodecEncoderErrorReportsPath(t *testing.T) {
+	recordSchemaJSON := `{"type":"record","name":"user","fields":[{"name":"addresses","type":{"type":"array","items":["null",{"type":"record","name":"address","fields":[{"name":"zip","type":"int"}]}]}}]}`
+	codec, err := NewCodec(recordSchemaJSON)
+	checkErrorFatal(t, err, nil)
+
+	address, err := NewRecord(RecordSchema(`{"type":"record","name":"address","fields":[{"name":"zip","type":"int"}]}`))
+	checkErrorFatal(t, err, nil)
+	address.Set("zip", "12345")
+
+	someRecord, err := NewRecord(RecordSchema(recordSchemaJSON))
+	checkErrorFatal(t, err, nil)
+	someRecord.Set("addresses", []interface{}{nil, nil, nil, address})
+
+	err = codec.Encode(new(bytes.Buffer), someRecord)
+	checkError(t, err, "cannot encode record (user) at addresses[3].zip: cannot encode array (array): cannot encode union (union): cannot encode record (address): cannot encode int: expected: int32; received: string")
+	if ee, ok := err.(*ErrEncoder); !ok || ee.Path != "addresses[3].zip" {
+		t.Errorf("Actual: %#v; Expected: %#v", err, "addresses[3].zip")
+	}
+
+	mapCodec, err := NewCodec(`{"type":"map","values":{"type":"array","items":"long"}}`)
+	checkErrorFatal(t, err, nil)
+	err = mapCodec.Encode(new(bytes.Buffer), map[string]interface{}{"some key": []interface{}{int64(1), "2"}})
+	checkError(t, err, "cannot encode map (map) at [some key][1]: ")
+}
+
+func TestCodecDecoderErrorReportsPath(t *testing.T) {
+	recordSchemaJSON := `{"type":"record","name":"user","fields":[{"name":"name","type":"string"},{"name":"addresses","type":{"type":"array","items":{"type":"record","name":"address","fields":[{"name":"zip","type":"int"}]}}}]}`
+	// name: "happy", addresses: one block of 2 items, second zip truncated
+	bits := []byte("\x0ahappy\x04\x1a")
+	codec, err := NewCodec(recordSchemaJSON)
+	checkErrorFatal(t, err, nil)
+	_, err = codec.Decode(bytes.NewReader(bits))
+	checkError(t, err, "cannot decode record (user) at addresses[1].zip: ")
+	if ed, ok := err.(*ErrDecoder); !ok || ed.Path != "addresses[1].zip" {
+		t.Errorf("Actual: %#v; Expected: %#v", err, "addresses[1].zip")
+	}
+
+	checkCodecDecoderError(t, `{"type":"map","values":"int"}`, []byte("\x02\x06key"), "cannot decode map (map) at [key]: cannot decode int: unexpected EOF")
+}
+
+func TestCodecDecoderErrorReportsOffset(t *testing.T) {
+	recordSchemaJSON := `{"type":"record","name":"user","namespace":"com.example","fields":[{"name":"name","type":"string"},{"name":"age","type":"int"}]}`
+	codec, err := NewCodec(recordSchemaJSON)
+	checkErrorFatal(t, err, nil)
+
+	// name: "happy", age: continuation bit set, then EOF
+	_, err = codec.Decode(bytes.NewReader([]byte("\x0ahappy\x80")))
+	var ed *ErrDecoder
+	if !errors.As(err, &ed) {
+		t.Fatalf("Actual: %T; Expected: %T", err, ed)
+	}
+	if ed.Offset != 7 {
+		t.Errorf("Actual: %#v; Expected: %#v", ed.Offset, 7)
+	}
+	if ed.SchemaName != "com.example.user" {
+		t.Errorf("Actual: %#v; Expected: %#v", ed.SchemaName, "com.example.user")
+	}
+	if ed.Path != "age" {
+		t.Errorf("Actual: %#v; Expected: %#v", ed.Path, "age")
+	}
+
+	intCodec, err := NewCodec(`"int"`)
+	checkErrorFatal(t, err, nil)
+	_, err = intCodec.Decode(bytes.NewReader([]byte("\x80")))
+	if ed, ok := err.(*ErrDecoder); !ok || ed.SchemaName != "int" || ed.Offset != 1 {
+		t.Errorf("Actual: %#v; Expected: %#v", err, "int at offset 1")
+	}
+}
+
+func TestCodecEncoderErrorReportsSchemaName(t *testing.T) {
+	codec, err := NewCodec(`{"type":"array","items":"long"}`)
+	checkErrorFatal(t, err, nil)
+	err = codec.Encode(new(bytes.Buffer), []interface{}{int64(1), "two"})
+	var ee *ErrEncoder
+	if !errors.As(err, &ee) {
+		t.Fatalf("Actual: %T; Expected: %T", err, ee)
+	}
+	if ee.SchemaName != "array" || ee.Path != "[1]" {
+		t.Errorf("Actual: %#v; Expected: %#v", ee, "array at [1]")
+	}
+}
+
+func TestCodecBuildErrorReportsPath(t *testing.T) {
+	_, err := NewCodec(`{"type":"record","name":"user","fields":[{"name":"address","type":{"type":"record","name":"address","fields":[{"name":"zip","type":"flubber"}]}}]}`)
+	checkError(t, err, "cannot build record (null namespace): record field ought to be codec at address.zip: ")
+	if eb, ok := err.(*ErrCodecBuild); !ok || eb.Path != "address.zip" {
+		t.Errorf("Actual: %#v; Expected: %#v", err, "address.zip")
+	}
+}
+
 ////////////////////////////////////////
 
 func TestBufferedEncoder(t *testing.T) {
@@ -1129,3 +1447,1042 @@ func TestDecoderStringPartialReads(t *testing.T) {
 		t.Errorf("Actual: %#v; Expected: %#v", result, want)
 	}
 }
+
+func TestCodecEncoderRecordFromMap(t *testing.T) {
+	schema := `{"type":"record","name":"user","fields":[{"name":"name","type":"string"},{"name":"age","type":"int","default":7},{"name":"home","type":["null",{"type":"record","name":"address","fields":[{"name":"city","type":"string"}]}]}]}`
+	checkCodecEncoderResult(t, schema, map[string]interface{}{"name": "Bo", "home": nil}, []byte("\x04Bo\x0e\x00"))
+	checkCodecEncoderResult(t, schema, map[string]interface{}{"name": "Bo", "age": int32(1), "home": map[string]interface{}{"city": "X"}}, []byte("\x04Bo\x02\x02\x02X"))
+	checkCodecEncoderError(t, schema, map[string]interface{}{"name": "Bo", "nickname": "B"}, `cannot encode record (user): no such field: "nickname"`)
+	checkCodecEncoderError(t, schema, map[string]interface{}{"name": "Bo", "zip": 1, "nickname": "B"}, `cannot encode record (user): no such field: "nickname"`)
+	checkCodecEncoderError(t, schema, map[string]interface{}{"age": int32(1)}, "field has no data and no default set: name")
+	checkCodecEncoderError(t, schema, map[string]interface{}{"name": "Bo", "home": map[string]interface{}{"town": "X"}}, "datum ought match schema: expected: null, address; received: map")
+	checkCodecValidate(t, schema, map[string]interface{}{"name": "Bo", "home": map[string]interface{}{"city": "X"}}, nil)
+	checkCodecValidate(t, schema, map[string]interface{}{"name": "Bo", "zip": 1}, `no such field: "zip"`)
+
+	// the keys choose among record members, and the field data among
+	// record members with the same field names
+	union := `["null",{"type":"record","name":"a","fields":[{"name":"x","type":"int"}]},{"type":"record","name":"b","fields":[{"name":"x","type":"string"}]},{"type":"record","name":"c","fields":[{"name":"y","type":"int"}]}]`
+	checkCodecEncoderResult(t, union, map[string]interface{}{"x": int32(1)}, []byte("\x02\x02"))
+	checkCodecEncoderResult(t, union, map[string]interface{}{"x": "s"}, []byte("\x04\x02s"))
+	checkCodecEncoderResult(t, union, OrderedMap{{"y", int32(1)}}, []byte("\x06\x02"))
+	checkCodecEncoderError(t, union, map[string]interface{}{"x": 1.5}, "datum ought match schema")
+}
+
+func TestCodecDecodeRecordsAsMaps(t *testing.T) {
+	schema := `{"type":"record","name":"user","fields":[{"name":"name","type":"string"},{"name":"home","type":["null",{"type":"record","name":"address","fields":[{"name":"zip","type":"int"},{"name":"city","type":"string"}]}]}]}`
+	codec, err := NewCodec(schema, DecodeRecordsAsMaps())
+	checkErrorFatal(t, err, nil)
+	datum, err := codec.Decode(bytes.NewReader([]byte("\x04Bo\x02\x02\x02X")))
+	checkErrorFatal(t, err, nil)
+	expected := OrderedMap{
+		{"name", "Bo"},
+		{"home", OrderedMap{{"zip", int32(1)}, {"city", "X"}}},
+	}
+	if !reflect.DeepEqual(datum, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", datum, expected)
+	}
+
+	_, err = codec.Decode(bytes.NewReader([]byte("\x04Bo\x02\x02")))
+	checkError(t, err, "cannot decode record (user) at home.city: ")
+
+	var user struct {
+		Name string
+		Home *struct {
+			Zip  int
+			City string
+		}
+	}
+	checkErrorFatal(t, codec.(StructCodec).DecodeInto(bytes.NewReader([]byte("\x04Bo\x02\x02\x02X")), &user), nil)
+	if user.Name != "Bo" || user.Home == nil || user.Home.Zip != 1 || user.Home.City != "X" {
+		t.Errorf("Actual: %#v", user)
+	}
+}
+
+func TestCodecDecodeRecordsAsMapsNestedOrder(t *testing.T) {
+	schema := `{"type":"record","name":"outer","fields":[
+{"name":"z","type":{"type":"array","items":{"type":"record","name":"item","fields":[{"name":"y","type":"int"},{"name":"b","type":"int"}]}}},
+{"name":"a","type":{"type":"map","values":"item"}}]}`
+	codec, err := NewCodec(schema, DecodeRecordsAsMaps())
+	checkErrorFatal(t, err, nil)
+	datum, err := codec.Decode(bytes.NewReader([]byte("\x02\x02\x04\x00\x02\x02k\x06\x08\x00")))
+	checkErrorFatal(t, err, nil)
+	item := func(y, b int32) OrderedMap { return OrderedMap{{"y", y}, {"b", b}} }
+	expected := OrderedMap{
+		{"z", []interface{}{item(1, 2)}},
+		{"a", map[string]interface{}{"k": item(3, 4)}},
+	}
+	if !reflect.DeepEqual(datum, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", datum, expected)
+	}
+}
+
+func TestCodecRecordsAsMapsRoundTrip(t *testing.T) {
+	schema := `{"type":"record","name":"user","fields":[
+{"name":"name","type":"string"},
+{"name":"home","type":["null",{"type":"record","name":"address","fields":[{"name":"zip","type":"int"},{"name":"city","type":"string"}]}]},
+{"name":"others","type":{"type":"map","values":"address"}}]}`
+	encoded := []byte("\x04Bo\x02\x02\x02X\x02\x02k\x04\x02Y\x00")
+
+	codec, err := NewCodec(schema, DecodeRecordsAsMaps())
+	checkErrorFatal(t, err, nil)
+	datum, err := codec.Decode(bytes.NewReader(encoded))
+	checkErrorFatal(t, err, nil)
+	checkError(t, codec.(Validator).Validate(datum), nil)
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, codec.Encode(bb, datum), nil)
+	if actual := bb.Bytes(); !bytes.Equal(actual, encoded) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, encoded)
+	}
+
+	jsonCodec, err := NewJSONCodec(schema, DecodeRecordsAsMaps())
+	checkErrorFatal(t, err, nil)
+	bb.Reset()
+	checkErrorFatal(t, jsonCodec.Encode(bb, datum), nil)
+	jsonDatum, err := jsonCodec.Decode(bb)
+	checkErrorFatal(t, err, nil)
+	bb.Reset()
+	checkErrorFatal(t, codec.Encode(bb, jsonDatum), nil)
+	if actual := bb.Bytes(); !bytes.Equal(actual, encoded) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, encoded)
+	}
+
+	checkCodecEncoderError(t, schema, OrderedMap{{"name", "Bo"}, {"nick", "B"}}, `no such field: "nick"`)
+}
+
+func TestCodecEncoderUnionPointers(t *testing.T) {
+	someLong, someString, someDouble, someInt := int64(13), "happy", float64(3.5), int32(-1)
+	var nilLong *int64
+	checkCodecEncoderResult(t, `["null","long"]`, &someLong, []byte("\x02\x1a"))
+	checkCodecEncoderResult(t, `["null","long"]`, nilLong, []byte("\x00"))
+	checkCodecEncoderResult(t, `["null","string"]`, &someString, []byte("\x02\x0ahappy"))
+	checkCodecEncoderResult(t, `["null","double"]`, &someDouble, []byte("\x02\x00\x00\x00\x00\x00\x00\x0c\x40"))
+	checkCodecEncoderResult(t, `["null","int"]`, &someInt, []byte("\x02\x01"))
+	checkCodecEncoderError(t, `["null","int"]`, &someLong, "expected: null, int32; received: int64")
+	checkCodecValidate(t, `["null","long"]`, &someLong, nil)
+	checkCodecValidate(t, `["null","long"]`, nilLong, nil)
+
+	schema := `{"type":"record","name":"r","fields":[{"name":"a","type":["null","long"]},{"name":"b","type":["null","string"]}]}`
+	checkCodecEncoderResult(t, schema, map[string]interface{}{"a": &someLong, "b": (*string)(nil)}, []byte("\x02\x1a\x00"))
+	pointerRecord, err := NewRecord(RecordSchema(`{"type":"record","name":"p","fields":[{"name":"a","type":"long"}]}`))
+	checkErrorFatal(t, err, nil)
+	pointerRecord.Set("a", someLong)
+	checkCodecEncoderResult(t, `["null",{"type":"record","name":"p","fields":[{"name":"a","type":"long"}]}]`, &pointerRecord, []byte("\x02\x1a"))
+	var nilRecord *Record
+	checkCodecEncoderResult(t, `["null",{"type":"record","name":"p","fields":[{"name":"a","type":"long"}]}]`, nilRecord, []byte("\x00"))
+	checkCodecEncoderResult(t, `["null",{"type":"record","name":"p","fields":[{"name":"a","type":"long"}]}]`, &nilRecord, []byte("\x00"))
+	checkCodecValidate(t, `["null",{"type":"record","name":"p","fields":[{"name":"a","type":"long"}]}]`, nilRecord, nil)
+}
+
+func TestCodecEncoderUnionNumericAndSlices(t *testing.T) {
+	checkCodecEncoderResult(t, `["null","long"]`, json.Number("13"), []byte("\x02\x1a"))
+	checkCodecEncoderResult(t, `["null","long"]`, 13, []byte("\x02\x1a"))
+	checkCodecEncoderResult(t, `["null","long"]`, int32(13), []byte("\x02\x1a"))
+	checkCodecEncoderResult(t, `["null","int"]`, 13, []byte("\x02\x1a"))
+	checkCodecEncoderResult(t, `["null","int"]`, int16(13), []byte("\x02\x1a"))
+	checkCodecEncoderResult(t, `["int","long"]`, 13, []byte("\x02\x1a"))
+	checkCodecEncoderResult(t, `["null","double"]`, json.Number("3.5"), []byte("\x02\x00\x00\x00\x00\x00\x00\x0c\x40"))
+	checkCodecEncoderResult(t, `["null",{"type":"array","items":"string"}]`, []string{"a"}, []byte("\x02\x02\x02a\x00"))
+	checkCodecEncoderError(t, `["null","long"]`, json.Number("3.5"), "received: json.Number")
+	checkCodecEncoderError(t, `["null","int"]`, 1<<40, "value out of range for 32-bit int")
+	checkCodecValidate(t, `["null","long"]`, json.Number("13"), nil)
+	checkCodecValidate(t, `["null",{"type":"array","items":"long"}]`, []int64{1}, nil)
+}
+
+func TestCodecUnionValue(t *testing.T) {
+	schema := `["null","int","long",{"type":"enum","name":"e","namespace":"n","symbols":["A"]}]`
+	checkCodecEncoderResult(t, schema, Union("long", int64(1)), []byte("\x04\x02"))
+	checkCodecEncoderResult(t, schema, Union("int", int32(1)), []byte("\x02\x02"))
+	checkCodecEncoderResult(t, schema, Union("null", nil), []byte("\x00"))
+	checkCodecEncoderResult(t, schema, Union("n.e", Enum{Name: "n.e", Value: "A"}), []byte("\x06\x00"))
+	checkCodecEncoderError(t, schema, Union("long", "1"), "expected: int64; received: string")
+	checkCodecEncoderError(t, schema, Union("string", "x"), "received: string")
+	checkCodecValidate(t, schema, Union("long", int64(1)), nil)
+
+	codec, err := NewCodec(schema, DecodeUnionValues())
+	checkErrorFatal(t, err, nil)
+	for _, bits := range [][]byte{[]byte("\x04\x02"), []byte("\x02\x02"), []byte("\x00")} {
+		datum, err := codec.Decode(bytes.NewReader(bits))
+		checkErrorFatal(t, err, nil)
+		bb := new(bytes.Buffer)
+		checkErrorFatal(t, codec.Encode(bb, datum), nil)
+		if !bytes.Equal(bb.Bytes(), bits) {
+			t.Errorf("Actual: %#v; Expected: %#v", bb.Bytes(), bits)
+		}
+	}
+	datum, err := codec.Decode(bytes.NewReader([]byte("\x04\x02")))
+	checkErrorFatal(t, err, nil)
+	if expected := (UnionValue{Branch: 2, TypeName: "long", Value: int64(1)}); datum != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", datum, expected)
+	}
+	datum, err = codec.Decode(bytes.NewReader([]byte("\x00")))
+	checkErrorFatal(t, err, nil)
+	if datum != nil {
+		t.Errorf("Actual: %#v; Expected: %#v", datum, nil)
+	}
+}
+
+func TestCodecEncoderUnionRecordBranches(t *testing.T) {
+	schema := `["null",{"type":"record","name":"a","namespace":"n","fields":[{"name":"x","type":"int"}]},{"type":"record","name":"b","namespace":"n","fields":[{"name":"y","type":"string"}]}]`
+	newTestRecord := func(recordSchema string) *Record {
+		someRecord, err := NewRecord(RecordSchema(recordSchema))
+		checkErrorFatal(t, err, nil)
+		return someRecord
+	}
+
+	b := newTestRecord(`{"type":"record","name":"b","namespace":"n","fields":[{"name":"y","type":"string"}]}`)
+	checkErrorFatal(t, b.Set("y", "hi"), nil)
+	checkCodecEncoderResult(t, schema, b, []byte("\x04\x04hi"))
+
+	c := newTestRecord(`{"type":"record","name":"c","namespace":"n","fields":[{"name":"y","type":"string"}]}`)
+	checkErrorFatal(t, c.Set("y", "hi"), nil)
+	checkCodecEncoderError(t, schema, c, "expected record: n.a, n.b; received record: n.c")
+	checkCodecValidate(t, schema, c, "expected record: n.a, n.b; received record: n.c")
+	checkCodecEncoderError(t, `["null","int"]`, c, "expected no record; received record: n.c")
+
+	// same name, different fields
+	wrong := newTestRecord(`{"type":"record","name":"b","namespace":"n","fields":[{"name":"z","type":"string"}]}`)
+	checkErrorFatal(t, wrong.Set("z", "hi"), nil)
+	checkCodecEncoderError(t, schema, wrong, "expected field 0: n.y; received: n.z")
+}
+
+func TestCodecRejectsDuplicates(t *testing.T) {
+	for _, build := range []func(string, ...CodecSetter) (Codec, error){NewCodec, NewJSONCodec} {
+		_, err := build(`{"type":"record","name":"r","fields":[{"name":"a","type":"int"},{"name":"b","type":"int"},{"name":"a","type":"string"}]}`)
+		checkError(t, err, "duplicate field name: a")
+		_, err = build(`{"type":"enum","name":"e","symbols":["A","B","A"]}`)
+		checkError(t, err, "cannot build enum (e): duplicate symbol: A")
+	}
+}
+
+func TestCodecOriginalSchema(t *testing.T) {
+	original := `{
+  "type": "record",
+  "name": "r",
+  "fields": [ {"name": "b", "type": "int"} ]
+}`
+	codec, err := NewCodec(original)
+	checkErrorFatal(t, err, nil)
+	if actual := codec.(SchemaInspector).OriginalSchema(); actual != original {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, original)
+	}
+	expected := `{"fields":[{"name":"b","type":"int"}],"name":"r","type":"record"}`
+	if actual := codec.Schema(); actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+
+	codec, err = NewJSONCodec(original)
+	checkErrorFatal(t, err, nil)
+	if actual := codec.(SchemaInspector).OriginalSchema(); actual != original {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, original)
+	}
+
+	field, err := codec.(SchemaInspector).FieldCodec("b")
+	checkErrorFatal(t, err, nil)
+	if actual, expected := field.(SchemaInspector).OriginalSchema(), `"int"`; actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+
+	codec, err = NewCodecFromValue(map[string]interface{}{"type": "array", "items": "int"})
+	checkErrorFatal(t, err, nil)
+	if actual, expected := codec.(SchemaInspector).OriginalSchema(), `{"items":"int","type":"array"}`; actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+}
+
+func TestNewCodecWithTypes(t *testing.T) {
+	address := `{"type":"record","name":"Address","namespace":"com.example","fields":[{"name":"city","type":"string"},{"name":"kind","type":"com.example.Kind"}]}`
+	kind := `{"type":"enum","name":"com.example.Kind","symbols":["HOME","WORK"]}`
+	main := `{"type":"record","name":"Person","namespace":"com.example","fields":[{"name":"home","type":"Address"},{"name":"work","type":["null","Address"]}]}`
+
+	_, err := NewCodec(main)
+	checkError(t, err, "unknown type name")
+
+	// the auxiliary schemas are not listed in dependency order
+	codec, err := NewCodecWithTypes(main, address, kind)
+	checkErrorFatal(t, err, nil)
+
+	expected := `{"fields":[{"name":"home","type":{"fields":[{"name":"city","type":"string"},{"name":"kind","type":{"name":"com.example.Kind","symbols":["HOME","WORK"],"type":"enum"}}],"name":"com.example.Address","type":"record"}},{"name":"work","type":["null","com.example.Address"]}],"name":"Person","namespace":"com.example","type":"record"}`
+	if actual := codec.Schema(); actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+	// the embedded schema stands on its own
+	_, err = NewCodec(codec.Schema())
+	checkErrorFatal(t, err, nil)
+
+	datum, err := codec.Decode(bytes.NewReader([]byte("\x06NYC\x02\x00")))
+	checkErrorFatal(t, err, nil)
+	person := datum.(*Record)
+	home, err := person.Get("home")
+	checkErrorFatal(t, err, nil)
+	city, err := home.(*Record).Get("city")
+	checkErrorFatal(t, err, nil)
+	if city != "NYC" {
+		t.Errorf("Actual: %#v; Expected: %#v", city, "NYC")
+	}
+
+	// setters apply as they do for NewCodec
+	codec, err = NewCodecWithTypes(main, address, kind)
+	checkErrorFatal(t, err, nil)
+	checkErrorFatal(t, DecodeRecordsAsMaps()(codec), nil)
+	datum, err = codec.Decode(bytes.NewReader([]byte("\x06NYC\x02\x00")))
+	checkErrorFatal(t, err, nil)
+	if _, ok := datum.(OrderedMap); !ok {
+		t.Errorf("Actual: %T; Expected: %T", datum, OrderedMap{})
+	}
+	_, err = NewCodecWithTypes(main, address)
+	checkError(t, err, "unknown type name")
+
+	// a named type may be repeated, but not redefined
+	_, err = NewCodecWithTypes(main, address, kind, kind)
+	checkErrorFatal(t, err, nil)
+	_, err = NewCodecWithTypes(main, address, kind, `{"type":"enum","name":"com.example.Kind","symbols":["HOME"]}`)
+	checkError(t, err, "named type redefined differently: com.example.Kind")
+	_, err = NewCodec(`{"type":"record","name":"r","fields":[
+{"name":"a","type":{"type":"enum","name":"E","symbols":["A"]}},
+{"name":"b","type":{"type":"enum","name":"E","symbols":["B"]}}]}`)
+	checkError(t, err, "named type redefined differently: E")
+}
+
+func TestCodecEncoderGoIntegers(t *testing.T) {
+	checkCodecEncoderResult(t, `"int"`, 3, []byte("\x06"))
+	checkCodecEncoderResult(t, `"int"`, int8(-1), []byte("\x01"))
+	checkCodecEncoderResult(t, `"int"`, int16(3), []byte("\x06"))
+	checkCodecEncoderResult(t, `"long"`, 3, []byte("\x06"))
+	checkCodecEncoderResult(t, `"long"`, int32(-1), []byte("\x01"))
+	checkCodecEncoderError(t, `"int"`, int64(3), "expected: int32; received: int64")
+	checkCodecValidate(t, `"int"`, 3, nil)
+
+	if strconv.IntSize == 64 {
+		wide := int64(math.MaxInt32) + 1
+		checkCodecEncoderError(t, `"int"`, int(wide), "value out of range for 32-bit int: 2147483648")
+		checkCodecValidate(t, `"int"`, int(-wide-1), "value out of range for 32-bit int: -2147483649")
+		checkCodecJSONEncoderError(t, `"int"`, int(wide), "value out of range for 32-bit int: 2147483648")
+	}
+	checkCodecJSONEncoderResult(t, `"int"`, 3, []byte("3"))
+	checkCodecJSONEncoderResult(t, `"long"`, 3, []byte("3"))
+}
+
+func TestCodecEncoderJSONNumber(t *testing.T) {
+	checkCodecEncoderResult(t, `"int"`, json.Number("3"), []byte("\x06"))
+	checkCodecEncoderResult(t, `"long"`, json.Number("-1"), []byte("\x01"))
+	checkCodecEncoderResult(t, `"float"`, json.Number("3.5"), []byte("\x00\x00\x60\x40"))
+	checkCodecEncoderResult(t, `"double"`, json.Number("3.5"), []byte("\x00\x00\x00\x00\x00\x00\x0c\x40"))
+	checkCodecEncoderError(t, `"int"`, json.Number("2147483648"), "value out of range for 32-bit int: 2147483648")
+	checkCodecEncoderError(t, `"long"`, json.Number("1.5"), `cannot convert json.Number to long: "1.5"`)
+	checkCodecEncoderError(t, `"float"`, json.Number("1e39"), "value out of range for 32-bit float: 1e39")
+	checkCodecValidate(t, `"double"`, json.Number("1e39"), nil)
+	checkCodecJSONEncoderResult(t, `"long"`, json.Number("12"), []byte("12"))
+
+	// strings only when asked
+	checkCodecEncoderError(t, `"long"`, "12", "expected: int64; received: string")
+	codec, err := NewCodec(`{"type":"record","name":"r","fields":[{"name":"a","type":"long"},{"name":"b","type":"double"}]}`, EncodeNumericStrings())
+	checkErrorFatal(t, err, nil)
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, codec.Encode(bb, map[string]interface{}{"a": "12", "b": "3.5"}), nil)
+	expected := []byte("\x18\x00\x00\x00\x00\x00\x00\x0c\x40")
+	if actual := bb.Bytes(); !bytes.Equal(actual, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+	checkError(t, codec.Encode(bb, map[string]interface{}{"a": "twelve", "b": "3.5"}), `cannot convert json.Number to long: "twelve"`)
+	checkErrorFatal(t, codec.(Validator).Validate(map[string]interface{}{"a": "12", "b": "3.5"}), nil)
+}
+
+func TestCodecDecodeStrict(t *testing.T) {
+	codec, err := NewCodec(`"long"`)
+	checkErrorFatal(t, err, nil)
+
+	datum, err := codec.(StrictDecoder).DecodeStrict(bytes.NewReader([]byte("\x06")))
+	checkErrorFatal(t, err, nil)
+	if datum != int64(3) {
+		t.Errorf("Actual: %#v; Expected: %#v", datum, int64(3))
+	}
+
+	_, err = codec.(StrictDecoder).DecodeStrict(bytes.NewReader([]byte("\x06\x08\x0a")))
+	checkError(t, err, "cannot decode long: 2 trailing bytes after datum")
+	if ed, ok := err.(*ErrDecoder); !ok || ed.Offset != 1 {
+		t.Errorf("Actual: %#v; Expected: %#v", err, "offset 1")
+	}
+
+	_, err = codec.(StrictDecoder).DecodeStrict(bytes.NewReader([]byte("\x86")))
+	checkError(t, err, "cannot decode long")
+
+	// Decode leaves the rest of the stream alone
+	r := bytes.NewReader([]byte("\x06\x08"))
+	_, err = codec.Decode(r)
+	checkErrorFatal(t, err, nil)
+	if r.Len() != 1 {
+		t.Errorf("Actual: %#v; Expected: %#v", r.Len(), 1)
+	}
+}
+
+func TestCodecEncoderComplexFieldDefaults(t *testing.T) {
+	schema := `{"type":"record","name":"r","namespace":"n","fields":[
+		{"name":"point","type":{"type":"record","name":"Point","fields":[{"name":"x","type":"int"},{"name":"y","type":"int","default":7}]},"default":{"x":1}},
+		{"name":"list","type":{"type":"array","items":"long"},"default":[1,2]},
+		{"name":"tags","type":{"type":"map","values":{"type":"enum","name":"Tag","symbols":["A","B"]}},"default":{"k":"B"}},
+		{"name":"other","type":"Point","default":{"x":2,"y":3}},
+		{"name":"id","type":"int"}]}`
+	expected := []byte("\x02\x0e\x04\x02\x04\x00\x02\x02k\x02\x00\x04\x06\x0a")
+	codec, err := NewCodec(schema)
+	checkErrorFatal(t, err, nil)
+	someRecord, err := NewRecord(RecordSchema(schema))
+	checkErrorFatal(t, err, nil)
+	checkErrorFatal(t, someRecord.Set("id", int32(5)), nil)
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, codec.Encode(bb, someRecord), nil)
+	if actual := bb.Bytes(); !bytes.Equal(actual, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+	checkErrorFatal(t, codec.(Validator).Validate(someRecord), nil)
+
+	jsonCodec, err := NewJSONCodec(schema)
+	checkErrorFatal(t, err, nil)
+	bb.Reset()
+	checkErrorFatal(t, jsonCodec.Encode(bb, someRecord), nil)
+	expectedJSON := `{"point":{"x":1,"y":7},"list":[1,2],"tags":{"k":"B"},"other":{"x":2,"y":3},"id":5}`
+	if actual := bb.String(); actual != expectedJSON {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expectedJSON)
+	}
+}
+
+func TestCodecUnionDefaultMatchesFirstMember(t *testing.T) {
+	for _, build := range []func(string, ...CodecSetter) (Codec, error){NewCodec, NewJSONCodec} {
+		_, err := build(`{"type":"record","name":"r","fields":[{"name":"a","type":["null","string"],"default":"foo"}]}`)
+		checkError(t, err, "union default ought to match first member: a: expected: null; received: string")
+		var parseError *ErrSchemaParse
+		if !errors.As(err, &parseError) {
+			t.Errorf("Actual: %#v; Expected: %#v", err, "*ErrSchemaParse")
+		}
+
+		_, err = build(`{"type":"record","name":"r","fields":[{"name":"e","type":{"type":"enum","name":"E","symbols":["A"]}},{"name":"a","type":["E","null"],"default":"Z"}]}`)
+		checkError(t, err, "union default ought to match first member: symbol not defined: Z")
+
+		_, err = build(`{"type":"record","name":"r","fields":[{"name":"a","type":["null","string"],"default":null},{"name":"b","type":["string","null"],"default":"foo"}]}`)
+		checkError(t, err, nil)
+	}
+	_, err := NewRecord(RecordSchema(`{"type":"record","name":"r","fields":[{"name":"a","type":["int","null"],"default":null}]}`))
+	checkError(t, err, "union default ought to match first member: a: expected: int32; received: <nil>")
+}
+
+func TestCodecSortMapKeys(t *testing.T) {
+	schema := `{"type":"map","values":{"type":"map","values":"int"}}`
+	datum := make(map[string]interface{})
+	for _, k := range []string{"d", "b", "e", "a", "c"} {
+		datum[k] = map[string]interface{}{k + "2": int32(2), k + "1": int32(1)}
+	}
+	codec, err := NewCodec(schema, SortMapKeys())
+	checkErrorFatal(t, err, nil)
+
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, codec.Encode(bb, datum), nil)
+	expected := bb.String()
+	if !strings.HasPrefix(expected, "\x0a\x02a\x04\x04a1\x02\x04a2\x04\x00\x02b") {
+		t.Errorf("Actual: %#v; Expected prefix: %#v", expected, "\x0a\x02a\x04\x04a1\x02\x04a2\x04\x00\x02b")
+	}
+	for i := 0; i < 20; i++ {
+		bb.Reset()
+		checkErrorFatal(t, codec.Encode(bb, datum), nil)
+		if actual := bb.String(); actual != expected {
+			t.Fatalf("Actual: %#v; Expected: %#v", actual, expected)
+		}
+	}
+}
+
+func TestCodecEncodeBlockSizes(t *testing.T) {
+	codec, err := NewCodec(`{"type":"array","items":{"type":"map","values":"string"}}`, EncodeBlockSizes())
+	checkErrorFatal(t, err, nil)
+
+	datum := []interface{}{map[string]interface{}{"a": "bc"}, map[string]interface{}{}}
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, codec.Encode(bb, datum), nil)
+	// array block of 2 items, 9 bytes: a map block of 1 entry, 5 bytes,
+	// and an empty map
+	expected := []byte("\x03\x12\x01\x0a\x02a\x04bc\x00\x00\x00")
+	if actual := bb.Bytes(); !bytes.Equal(actual, expected) {
+		t.Fatalf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+
+	decoded, err := codec.Decode(bytes.NewReader(expected))
+	checkErrorFatal(t, err, nil)
+	if !reflect.DeepEqual(decoded, datum) {
+		t.Errorf("Actual: %#v; Expected: %#v", decoded, datum)
+	}
+
+	// items are split across blocks of ten
+	longArray := make([]interface{}, 12)
+	for idx := range longArray {
+		longArray[idx] = map[string]interface{}{}
+	}
+	bb.Reset()
+	checkErrorFatal(t, codec.Encode(bb, longArray), nil)
+	if actual := bb.Bytes()[:2]; !bytes.Equal(actual, []byte("\x13\x14")) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, []byte("\x13\x14"))
+	}
+	decoded, err = codec.Decode(bb)
+	checkErrorFatal(t, err, nil)
+	if !reflect.DeepEqual(decoded, longArray) {
+		t.Errorf("Actual: %#v; Expected: %#v", decoded, longArray)
+	}
+}
+
+func TestCodecDecodeEOFAtDatumBoundary(t *testing.T) {
+	codec, err := NewCodec(`{"type":"record","name":"r","fields":[{"name":"a","type":"int"},{"name":"b","type":"string"}]}`)
+	checkErrorFatal(t, err, nil)
+
+	r := bytes.NewReader([]byte("\x02\x02a\x04\x02b"))
+	var count int
+	for {
+		_, err := codec.Decode(r)
+		if err == io.EOF {
+			break
+		}
+		checkErrorFatal(t, err, nil)
+		count++
+	}
+	if count != 2 {
+		t.Errorf("Actual: %#v; Expected: %#v", count, 2)
+	}
+
+	// truncated after the first field
+	_, err = codec.Decode(bytes.NewReader([]byte("\x02")))
+	checkError(t, err, "cannot decode record (r) at b: unexpected EOF")
+	if _, ok := err.(*ErrDecoder); !ok {
+		t.Errorf("Actual: %T; Expected: *ErrDecoder", err)
+	}
+	// truncated part way through a string
+	_, err = codec.Decode(bytes.NewReader([]byte("\x02\x04a")))
+	checkError(t, err, "unexpected EOF")
+}
+
+func TestCodecEncoderArrayTypedSlices(t *testing.T) {
+	checkCodecEncoderResult(t, `{"type":"array","items":"long"}`, []int64{-1, -2, -3, -4, -5, -6, 0, 1, 2, 3, 4, 5, 6}, []byte{
+		20,
+		1, 3, 5, 7, 9, 11, 0, 2, 4, 6,
+		6,
+		8, 10, 12,
+		0,
+	})
+	checkCodecEncoderResult(t, `{"type":"array","items":"int"}`, []int32{1, 2}, []byte("\x04\x02\x04\x00"))
+	checkCodecEncoderResult(t, `{"type":"array","items":"string"}`, []string{"a"}, []byte("\x02\x02a\x00"))
+	checkCodecEncoderResult(t, `{"type":"array","items":"double"}`, []float64{}, []byte("\x00"))
+	checkCodecEncoderResult(t, `{"type":"array","items":"boolean"}`, []bool{true}, []byte("\x02\x01\x00"))
+	checkCodecEncoderError(t, `{"type":"array","items":"int"}`, []string{"a"}, "cannot encode array (array) at [0]")
+	checkCodecEncoderError(t, `{"type":"array","items":"int"}`, []uint8{1}, "expected: []interface{}; received: []uint8")
+	checkCodecValidate(t, `{"type":"array","items":"double"}`, []float64{1.5}, nil)
+	checkCodecValidate(t, `{"type":"array","items":"double"}`, []string{"a"}, "[0]")
+	checkCodecJSONEncoderResult(t, `{"type":"array","items":"int"}`, []int32{1, 2}, []byte("[1,2]"))
+}
+
+func TestMustNewCodec(t *testing.T) {
+	codec := MustNewCodec(`"int"`, SortMapKeys())
+	if actual, expected := codec.Schema(), `"int"`; actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatalf("Actual: %#v; Expected: panic", r)
+		}
+		if msg, _ := r.(string); !strings.HasPrefix(msg, `goavro: NewCodec("\"flubber\"")`) {
+			t.Errorf("Actual: %#v; Expected: %#v", msg, `goavro: NewCodec("\"flubber\"")`)
+		}
+	}()
+	MustNewCodec(`"flubber"`)
+}
+
+func TestCodecDecodeUnionValueBranch(t *testing.T) {
+	codec, err := NewCodec(`["int","long","string"]`, DecodeUnionValues())
+	checkErrorFatal(t, err, nil)
+	for _, tc := range []struct {
+		bits     string
+		expected UnionValue
+	}{
+		{"\x00\x02", UnionValue{Branch: 0, TypeName: "int", Value: int32(1)}},
+		{"\x02\x02", UnionValue{Branch: 1, TypeName: "long", Value: int64(1)}},
+		{"\x04\x02a", UnionValue{Branch: 2, TypeName: "string", Value: "a"}},
+	} {
+		datum, err := codec.Decode(bytes.NewReader([]byte(tc.bits)))
+		checkErrorFatal(t, err, nil)
+		if datum != tc.expected {
+			t.Errorf("Actual: %#v; Expected: %#v", datum, tc.expected)
+		}
+		// Branch is ignored when encoding
+		bb := new(bytes.Buffer)
+		checkErrorFatal(t, codec.Encode(bb, UnionValue{Branch: 7, TypeName: tc.expected.TypeName, Value: tc.expected.Value}), nil)
+		if actual := bb.String(); actual != tc.bits {
+			t.Errorf("Actual: %#v; Expected: %#v", actual, tc.bits)
+		}
+	}
+}
+
+func TestCodecUnknownLogicalTypeFallsBackToBaseType(t *testing.T) {
+	schema := `{"type":"long","logicalType":"made-up"}`
+	checkCodecDecoderResult(t, schema, []byte("\x54"), int64(42))
+	checkCodecEncoderResult(t, schema, int64(42), []byte("\x54"))
+	checkCodecValidate(t, schema, int64(42), nil)
+	checkCodecEncoderError(t, schema, "42", "expected: int64; received: string")
+	checkCodecJSONDecoderResult(t, schema, []byte("42"), int64(42))
+	checkCodecJSONEncoderResult(t, schema, int64(42), []byte("42"))
+
+	// a logicalType which is not a string is ignored as well
+	checkCodecDecoderResult(t, `{"type":"string","logicalType":13}`, []byte("\x02a"), "a")
+	checkCodecDecoderResult(t, `{"type":"fixed","name":"f","size":2,"logicalType":"made-up"}`, []byte("ab"), Fixed{Name: "f", Value: []byte("ab")})
+
+	// converters registered for other logical types do not apply
+	record := `{"type":"record","name":"r","fields":[{"name":"when","type":` + schema + `}]}`
+	codec, err := NewCodec(record, DecodeRecordsAsMaps(), DecodeConverter("timestamp-millis", func(v interface{}) (interface{}, error) {
+		return nil, errors.New("ought not to be called")
+	}))
+	checkErrorFatal(t, err, nil)
+	datum, err := codec.Decode(bytes.NewReader([]byte("\x54")))
+	checkErrorFatal(t, err, nil)
+	if expected := (OrderedMap{{"when", int64(42)}}); !reflect.DeepEqual(datum, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", datum, expected)
+	}
+}
+
+func TestCodecMaxDecodeDepth(t *testing.T) {
+	schema := `{"type":"record","name":"r","fields":[{"name":"a","type":["null",{"type":"array","items":{"type":"map","values":"int"}}]}]}`
+	bits := []byte("\x02\x02\x02\x02k\x02\x00\x00")
+
+	codec, err := NewCodec(schema, MaxDecodeDepth(4))
+	checkErrorFatal(t, err, nil)
+	_, err = codec.Decode(bytes.NewReader(bits))
+	checkError(t, err, nil)
+	// the limit applies afresh to each datum
+	_, err = codec.Decode(bytes.NewReader(bits))
+	checkError(t, err, nil)
+
+	codec, err = NewCodec(schema, MaxDecodeDepth(3))
+	checkErrorFatal(t, err, nil)
+	_, err = codec.Decode(bytes.NewReader(bits))
+	checkError(t, err, "cannot decode record (r) at a[0]: cannot decode array (array): cannot decode map (map): nesting deeper than 3 levels")
+	// shallower data decode all the same
+	_, err = codec.Decode(bytes.NewReader([]byte("\x00")))
+	checkError(t, err, nil)
+
+	_, err = NewCodec(schema, MaxDecodeDepth(0))
+	checkError(t, err, "MaxDecodeDepth ought to be positive: 0")
+}
+
+func TestCodecEncodeTimes(t *testing.T) {
+	when := time.Date(1970, 1, 2, 0, 0, 0, 5e6, time.UTC)
+	before := time.Date(1969, 12, 31, 12, 0, 0, 0, time.UTC)
+
+	checkCodecEncoderError(t, `"long"`, when, "received: time.Time")
+
+	longCodec, err := NewCodec(`"long"`, EncodeTimes())
+	checkErrorFatal(t, err, nil)
+	intCodec, err := NewCodec(`"int"`, EncodeTimes())
+	checkErrorFatal(t, err, nil)
+	unionCodec, err := NewCodec(`["null","long"]`, EncodeTimes())
+	checkErrorFatal(t, err, nil)
+	jsonCodec, err := NewJSONCodec(`"long"`, EncodeTimes())
+	checkErrorFatal(t, err, nil)
+
+	for _, tc := range []struct {
+		codec    Codec
+		datum    interface{}
+		expected interface{}
+	}{
+		{longCodec, when, int64(86400005)},
+		{longCodec, before, int64(-43200000)},
+		{intCodec, when, int32(1)},
+		{intCodec, before, int32(-1)},
+		{unionCodec, Union("long", when), int64(86400005)},
+	} {
+		checkErrorFatal(t, tc.codec.(Validator).Validate(tc.datum), nil)
+		bb := new(bytes.Buffer)
+		checkErrorFatal(t, tc.codec.Encode(bb, tc.datum), nil)
+		actual, err := tc.codec.Decode(bb)
+		checkErrorFatal(t, err, nil)
+		if actual != tc.expected {
+			t.Errorf("Actual: %#v; Expected: %#v", actual, tc.expected)
+		}
+	}
+
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, jsonCodec.Encode(bb, when), nil)
+	if actual, expected := bb.String(), "86400005"; actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+}
+
+func TestCodecDecodeCorruptLengths(t *testing.T) {
+	// a string claiming to be 1 GiB long, followed by a few bytes
+	checkCodecDecoderError(t, `"string"`, []byte("\x80\x80\x80\x80\x08abc"), "unexpected EOF")
+	checkCodecDecoderError(t, `"bytes"`, []byte("\x80\x80\x80\x80\x08abc"), "unexpected EOF")
+	// a single block of two billion nulls
+	checkCodecDecoderError(t, `{"type":"array","items":"null"}`, []byte("\xfe\xff\xff\xff\x1f"), "greater than the max currently set with MaxBlockCount")
+	checkCodecDecoderError(t, `{"type":"map","values":"null"}`, []byte("\xfe\xff\xff\xff\x1f"), "greater than the max currently set with MaxBlockCount")
+	// a negative block count which has no positive counterpart
+	checkCodecDecoderError(t, `{"type":"array","items":"null"}`, []byte("\xff\xff\xff\xff\xff\xff\xff\xff\xff\x01"), "block count too large")
+	// blocks of 2^26 items which occupy no bytes, at any depth
+	checkCodecDecoderError(t, `{"type":"array","items":"null"}`, []byte("\x80\x80\x80\x40"), "more than MaxEmptyItems")
+	checkCodecDecoderError(t, `{"type":"array","items":{"type":"fixed","name":"f","size":0}}`, []byte("\x80\x80\x80\x40"), "more than MaxEmptyItems")
+	checkCodecDecoderError(t, `{"type":"array","items":{"type":"array","items":"null"}}`, []byte("\x7e"+strings.Repeat("\x80\x80\x40\x00", 63)+"\x00"), "more than MaxEmptyItems")
+	codec, err := NewCodec(`{"type":"array","items":"null"}`)
+	checkErrorFatal(t, err, nil)
+	checkError(t, codec.(ArrayStreamCodec).DecodeArrayStream(bytes.NewReader([]byte("\x80\x80\x80\x40")), func(interface{}) error { return nil }), "more than MaxEmptyItems")
+	// the limit applies to each datum of a stream on its own
+	dr := NewDatumReader(bytes.NewReader([]byte("\x80\x80\x40\x00\x80\x80\x40\x00")), codec)
+	for dr.Next() {
+		if actual, expected := len(dr.Datum().([]interface{})), 1<<19; actual != expected {
+			t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+		}
+	}
+	checkError(t, dr.Err(), nil)
+
+	_, err = NewCodec(`{"type":"fixed","name":"f","size":-1}`)
+	checkError(t, err, "size ought to be between 0 and")
+}
+
+func TestCodecMaxDecodeLength(t *testing.T) {
+	schema := `{"type":"record","name":"r","fields":[{"name":"s","type":"string"},{"name":"b","type":"bytes"},{"name":"m","type":{"type":"map","values":"int"}}]}`
+
+	codec, err := NewCodec(schema, MaxDecodeLength(3))
+	checkErrorFatal(t, err, nil)
+	_, err = codec.Decode(bytes.NewReader([]byte("\x06abc\x06xyz\x02\x06key\x02\x00")))
+	checkError(t, err, nil)
+	_, err = codec.Decode(bytes.NewReader([]byte("\x08abcd\x00\x00")))
+	checkError(t, err, "cannot decode record (r) at s: cannot decode string: implementation error: length of string (4) is greater than the max currently set with MaxDecodeLength (3)")
+	_, err = codec.Decode(bytes.NewReader([]byte("\x00\x08abcd\x00")))
+	checkError(t, err, "length of bytes (4) is greater than the max currently set with MaxDecodeLength (3)")
+	_, err = codec.Decode(bytes.NewReader([]byte("\x00\x00\x02\x08keys\x02\x00")))
+	checkError(t, err, "length of string (4) is greater than the max currently set with MaxDecodeLength (3)")
+	// a length within the limit which the data cannot supply
+	_, err = codec.Decode(bytes.NewReader([]byte("\x06ab")))
+	checkError(t, err, "unexpected EOF")
+
+	// other codecs keep the default limit
+	checkCodecDecoderResult(t, `"string"`, []byte("\x08abcd"), "abcd")
+
+	_, err = NewCodec(schema, MaxDecodeLength(0))
+	checkError(t, err, "MaxDecodeLength ought to be positive: 0")
+}
+
+const decodeReuseSchema = `{"type":"record","name":"r","fields":[
+{"name":"id","type":"long"},
+{"name":"tags","type":{"type":"array","items":"string"}},
+{"name":"inner","type":["null",{"type":"record","name":"i","fields":[{"name":"scores","type":{"type":"map","values":"double"}}]}]}]}`
+
+func encodeDecodeReuseRecord(t testing.TB, codec Codec, id int64, tags []interface{}) []byte {
+	inner, err := NewRecord(RecordSchema(`{"type":"record","name":"i","fields":[{"name":"scores","type":{"type":"map","values":"double"}}]}`))
+	checkErrorFatal(t, err, nil)
+	checkErrorFatal(t, inner.Set("scores", map[string]interface{}{"a": 1.5}), nil)
+	record, err := NewRecord(RecordSchema(decodeReuseSchema))
+	checkErrorFatal(t, err, nil)
+	checkErrorFatal(t, record.Set("id", id), nil)
+	checkErrorFatal(t, record.Set("tags", tags), nil)
+	checkErrorFatal(t, record.Set("inner", inner), nil)
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, codec.Encode(bb, record), nil)
+	return bb.Bytes()
+}
+
+func TestCodecDecodeReuse(t *testing.T) {
+	codec, err := NewCodec(decodeReuseSchema)
+	checkErrorFatal(t, err, nil)
+	first := encodeDecodeReuseRecord(t, codec, 1, []interface{}{"x", "y", "z"})
+	second := encodeDecodeReuseRecord(t, codec, 2, []interface{}{"w"})
+
+	rec := new(Record)
+	checkErrorFatal(t, codec.(ReuseDecoder).DecodeReuse(bytes.NewReader(first), rec), nil)
+	tags, _ := rec.Get("tags")
+	inner, _ := rec.Get("inner")
+
+	checkErrorFatal(t, codec.(ReuseDecoder).DecodeReuse(bytes.NewReader(second), rec), nil)
+	if actual, _ := rec.Get("id"); actual != int64(2) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, int64(2))
+	}
+	reusedTags, _ := rec.Get("tags")
+	if actual, expected := reusedTags.([]interface{}), []interface{}{"w"}; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	} else if &actual[0] != &tags.([]interface{})[0] {
+		t.Errorf("Actual: %v; Expected: %v", "new array storage", "reused array storage")
+	}
+	if reusedInner, _ := rec.Get("inner"); reusedInner != inner {
+		t.Errorf("Actual: %p; Expected: %p", reusedInner, inner)
+	}
+
+	// decoded data equal those Decode returns
+	datum, err := codec.Decode(bytes.NewReader(second))
+	checkErrorFatal(t, err, nil)
+	if actual, expected := rec.String(), datum.(*Record).String(); actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+
+	rec.Reset()
+	if actual, _ := rec.Get("id"); actual != nil {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, nil)
+	}
+
+	err = codec.(ReuseDecoder).DecodeReuse(bytes.NewReader(first), nil)
+	checkError(t, err, "expected: non-nil *Record")
+	longCodec, err := NewCodec(`"long"`)
+	checkErrorFatal(t, err, nil)
+	err = longCodec.(ReuseDecoder).DecodeReuse(bytes.NewReader([]byte{2}), rec)
+	checkError(t, err, "DecodeReuse ought to be used with record codec")
+	err = codec.(ReuseDecoder).DecodeReuse(bytes.NewReader(nil), rec)
+	checkError(t, err, io.EOF)
+}
+
+func TestCodecDecodeReuseAllocatesLess(t *testing.T) {
+	codec, err := NewCodec(decodeReuseSchema)
+	checkErrorFatal(t, err, nil)
+	bits := encodeDecodeReuseRecord(t, codec, 1, []interface{}{"x", "y", "z"})
+	rec := new(Record)
+	reuse := testing.AllocsPerRun(100, func() {
+		checkErrorFatal(t, codec.(ReuseDecoder).DecodeReuse(bytes.NewReader(bits), rec), nil)
+	})
+	fresh := testing.AllocsPerRun(100, func() {
+		_, err := codec.Decode(bytes.NewReader(bits))
+		checkErrorFatal(t, err, nil)
+	})
+	if reuse >= fresh {
+		t.Errorf("Actual: %v allocations; Expected: fewer than %v", reuse, fresh)
+	}
+}
+
+func BenchmarkCodecDecode(b *testing.B) {
+	codec, err := NewCodec(decodeReuseSchema)
+	checkErrorFatal(b, err, nil)
+	bits := encodeDecodeReuseRecord(b, codec, 1, []interface{}{"x", "y", "z"})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := codec.Decode(bytes.NewReader(bits)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCodecDecodeReuse(b *testing.B) {
+	codec, err := NewCodec(decodeReuseSchema)
+	checkErrorFatal(b, err, nil)
+	bits := encodeDecodeReuseRecord(b, codec, 1, []interface{}{"x", "y", "z"})
+	rec := new(Record)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := codec.(ReuseDecoder).DecodeReuse(bytes.NewReader(bits), rec); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// scalarRecordSchema has fields of primitive types only, which records
+// encode and decode along a faster path, as they do when the types are
+// written as schema objects, as in scalarRecordObjectSchema.
+const (
+	scalarRecordSchema       = `{"type":"record","name":"r","fields":[{"name":"a","type":"int"},{"name":"b","type":"long"},{"name":"c","type":"double"},{"name":"d","type":"string"},{"name":"e","type":"boolean"}]}`
+	scalarRecordObjectSchema = `{"type":"record","name":"r","fields":[{"name":"a","type":{"type":"int"}},{"name":"b","type":{"type":"long"}},{"name":"c","type":{"type":"double"}},{"name":"d","type":{"type":"string"}},{"name":"e","type":{"type":"boolean"}}]}`
+)
+
+func TestCodecScalarRecord(t *testing.T) {
+	for _, schema := range []string{scalarRecordSchema, scalarRecordObjectSchema} {
+		codec, err := NewCodec(schema)
+		checkErrorFatal(t, err, nil)
+		bits := []byte("\x02\x04\x00\x00\x00\x00\x00\x00\xf0\x3f\x02x\x01")
+
+		first, err := codec.Decode(bytes.NewReader(bits))
+		checkErrorFatal(t, err, nil)
+		checkErrorFatal(t, first.(*Record).Set("d", "changed"), nil)
+		second, err := codec.Decode(bytes.NewReader(bits))
+		checkErrorFatal(t, err, nil)
+		if actual, _ := second.(*Record).Get("d"); actual != "x" {
+			t.Errorf("Actual: %#v; Expected: %#v", actual, "x")
+		}
+		if actual, _ := second.(*Record).Get("c"); actual != float64(1) {
+			t.Errorf("Actual: %#v; Expected: %#v", actual, float64(1))
+		}
+
+		bb := new(bytes.Buffer)
+		checkErrorFatal(t, codec.Encode(bb, second), nil)
+		if actual := bb.Bytes(); !bytes.Equal(actual, bits) {
+			t.Errorf("Actual: %#v; Expected: %#v", actual, bits)
+		}
+		// writers which are not ByteWriters see the record in one write
+		w := &countingWriter{}
+		checkErrorFatal(t, codec.Encode(w, second), nil)
+		if actual, expected := w.writes, 1; actual != expected {
+			t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+		}
+
+		_, err = codec.Decode(bytes.NewReader(bits[:9]))
+		checkError(t, err, "cannot decode record (r) at c: cannot decode double: unexpected EOF")
+		checkErrorFatal(t, second.(*Record).Set("a", "x"), nil)
+		checkError(t, codec.Encode(w, second), "cannot encode record (r) at a: cannot encode int: expected: int32; received: string")
+	}
+}
+
+// countingWriter counts the writes made to it.
+type countingWriter struct {
+	writes int
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	cw.writes++
+	return len(p), nil
+}
+
+// benchmarkScalarRecord runs a benchmark of a record whose fields are
+// all primitives, along either the path specialized for such records,
+// or the general path.
+func benchmarkScalarRecord(b *testing.B, specialized bool, run func(*testing.B, Codec)) {
+	var schema interface{}
+	checkErrorFatal(b, json.Unmarshal([]byte(scalarRecordSchema), &schema), nil)
+	st := newSymbolTable(&codecOptions{generalRecords: !specialized})
+	codec, err := st.buildCodec(nullNamespace, schema)
+	checkErrorFatal(b, err, nil)
+	codec.opts = st.opts
+	b.ReportAllocs()
+	run(b, codec)
+}
+
+func decodeScalarRecords(b *testing.B, codec Codec) {
+	bits := []byte("\x02\x04\x00\x00\x00\x00\x00\x00\xf0\x3f\x02x\x01")
+	for i := 0; i < b.N; i++ {
+		if _, err := codec.Decode(bytes.NewReader(bits)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func encodeScalarRecords(b *testing.B, codec Codec) {
+	datum, err := codec.Decode(bytes.NewReader([]byte("\x02\x04\x00\x00\x00\x00\x00\x00\xf0\x3f\x02x\x01")))
+	checkErrorFatal(b, err, nil)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := codec.Encode(ioutil.Discard, datum); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCodecDecodeScalarRecord(b *testing.B) {
+	benchmarkScalarRecord(b, true, decodeScalarRecords)
+}
+
+func BenchmarkCodecDecodeScalarRecordGeneral(b *testing.B) {
+	benchmarkScalarRecord(b, false, decodeScalarRecords)
+}
+
+func BenchmarkCodecEncodeScalarRecord(b *testing.B) {
+	benchmarkScalarRecord(b, true, encodeScalarRecords)
+}
+
+func BenchmarkCodecEncodeScalarRecordGeneral(b *testing.B) {
+	benchmarkScalarRecord(b, false, encodeScalarRecords)
+}
+
+func TestCodecNamedTypeAliases(t *testing.T) {
+	schema := `{"type":"record","name":"com.example.Pair","fields":[
+{"name":"first","type":{"type":"record","name":"Point","aliases":["OldPoint","org.legacy.Coordinate"],"fields":[{"name":"x","type":"int"}]}},
+{"name":"second","type":"OldPoint"},
+{"name":"third","type":"org.legacy.Coordinate"},
+{"name":"color","type":{"type":"enum","name":"Color","aliases":["Colour"],"symbols":["RED"]}},
+{"name":"shade","type":"Colour"},
+{"name":"hash","type":{"type":"fixed","name":"Hash","aliases":["Digest"],"size":1}},
+{"name":"digest","type":"Digest"}]}`
+	codec, err := NewCodec(schema)
+	checkErrorFatal(t, err, nil)
+
+	point := func(x int32) map[string]interface{} { return map[string]interface{}{"x": x} }
+	datum := map[string]interface{}{
+		"first": point(1), "second": point(2), "third": point(3),
+		"color": "RED", "shade": "RED",
+		"hash": Fixed{Name: "com.example.Hash", Value: []byte("a")}, "digest": Fixed{Name: "com.example.Hash", Value: []byte("b")},
+	}
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, codec.Encode(bb, datum), nil)
+	expected := []byte("\x02\x04\x06\x00\x00ab")
+	if actual := bb.Bytes(); !bytes.Equal(actual, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+	decoded, err := codec.Decode(bb)
+	checkErrorFatal(t, err, nil)
+	second, err := decoded.(*Record).Get("second")
+	checkErrorFatal(t, err, nil)
+	if actual := second.(*Record).Name; actual != "com.example.Point" {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, "com.example.Point")
+	}
+
+	// references by alias resolve in the schema tree and inlined schema
+	third := codec.(SchemaInspector).SchemaTree().(*RecordNode).Fields[2].Type
+	if actual, ok := third.(*RecordNode); !ok || actual.Name != "com.example.Point" {
+		t.Errorf("Actual: %#v; Expected: %#v", third, "com.example.Point")
+	}
+	if strings.Contains(codec.(SchemaInspector).InlinedSchema(), `"type":"OldPoint"`) {
+		t.Errorf("Actual: %s; Expected: %s", codec.(SchemaInspector).InlinedSchema(), "alias references inlined")
+	}
+
+	_, err = NewCodec(`{"type":"record","name":"r","fields":[
+{"name":"a","type":{"type":"fixed","name":"a","size":1}},
+{"name":"b","type":{"type":"fixed","name":"b","aliases":["a"],"size":1}}]}`)
+	checkError(t, err, "alias conflicts with named type: a")
+}
+
+func TestCodecIgnoreUnknownFields(t *testing.T) {
+	schema := `{"type":"record","name":"r","fields":[{"name":"a","type":"int"},{"name":"b","type":"string","default":"x"}]}`
+	datum := map[string]interface{}{"a": int32(1), "adopted_later": true}
+
+	// unknown fields are an error by default
+	checkCodecEncoderError(t, schema, datum, "adopted_later")
+	checkCodecValidate(t, schema, datum, "adopted_later")
+	checkCodecJSONDecoderError(t, schema, []byte(`{"a":1,"b":"y","adopted_later":true}`), "Got unknown field adopted_later")
+
+	codec, err := NewCodec(schema, IgnoreUnknownFields())
+	checkErrorFatal(t, err, nil)
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, codec.Encode(bb, datum), nil)
+	if actual, expected := bb.Bytes(), []byte("\x02\x02x"); !bytes.Equal(actual, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+	checkError(t, codec.(Validator).Validate(datum), nil)
+	// within a union, the map still resolves to the record
+	union, err := NewCodec(`["null",`+schema+`]`, IgnoreUnknownFields())
+	checkErrorFatal(t, err, nil)
+	checkError(t, union.Encode(new(bytes.Buffer), datum), nil)
+
+	jsonCodec, err := NewJSONCodec(schema, IgnoreUnknownFields())
+	checkErrorFatal(t, err, nil)
+	decoded, err := jsonCodec.Decode(bytes.NewReader([]byte(`{"a":1,"b":"y","adopted_later":{"nested":[1]}}`)))
+	checkErrorFatal(t, err, nil)
+	if actual, _ := decoded.(*Record).Get("b"); actual != "y" {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, "y")
+	}
+}
+
+func TestNewCodecFromValue(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "record",
+		"name": "r",
+		"fields": []interface{}{
+			map[string]interface{}{"name": "a", "type": "int", "default": 7},
+			map[string]interface{}{"name": "b", "type": map[string]interface{}{"type": "fixed", "name": "f", "size": int64(2)}},
+			map[string]interface{}{"name": "c", "type": map[string]interface{}{"type": "enum", "name": "e", "symbols": []string{"X", "Y"}}},
+		},
+	}
+	codec, err := NewCodecFromValue(schema)
+	checkErrorFatal(t, err, nil)
+	expected := MustNewCodec(`{"type":"record","name":"r","fields":[{"name":"a","type":"int","default":7},{"name":"b","type":{"type":"fixed","name":"f","size":2}},{"name":"c","type":{"type":"enum","name":"e","symbols":["X","Y"]}}]}`)
+	if actual, expected := codec.Schema(), expected.Schema(); actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, codec.Encode(bb, map[string]interface{}{"b": Fixed{Name: "f", Value: []byte("ab")}, "c": "Y"}), nil)
+	if actual, expected := bb.Bytes(), []byte("\x0eab\x02"); !bytes.Equal(actual, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+
+	// the codec does not share the schema value
+	schema["name"] = "changed"
+	if name, _ := codec.(SchemaInspector).Name(); name != "r" {
+		t.Errorf("Actual: %#v; Expected: %#v", name, "r")
+	}
+
+	_, err = NewCodecFromValue(map[string]interface{}{"type": "fixed", "name": "f", "size": struct{}{}})
+	checkError(t, err, "cannot use schema value: unsupported type: struct {}")
+	_, err = NewCodecFromValue(`"int"`)
+	checkError(t, err, "could not normalize name")
+}
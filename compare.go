@@ -0,0 +1,356 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+
+package goavro
+
+import (
+	"bytes"
+	"io"
+)
+
+// Compare compares two binary encoded data of the codec's schema using
+// the sort order defined by the Avro specification, without decoding
+// them into Go values. It returns a negative number when a sorts before
+// b, zero when they are equal, and a positive number when a sorts after
+// b. Record fields are compared in schema order, honoring their order
+// attribute, so fields marked "descending" reverse the comparison and
+// fields marked "ignore" do not take part in it. An error is returned
+// when either datum cannot be read, or when a map, which the
+// specification does not order, would need to be compared.
+//
+//   result, err := codec.Compare(leftKey, rightKey)
+//   if err != nil {
+//       return err
+//   }
+//   if result < 0 {
+//       // leftKey sorts first
+//   }
+func (c codec) Compare(a, b []byte) (int, error) {
+	tree := c.tree
+	if tree == nil {
+		tree = c.SchemaTree()
+	}
+	return compareNode(tree, bytes.NewReader(a), bytes.NewReader(b))
+}
+
+// compareNode compares the next value of node read from each reader.
+// It always reads both values completely, so that values following
+// them may be compared.
+func compareNode(node SchemaNode, ra, rb io.Reader) (int, error) {
+	switch n := node.(type) {
+	case *PrimitiveNode:
+		return comparePrimitive(n.Type, ra, rb)
+	case *EnumNode:
+		// enums sort by the position of their symbols
+		return comparePrimitive("int", ra, rb)
+	case *FixedNode:
+		a, b := make([]byte, n.Size), make([]byte, n.Size)
+		if _, err := io.ReadFull(ra, a); err != nil {
+			return 0, newDecoderError("fixed", err)
+		}
+		if _, err := io.ReadFull(rb, b); err != nil {
+			return 0, newDecoderError("fixed", err)
+		}
+		return bytes.Compare(a, b), nil
+	case *UnionNode:
+		a, err := intDecoder(ra)
+		if err != nil {
+			return 0, newDecoderError("union", err)
+		}
+		b, err := intDecoder(rb)
+		if err != nil {
+			return 0, newDecoderError("union", err)
+		}
+		ia, ib := int(a.(int32)), int(b.(int32))
+		for _, index := range []int{ia, ib} {
+			if index < 0 || index >= len(n.Members) {
+				return 0, newDecoderError("union", "index must be between 0 and %d; read index: %d", len(n.Members)-1, index)
+			}
+		}
+		if ia != ib {
+			// values of different members sort by member position
+			if err = skipNode(n.Members[ia], ra); err != nil {
+				return 0, err
+			}
+			if err = skipNode(n.Members[ib], rb); err != nil {
+				return 0, err
+			}
+			return compareInts(int64(ia), int64(ib)), nil
+		}
+		return compareNode(n.Members[ia], ra, rb)
+	case *ArrayNode:
+		return compareArrays(n.Items, ra, rb)
+	case *RecordNode:
+		result := 0
+		for _, field := range n.Fields {
+			if field.Order == "ignore" {
+				if err := skipNode(field.Type, ra); err != nil {
+					return 0, err
+				}
+				if err := skipNode(field.Type, rb); err != nil {
+					return 0, err
+				}
+				continue
+			}
+			fieldResult, err := compareNode(field.Type, ra, rb)
+			if err != nil {
+				return 0, newDecoderPathError("record ("+n.Name+")", name{n: field.Name}.basename(), err)
+			}
+			if field.Order == "descending" {
+				fieldResult = -fieldResult
+			}
+			if result == 0 {
+				result = fieldResult
+			}
+		}
+		return result, nil
+	case *MapNode:
+		return 0, newDecoderError("map", "maps cannot be compared")
+	}
+	return 0, newDecoderError("compare", "unknown schema node: %T", node)
+}
+
+// primitiveDecoder returns the binary decoder of the primitive type.
+// Strings are decoded as bytes, because both sort by their bytes.
+func primitiveDecoder(typeName string) (decoderFunction, error) {
+	switch typeName {
+	case "null":
+		return nullDecoder, nil
+	case "boolean":
+		return booleanDecoder, nil
+	case "int":
+		return intDecoder, nil
+	case "long":
+		return longDecoder, nil
+	case "float":
+		return floatDecoder, nil
+	case "double":
+		return doubleDecoder, nil
+	case "bytes", "string":
+		return bytesDecoder, nil
+	}
+	return nil, newDecoderError("compare", "unknown primitive type: %s", typeName)
+}
+
+func comparePrimitive(typeName string, ra, rb io.Reader) (int, error) {
+	decoder, err := primitiveDecoder(typeName)
+	if err != nil {
+		return 0, err
+	}
+	a, err := decoder(ra)
+	if err != nil {
+		return 0, err
+	}
+	b, err := decoder(rb)
+	if err != nil {
+		return 0, err
+	}
+	switch va := a.(type) {
+	case nil:
+		return 0, nil
+	case bool:
+		vb := b.(bool)
+		switch {
+		case va == vb:
+			return 0, nil
+		case vb:
+			return -1, nil
+		}
+		return 1, nil
+	case int32:
+		return compareInts(int64(va), int64(b.(int32))), nil
+	case int64:
+		return compareInts(va, b.(int64)), nil
+	case float32:
+		return compareFloats(float64(va), float64(b.(float32))), nil
+	case float64:
+		return compareFloats(va, b.(float64)), nil
+	case []byte:
+		return bytes.Compare(va, b.([]byte)), nil
+	}
+	return 0, newDecoderError("compare", "unexpected value: %T", a)
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// arrayItems iterates over the items of a binary encoded array, one
+// block at a time.
+type arrayItems struct {
+	r         io.Reader
+	remaining int64
+	done      bool
+}
+
+// next returns true when another item is ready to be read from r.
+func (ai *arrayItems) next() (bool, error) {
+	if ai.done {
+		return false, nil
+	}
+	if ai.remaining == 0 {
+		count, err := longDecoder(ai.r)
+		if err != nil {
+			return false, newDecoderError("array", err)
+		}
+		ai.remaining = count.(int64)
+		if ai.remaining < 0 {
+			// negative count is followed by the block size in bytes
+			if _, err = longDecoder(ai.r); err != nil {
+				return false, newDecoderError("array", err)
+			}
+			ai.remaining = -ai.remaining
+		}
+		if ai.remaining == 0 {
+			ai.done = true
+			return false, nil
+		}
+	}
+	ai.remaining--
+	return true, nil
+}
+
+// compareArrays compares two arrays item by item; when one array is a
+// prefix of the other, the shorter array sorts first.
+func compareArrays(items SchemaNode, ra, rb io.Reader) (int, error) {
+	a, b := &arrayItems{r: ra}, &arrayItems{r: rb}
+	result := 0
+	for {
+		hasA, err := a.next()
+		if err != nil {
+			return 0, err
+		}
+		hasB, err := b.next()
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case hasA && hasB:
+			itemResult, err := compareNode(items, ra, rb)
+			if err != nil {
+				return 0, err
+			}
+			if result == 0 {
+				result = itemResult
+			}
+			continue
+		case hasA:
+			if result == 0 {
+				result = 1
+			}
+			return result, skipItems(a, func(r io.Reader) error { return skipNode(items, r) })
+		case hasB:
+			if result == 0 {
+				result = -1
+			}
+			return result, skipItems(b, func(r io.Reader) error { return skipNode(items, r) })
+		}
+		return result, nil
+	}
+}
+
+// skipItems reads and discards the remaining items of an array or map,
+// the first of which is ready to be read.
+func skipItems(ai *arrayItems, skip func(io.Reader) error) error {
+	for more := true; more; {
+		if err := skip(ai.r); err != nil {
+			return err
+		}
+		var err error
+		if more, err = ai.next(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skipNode reads and discards the next value of node from r.
+func skipNode(node SchemaNode, r io.Reader) error {
+	switch n := node.(type) {
+	case *PrimitiveNode:
+		if n.Type == "bytes" || n.Type == "string" {
+			return skipLengthPrefixed(r, n.Type)
+		}
+		decoder, err := primitiveDecoder(n.Type)
+		if err != nil {
+			return err
+		}
+		_, err = decoder(r)
+		return err
+	case *EnumNode:
+		_, err := intDecoder(r)
+		return err
+	case *FixedNode:
+		return skipLength(r, int64(n.Size))
+	case *UnionNode:
+		index, err := intDecoder(r)
+		if err != nil {
+			return newDecoderError("union", err)
+		}
+		idx := int(index.(int32))
+		if idx < 0 || idx >= len(n.Members) {
+			return newDecoderError("union", "index must be between 0 and %d; read index: %d", len(n.Members)-1, idx)
+		}
+		return skipNode(n.Members[idx], r)
+	case *ArrayNode:
+		ai := &arrayItems{r: r}
+		more, err := ai.next()
+		if err != nil || !more {
+			return err
+		}
+		return skipItems(ai, func(r io.Reader) error {
+			return skipNode(n.Items, r)
+		})
+	case *MapNode:
+		ai := &arrayItems{r: r}
+		more, err := ai.next()
+		if err != nil || !more {
+			return err
+		}
+		return skipItems(ai, func(r io.Reader) error {
+			if _, err := stringDecoder(r); err != nil {
+				return err
+			}
+			return skipNode(n.Values, r)
+		})
+	case *RecordNode:
+		for _, field := range n.Fields {
+			if err := skipNode(field.Type, r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return newDecoderError("compare", "unknown schema node: %T", node)
+}
@@ -0,0 +1,99 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+
+package goavro
+
+import (
+	"bytes"
+	"testing"
+)
+
+func checkCompare(t *testing.T, schema string, a, b interface{}, expected int) {
+	codec, err := NewCodec(schema)
+	checkErrorFatal(t, err, nil)
+	encode := func(datum interface{}) []byte {
+		bb := new(bytes.Buffer)
+		checkErrorFatal(t, codec.Encode(bb, datum), nil)
+		return bb.Bytes()
+	}
+	ea, eb := encode(a), encode(b)
+	actual, err := codec.(Comparer).Compare(ea, eb)
+	checkErrorFatal(t, err, nil)
+	if actual != expected {
+		t.Errorf("Schema: %s; A: %#v; B: %#v; Actual: %#v; Expected: %#v", schema, a, b, actual, expected)
+	}
+	actual, err = codec.(Comparer).Compare(eb, ea)
+	checkErrorFatal(t, err, nil)
+	if actual != -expected {
+		t.Errorf("Schema: %s; A: %#v; B: %#v; Actual: %#v; Expected: %#v", schema, b, a, actual, -expected)
+	}
+}
+
+func TestCodecComparePrimitives(t *testing.T) {
+	checkCompare(t, `"null"`, nil, nil, 0)
+	checkCompare(t, `"boolean"`, false, true, -1)
+	checkCompare(t, `"int"`, int32(-3), int32(2), -1)
+	checkCompare(t, `"long"`, int64(300), int64(300), 0)
+	checkCompare(t, `"long"`, int64(-1), int64(-300), 1)
+	checkCompare(t, `"float"`, float32(1.5), float32(-2), 1)
+	checkCompare(t, `"double"`, float64(-1.5), float64(2), -1)
+	checkCompare(t, `"bytes"`, []byte("\x01\xff"), []byte("\x02"), -1)
+	checkCompare(t, `"string"`, "abc", "ab", 1)
+	checkCompare(t, `"string"`, "é", "z", 1)
+	checkCompare(t, `{"type":"fixed","name":"f","size":2}`, Fixed{Name: "f", Value: []byte("ab")}, Fixed{Name: "f", Value: []byte("ba")}, -1)
+	checkCompare(t, `{"type":"enum","name":"e","symbols":["Z","A"]}`, Enum{Name: "e", Value: "Z"}, Enum{Name: "e", Value: "A"}, -1)
+}
+
+func TestCodecCompareComposites(t *testing.T) {
+	checkCompare(t, `{"type":"array","items":"int"}`, []interface{}{int32(1), int32(2)}, []interface{}{int32(1), int32(2)}, 0)
+	checkCompare(t, `{"type":"array","items":"int"}`, []interface{}{int32(1)}, []interface{}{int32(1), int32(2)}, -1)
+	checkCompare(t, `{"type":"array","items":"int"}`, []interface{}{int32(2)}, []interface{}{int32(1), int32(2)}, 1)
+	checkCompare(t, `{"type":"array","items":"string"}`, []interface{}{}, []interface{}{"a"}, -1)
+	checkCompare(t, `["null","int","string"]`, nil, int32(-5), -1)
+	checkCompare(t, `["null","int","string"]`, "a", int32(5), 1)
+	checkCompare(t, `["null","int","string"]`, int32(4), int32(5), -1)
+}
+
+func TestCodecCompareRecordOrder(t *testing.T) {
+	schema := `{"type":"record","name":"r","fields":[
+		{"name":"tags","type":{"type":"map","values":"string"},"order":"ignore"},
+		{"name":"a","type":"int"},
+		{"name":"b","type":"string","order":"descending"},
+		{"name":"c","type":{"type":"array","items":"long"},"order":"ignore"},
+		{"name":"d","type":"long","order":"ascending"}]}`
+	record := func(tags map[string]interface{}, a int32, b string, c []interface{}, d int64) map[string]interface{} {
+		return map[string]interface{}{"tags": tags, "a": a, "b": b, "c": c, "d": d}
+	}
+	tags := map[string]interface{}{"k": "v"}
+	checkCompare(t, schema, record(tags, 1, "x", nil, 1), record(nil, 1, "x", []interface{}{int64(9)}, 1), 0)
+	checkCompare(t, schema, record(nil, 1, "x", nil, 1), record(nil, 2, "a", nil, 0), -1)
+	checkCompare(t, schema, record(nil, 1, "x", nil, 1), record(nil, 1, "y", nil, 0), 1)
+	checkCompare(t, schema, record(tags, 1, "x", []interface{}{int64(1)}, 1), record(nil, 1, "x", nil, 2), -1)
+}
+
+func TestCodecCompareErrors(t *testing.T) {
+	codec, err := NewCodec(`{"type":"map","values":"int"}`)
+	checkErrorFatal(t, err, nil)
+	_, err = codec.(Comparer).Compare([]byte("\x00"), []byte("\x00"))
+	checkError(t, err, "maps cannot be compared")
+
+	codec, err = NewCodec(`{"type":"record","name":"r","fields":[{"name":"a","type":"int"},{"name":"b","type":"string"}]}`)
+	checkErrorFatal(t, err, nil)
+	_, err = codec.(Comparer).Compare([]byte("\x02\x02a"), []byte("\x02\x04a"))
+	checkError(t, err, "cannot decode record (r) at b")
+}
@@ -0,0 +1,277 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+
+package goavro
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CheckCompatibility determines whether data written with the writer
+// schema can be read using the reader schema, following the schema
+// resolution rules of the Avro specification. When the schemas are not
+// compatible, the returned reasons explain why, each prefixed by the
+// path of the offending field when it is not the top level datum. An
+// error is returned when either schema is invalid.
+//
+//   ok, reasons, err := goavro.CheckCompatibility(newSchema, oldSchema)
+//   if err != nil {
+//       return err
+//   }
+//   if !ok {
+//       for _, reason := range reasons {
+//           fmt.Println(reason)
+//       }
+//   }
+func CheckCompatibility(readerSchema, writerSchema string) (bool, []string, error) {
+	reader, err := inlinedSchemaTree(readerSchema)
+	if err != nil {
+		return false, nil, err
+	}
+	writer, err := inlinedSchemaTree(writerSchema)
+	if err != nil {
+		return false, nil, err
+	}
+	ok, reasons := checkSchemaCompatibility(reader, writer, false)
+	return ok, reasons, nil
+}
+
+// checkSchemaCompatibility returns whether the reader schema can read data
+// written with the writer schema, and the reasons it cannot. When
+// symbolsAtRead is set, symbols of the writer's enums missing from the
+// reader's are left to be reported when read, as the Avro
+// specification permits.
+func checkSchemaCompatibility(reader, writer interface{}, symbolsAtRead bool) (bool, []string) {
+	cc := &compatibilityChecker{inProgress: make(map[[2]string]bool), symbolsAtRead: symbolsAtRead}
+	cc.check("", reader, writer)
+	return len(cc.reasons) == 0, cc.reasons
+}
+
+func inlinedSchemaTree(someJSONSchema string) (interface{}, error) {
+	c, err := NewCodec(someJSONSchema)
+	if err != nil {
+		return nil, err
+	}
+	var schema interface{}
+	if err = json.Unmarshal([]byte(c.(*codec).InlinedSchema()), &schema); err != nil {
+		return nil, &ErrSchemaParse{"cannot unmarshal JSON", err}
+	}
+	return schema, nil
+}
+
+// schemaTypeName returns the Avro type name of the schema, "union" for
+// unions, or the fullname of a named type referenced by name.
+func schemaTypeName(schema interface{}) string {
+	switch schemaType := schema.(type) {
+	case string:
+		return schemaType
+	case []interface{}:
+		return "union"
+	case map[string]interface{}:
+		if typeName, ok := schemaType["type"].(string); ok {
+			return typeName
+		}
+		return schemaTypeName(schemaType["type"])
+	}
+	return fmt.Sprintf("%T", schema)
+}
+
+// schemaDescription returns the fullname of a named type, or otherwise
+// its type name, for use in reasons.
+func schemaDescription(schema interface{}) string {
+	if schemaMap, ok := schema.(map[string]interface{}); ok {
+		if someName, ok := schemaMap["name"].(string); ok {
+			return someName
+		}
+	}
+	return schemaTypeName(schema)
+}
+
+// promotions lists, for each writer primitive type, the reader types it
+// may be promoted to.
+var promotions = map[string][]string{
+	"int":    {"long", "float", "double"},
+	"long":   {"float", "double"},
+	"float":  {"double"},
+	"string": {"bytes"},
+	"bytes":  {"string"},
+}
+
+type compatibilityChecker struct {
+	inProgress    map[[2]string]bool // reader and writer records being checked
+	reasons       []string
+	symbolsAtRead bool // leave missing enum symbols to be reported when read
+}
+
+func (cc *compatibilityChecker) fail(path, format string, a ...interface{}) {
+	reason := fmt.Sprintf(format, a...)
+	if path != "" {
+		reason = "at " + path + ": " + reason
+	}
+	cc.reasons = append(cc.reasons, reason)
+}
+
+// readable returns true when the reader schema can read the writer
+// schema, without recording reasons.
+func (cc *compatibilityChecker) readable(reader, writer interface{}) bool {
+	sub := &compatibilityChecker{inProgress: cc.inProgress, symbolsAtRead: cc.symbolsAtRead}
+	sub.check("", reader, writer)
+	return len(sub.reasons) == 0
+}
+
+func (cc *compatibilityChecker) check(path string, reader, writer interface{}) {
+	readerType, writerType := schemaTypeName(reader), schemaTypeName(writer)
+
+	if writerType == "union" {
+		// every branch the writer might have written must be readable
+		for _, member := range writer.([]interface{}) {
+			if readerType == "union" {
+				if !cc.readableByUnion(reader.([]interface{}), member) {
+					cc.fail(path, "reader union has no branch for writer branch %s", schemaDescription(member))
+				}
+				continue
+			}
+			cc.check(path, reader, member)
+		}
+		return
+	}
+	if readerType == "union" {
+		if !cc.readableByUnion(reader.([]interface{}), writer) {
+			cc.fail(path, "reader union has no branch for writer type %s", schemaDescription(writer))
+		}
+		return
+	}
+
+	if isPrimitiveTypeName(writerType) {
+		if readerType == writerType {
+			return
+		}
+		for _, promoted := range promotions[writerType] {
+			if readerType == promoted {
+				return
+			}
+		}
+		cc.fail(path, "reader type %s cannot read writer type %s", schemaDescription(reader), writerType)
+		return
+	}
+	if readerType != writerType {
+		cc.fail(path, "reader type %s cannot read writer type %s", schemaDescription(reader), schemaDescription(writer))
+		return
+	}
+
+	readerMap, _ := reader.(map[string]interface{})
+	writerMap, _ := writer.(map[string]interface{})
+	switch writerType {
+	case "array":
+		cc.check(itemPath(path, "*"), readerMap["items"], writerMap["items"])
+	case "map":
+		cc.check(itemPath(path, "*"), readerMap["values"], writerMap["values"])
+	case "fixed":
+		if cc.checkNames(path, readerMap, writerMap) && readerMap["size"] != writerMap["size"] {
+			cc.fail(path, "reader fixed %s has size %v but writer size is %v", readerMap["name"], readerMap["size"], writerMap["size"])
+		}
+	case "enum":
+		if !cc.checkNames(path, readerMap, writerMap) {
+			return
+		}
+		if _, hasDefault := readerMap["default"]; hasDefault || cc.symbolsAtRead {
+			return
+		}
+		readerSymbols := make(map[interface{}]bool)
+		for _, symbol := range readerMap["symbols"].([]interface{}) {
+			readerSymbols[symbol] = true
+		}
+		for _, symbol := range writerMap["symbols"].([]interface{}) {
+			if !readerSymbols[symbol] {
+				cc.fail(path, "writer symbol %v is missing in reader enum %s", symbol, readerMap["name"])
+			}
+		}
+	case "record", "error":
+		if !cc.checkNames(path, readerMap, writerMap) {
+			return
+		}
+		pair := [2]string{readerMap["name"].(string), writerMap["name"].(string)}
+		if cc.inProgress[pair] {
+			return // recursive reference, already being checked
+		}
+		cc.inProgress[pair] = true
+		defer delete(cc.inProgress, pair)
+
+		writerFields := make(map[string]interface{})
+		for _, field := range writerMap["fields"].([]interface{}) {
+			fieldMap := field.(map[string]interface{})
+			writerFields[fieldMap["name"].(string)] = fieldMap["type"]
+		}
+		for _, field := range readerMap["fields"].([]interface{}) {
+			fieldMap := field.(map[string]interface{})
+			fieldName := fieldMap["name"].(string)
+			writerField, ok := writerFields[fieldName]
+			if !ok {
+				aliases, _ := fieldMap["aliases"].([]interface{})
+				for _, alias := range aliases {
+					if someAlias, isString := alias.(string); isString {
+						if writerField, ok = writerFields[someAlias]; ok {
+							break
+						}
+					}
+				}
+			}
+			if !ok {
+				if _, hasDefault := fieldMap["default"]; !hasDefault {
+					cc.fail(path, "reader field %s has no default and is missing in writer", fieldName)
+				}
+				continue
+			}
+			cc.check(fieldPath(path, fieldName), fieldMap["type"], writerField)
+		}
+	}
+}
+
+func (cc *compatibilityChecker) readableByUnion(reader []interface{}, writer interface{}) bool {
+	for _, member := range reader {
+		if cc.readable(member, writer) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkNames returns true when the named types match by fullname, or
+// by one of the aliases of the reader, and otherwise records a reason.
+func (cc *compatibilityChecker) checkNames(path string, reader, writer map[string]interface{}) bool {
+	readerName, _ := reader["name"].(string)
+	writerName, _ := writer["name"].(string)
+	if readerName == writerName {
+		return true
+	}
+	readerNamespace := name{n: readerName}.namespace()
+	aliases, _ := reader["aliases"].([]interface{})
+	for _, alias := range aliases {
+		someAlias, ok := alias.(string)
+		if !ok {
+			continue
+		}
+		nm, err := newName(nameName(someAlias), nameEnclosingNamespace(readerNamespace))
+		if err == nil && nm.n == writerName {
+			return true
+		}
+	}
+	cc.fail(path, "reader %s %s does not match writer %s", schemaTypeName(reader), readerName, writerName)
+	return false
+}
@@ -0,0 +1,82 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+
+package goavro
+
+import (
+	"reflect"
+	"testing"
+)
+
+func checkCompatibility(t *testing.T, reader, writer string, expected []string) {
+	ok, reasons, err := CheckCompatibility(reader, writer)
+	checkErrorFatal(t, err, nil)
+	if ok != (len(expected) == 0) {
+		t.Errorf("Reader: %s; Writer: %s; Actual: %#v; Expected: %#v", reader, writer, ok, len(expected) == 0)
+	}
+	if len(reasons) != 0 || len(expected) != 0 {
+		if !reflect.DeepEqual(reasons, expected) {
+			t.Errorf("Reader: %s; Writer: %s; Actual: %#v; Expected: %#v", reader, writer, reasons, expected)
+		}
+	}
+}
+
+func TestCheckCompatibilityPrimitives(t *testing.T) {
+	checkCompatibility(t, `"int"`, `"int"`, nil)
+	checkCompatibility(t, `"long"`, `"int"`, nil)
+	checkCompatibility(t, `"double"`, `"float"`, nil)
+	checkCompatibility(t, `"bytes"`, `"string"`, nil)
+	checkCompatibility(t, `"int"`, `"long"`, []string{"reader type int cannot read writer type long"})
+	checkCompatibility(t, `{"type":"array","items":"long"}`, `{"type":"array","items":"int"}`, nil)
+	checkCompatibility(t, `{"type":"map","values":"int"}`, `{"type":"map","values":"string"}`, []string{"at [*]: reader type int cannot read writer type string"})
+}
+
+func TestCheckCompatibilityUnions(t *testing.T) {
+	checkCompatibility(t, `["null","long"]`, `"int"`, nil)
+	checkCompatibility(t, `["null","string"]`, `"int"`, []string{"reader union has no branch for writer type int"})
+	checkCompatibility(t, `"long"`, `["int","long"]`, nil)
+	checkCompatibility(t, `"long"`, `["null","long"]`, []string{"reader type long cannot read writer type null"})
+	checkCompatibility(t, `["null","string"]`, `["null","int"]`, []string{"reader union has no branch for writer branch int"})
+}
+
+func TestCheckCompatibilityNamedTypes(t *testing.T) {
+	checkCompatibility(t, `{"type":"enum","name":"e","symbols":["A","B","C"]}`, `{"type":"enum","name":"e","symbols":["A","B"]}`, nil)
+	checkCompatibility(t, `{"type":"enum","name":"e","symbols":["A"]}`, `{"type":"enum","name":"e","symbols":["A","B"]}`, []string{"writer symbol B is missing in reader enum e"})
+	checkCompatibility(t, `{"type":"enum","name":"e","symbols":["A"],"default":"A"}`, `{"type":"enum","name":"e","symbols":["A","B"]}`, nil)
+	checkCompatibility(t, `{"type":"fixed","name":"f","size":4}`, `{"type":"fixed","name":"f","size":8}`, []string{"reader fixed f has size 4 but writer size is 8"})
+	checkCompatibility(t, `{"type":"fixed","name":"f","size":4}`, `{"type":"fixed","name":"g","size":4}`, []string{"reader fixed f does not match writer g"})
+	checkCompatibility(t, `{"type":"fixed","name":"f","aliases":["g"],"size":4}`, `{"type":"fixed","name":"g","size":4}`, nil)
+}
+
+func TestCheckCompatibilityRecords(t *testing.T) {
+	writer := `{"type":"record","name":"user","fields":[{"name":"name","type":"string"},{"name":"age","type":"int"},{"name":"home","type":{"type":"record","name":"address","fields":[{"name":"zip","type":"long"}]}}]}`
+
+	// fields may be dropped, promoted, renamed through aliases, or added with defaults
+	reader := `{"type":"record","name":"user","fields":[{"name":"years","aliases":["age"],"type":"long"},{"name":"email","type":["null","string"],"default":null},{"name":"home","type":{"type":"record","name":"address","fields":[{"name":"zip","type":"double"}]}}]}`
+	checkCompatibility(t, reader, writer, nil)
+
+	reader = `{"type":"record","name":"user","fields":[{"name":"email","type":"string"},{"name":"age","type":"string"},{"name":"home","type":{"type":"record","name":"address","fields":[{"name":"zip","type":"int"}]}}]}`
+	checkCompatibility(t, reader, writer, []string{
+		"reader field email has no default and is missing in writer",
+		"at age: reader type string cannot read writer type int",
+		"at home.zip: reader type int cannot read writer type long",
+	})
+
+	_, _, err := CheckCompatibility(`{"type":"record"}`, writer)
+	checkError(t, err, "cannot build record")
+}
@@ -0,0 +1,166 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+
+package goavro
+
+import (
+	"strings"
+)
+
+// ConverterFunction converts a decoded value into an application type.
+type ConverterFunction func(interface{}) (interface{}, error)
+
+// DecodeConverter returns a CodecSetter which causes the codec to pass
+// each non-null value decoded for a record field through fn, and to
+// store the value fn returns in the record instead.
+//
+// The key is either the dotted path of a field of the codec's record,
+// such as "address.zip", or a type: a primitive type name such as
+// "long", the fullname of a named type, or a logical type such as
+// "timestamp-millis". Type keys match fields of that type, and fields
+// of a union of null and that type, wherever they appear in the
+// schema. When several converters match a field, the one registered
+// by field path takes precedence, followed by logical type, then
+// type. A path identifies a field of a record type, so it also applies
+// wherever else that record type appears in the schema.
+//
+//   codec, err := goavro.NewCodec(someSchema, goavro.DecodeConverter("timestamp-millis", func(datum interface{}) (interface{}, error) {
+//       ms := datum.(int64)
+//       return time.Unix(ms/1000, (ms%1000)*int64(time.Millisecond)), nil
+//   }))
+func DecodeConverter(key string, fn ConverterFunction) CodecSetter {
+	return func(c Codec) error {
+		someCodec, ok := c.(*codec)
+		if !ok || someCodec.opts == nil {
+			return newCodecBuildError("codec", "DecodeConverter ought to be used with NewCodec or NewJSONCodec")
+		}
+		if fn == nil {
+			return newCodecBuildError("codec", "DecodeConverter ought to have a function for %q", key)
+		}
+		if pathKey, ok := someCodec.converterPathKey(key); ok {
+			key = pathKey
+		}
+		if someCodec.opts.converters == nil {
+			someCodec.opts.converters = make(map[string]ConverterFunction)
+		}
+		someCodec.opts.converters[key] = fn
+		return nil
+	}
+}
+
+// converterPathKey returns the converter key of the record field found
+// at the specified dotted path, and whether the path named a field.
+func (c *codec) converterPathKey(path string) (string, bool) {
+	current := c
+	segments := strings.Split(path, ".")
+	for idx, segment := range segments {
+		child, ok := current.fc[segment]
+		if !ok {
+			return "", false
+		}
+		if idx == len(segments)-1 {
+			return fieldConverterKey(current.nm.n, segment), true
+		}
+		current = child
+	}
+	return "", false
+}
+
+// fieldConverterKey returns the converter key of the named field of
+// the named record. It cannot collide with type keys, which never
+// contain '#'.
+func fieldConverterKey(recordName, fieldName string) string {
+	return recordName + "#" + fieldName
+}
+
+// fieldConverterKeys returns, for each field of the record, the keys
+// of the converters which may apply to it, in order of precedence.
+func fieldConverterKeys(record *Record) [][]string {
+	keys := make([][]string, len(record.Fields))
+	for idx, field := range record.Fields {
+		keys[idx] = append([]string{fieldConverterKey(record.Name, name{n: field.Name}.basename())},
+			typeConverterKeys(record.n.namespace(), field.schema)...)
+	}
+	return keys
+}
+
+// typeConverterKeys returns the logical type and the type name or
+// fullname of the schema, or of the non-null member of a union of null
+// and one other type.
+func typeConverterKeys(enclosingNamespace string, schema interface{}) []string {
+	switch schemaType := schema.(type) {
+	case string:
+		if isPrimitiveTypeName(schemaType) {
+			return []string{schemaType}
+		}
+		nm, err := newName(nameName(schemaType), nameEnclosingNamespace(enclosingNamespace))
+		if err != nil {
+			return nil
+		}
+		return []string{nm.n}
+	case []interface{}:
+		if len(schemaType) == 2 {
+			if schemaType[0] == "null" {
+				return typeConverterKeys(enclosingNamespace, schemaType[1])
+			}
+			if schemaType[1] == "null" {
+				return typeConverterKeys(enclosingNamespace, schemaType[0])
+			}
+		}
+	case map[string]interface{}:
+		var keys []string
+		if logicalType, ok := schemaType["logicalType"].(string); ok {
+			keys = append(keys, logicalType)
+		}
+		switch typeName := schemaType["type"].(type) {
+		case string:
+			switch typeName {
+			case "record", "error", "enum", "fixed":
+				nm, err := newName(nameSchema(schemaType), nameEnclosingNamespace(enclosingNamespace))
+				if err != nil {
+					return keys
+				}
+				return append(keys, nm.n)
+			case "array", "map":
+				return append(keys, typeName)
+			}
+			return append(keys, typeConverterKeys(enclosingNamespace, typeName)...)
+		default:
+			return append(keys, typeConverterKeys(enclosingNamespace, typeName)...)
+		}
+	}
+	return nil
+}
+
+// convertField passes a decoded field value through the first
+// converter registered under one of the keys.
+func (opts *codecOptions) convertField(keys []string, value interface{}) (interface{}, error) {
+	if len(opts.converters) == 0 || value == nil {
+		return value, nil
+	}
+	for _, key := range keys {
+		if fn, ok := opts.converters[key]; ok {
+			converted, err := fn(value)
+			if err != nil {
+				return nil, newDecoderError("converter", err)
+			}
+			return converted, nil
+		}
+	}
+	return value, nil
+}
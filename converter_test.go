@@ -0,0 +1,99 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+
+package goavro
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type testColor string
+
+func TestDecodeConverter(t *testing.T) {
+	schema := `{"type":"record","name":"user","fields":[
+		{"name":"ts","type":{"type":"long","logicalType":"timestamp-millis"}},
+		{"name":"count","type":"long"},
+		{"name":"nick","type":["null","string"]},
+		{"name":"color","type":{"type":"enum","name":"color","symbols":["RED","GREEN"]}},
+		{"name":"address","type":{"type":"record","name":"address","fields":[{"name":"zip","type":"string"},{"name":"city","type":"string"}]}},
+		{"name":"pets","type":{"type":"array","items":{"type":"record","name":"pet","fields":[{"name":"name","type":"string"}]}}}]}`
+	setters := []CodecSetter{
+		DecodeConverter("timestamp-millis", func(datum interface{}) (interface{}, error) {
+			return fmt.Sprintf("ts:%d", datum), nil
+		}),
+		DecodeConverter("long", func(datum interface{}) (interface{}, error) {
+			return int(datum.(int64)), nil
+		}),
+		DecodeConverter("string", func(datum interface{}) (interface{}, error) {
+			return "s:" + datum.(string), nil
+		}),
+		DecodeConverter("color", func(datum interface{}) (interface{}, error) {
+			return testColor(datum.(Enum).Value), nil
+		}),
+		DecodeConverter("address.zip", func(datum interface{}) (interface{}, error) {
+			return "zip:" + datum.(string), nil
+		}),
+	}
+	datum := map[string]interface{}{
+		"ts":      int64(7),
+		"count":   int64(3),
+		"nick":    nil,
+		"color":   Enum{Name: "color", Value: "GREEN"},
+		"address": map[string]interface{}{"zip": "10001", "city": "NYC"},
+		"pets":    []interface{}{map[string]interface{}{"name": "rex"}},
+	}
+	expected := map[string]interface{}{
+		"ts":      "ts:7",
+		"count":   3,
+		"nick":    nil,
+		"color":   testColor("GREEN"),
+		"address": map[string]interface{}{"zip": "zip:10001", "city": "s:NYC"},
+		"pets":    []interface{}{map[string]interface{}{"name": "s:rex"}},
+	}
+
+	for _, build := range []func(string, ...CodecSetter) (Codec, error){NewCodec, NewJSONCodec} {
+		plain, err := build(schema)
+		checkErrorFatal(t, err, nil)
+		bb := new(bytes.Buffer)
+		checkErrorFatal(t, plain.Encode(bb, datum), nil)
+
+		codec, err := build(schema, setters...)
+		checkErrorFatal(t, err, nil)
+		actual, err := codec.Decode(bb)
+		checkErrorFatal(t, err, nil)
+		if native := nativeDatum(actual); !reflect.DeepEqual(native, expected) {
+			t.Errorf("Actual: %#v; Expected: %#v", native, expected)
+		}
+	}
+}
+
+func TestDecodeConverterErrors(t *testing.T) {
+	schema := `{"type":"record","name":"r","fields":[{"name":"n","type":"int"}]}`
+	_, err := NewCodec(schema, DecodeConverter("n", nil))
+	checkError(t, err, `DecodeConverter ought to have a function for "n"`)
+
+	codec, err := NewCodec(schema, DecodeConverter("n", func(datum interface{}) (interface{}, error) {
+		return nil, fmt.Errorf("no thanks")
+	}))
+	checkErrorFatal(t, err, nil)
+	_, err = codec.Decode(bytes.NewReader([]byte("\x02")))
+	checkError(t, err, "cannot decode record (r) at n: cannot decode converter: no thanks")
+}
@@ -0,0 +1,86 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+
+package goavro
+
+import (
+	"bufio"
+	"io"
+)
+
+// DatumReader reads a sequence of data, each encoded with the same
+// Codec, from an io.Reader, such as a stream of concatenated records.
+//
+//   dr := goavro.NewDatumReader(r, codec)
+//   for dr.Next() {
+//       fmt.Println(dr.Datum())
+//   }
+//   if err := dr.Err(); err != nil {
+//       log.Fatal(err)
+//   }
+type DatumReader struct {
+	br    *bufio.Reader
+	cr    *countingReader
+	codec Codec
+	datum interface{}
+	err   error
+}
+
+// NewDatumReader returns a DatumReader that decodes data from r using
+// the specified Codec. Reads from r are buffered, so r may be read past
+// the last datum returned.
+func NewDatumReader(r io.Reader, c Codec) *DatumReader {
+	br := bufio.NewReader(r)
+	return &DatumReader{br: br, cr: &countingReader{r: br}, codec: c}
+}
+
+// Next decodes the next datum, returning true when one is available by
+// calling Datum. It returns false when the io.Reader is exhausted at a
+// datum boundary, or when an error occurs, which is then returned by
+// Err. Running out of data part way through a datum is an error.
+func (dr *DatumReader) Next() bool {
+	if dr.err != nil {
+		return false
+	}
+	// A datum of a zero-width schema, such as null, reads no bytes, so
+	// look for the end of the stream before decoding rather than after.
+	if _, dr.err = dr.br.Peek(1); dr.err != nil {
+		dr.datum = nil
+		return false
+	}
+	dr.datum, dr.err = dr.codec.Decode(dr.cr)
+	if dr.err != nil {
+		dr.datum = nil
+		return false
+	}
+	return true
+}
+
+// Datum returns the datum decoded by the most recent call to Next.
+func (dr *DatumReader) Datum() interface{} {
+	return dr.datum
+}
+
+// Err returns the error that stopped iteration, or nil when the
+// io.Reader was exhausted at a datum boundary.
+func (dr *DatumReader) Err() error {
+	if dr.err == io.EOF {
+		return nil
+	}
+	return dr.err
+}
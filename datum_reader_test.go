@@ -0,0 +1,80 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+
+package goavro
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestDatumReader(t *testing.T) {
+	codec, err := NewCodec(`{"type":"record","name":"r","fields":[{"name":"n","type":"int"},{"name":"s","type":"string"}]}`)
+	checkErrorFatal(t, err, nil)
+
+	bb := new(bytes.Buffer)
+	for _, n := range []int32{1, 2, 3} {
+		checkErrorFatal(t, codec.Encode(bb, map[string]interface{}{"n": n, "s": "x"}), nil)
+	}
+	whole := bb.Bytes()
+
+	dr := NewDatumReader(bytes.NewReader(whole), codec)
+	var actual []interface{}
+	for dr.Next() {
+		n, err := dr.Datum().(*Record).Get("n")
+		checkErrorFatal(t, err, nil)
+		actual = append(actual, n)
+	}
+	checkError(t, dr.Err(), nil)
+	if expected := []interface{}{int32(1), int32(2), int32(3)}; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+	if dr.Next() {
+		t.Errorf("Actual: %#v; Expected: %#v", true, false)
+	}
+
+	// empty stream
+	dr = NewDatumReader(bytes.NewReader(nil), codec)
+	if dr.Next() {
+		t.Errorf("Actual: %#v; Expected: %#v", true, false)
+	}
+	checkError(t, dr.Err(), nil)
+
+	// truncated within the final record
+	dr = NewDatumReader(bytes.NewReader(whole[:len(whole)-1]), codec)
+	var count int
+	for dr.Next() {
+		count++
+	}
+	if count != 2 {
+		t.Errorf("Actual: %#v; Expected: %#v", count, 2)
+	}
+	checkError(t, dr.Err(), "cannot decode record (r) at s: ")
+}
+
+func TestDatumReaderZeroWidth(t *testing.T) {
+	codec, err := NewCodec(`"null"`)
+	checkErrorFatal(t, err, nil)
+
+	dr := NewDatumReader(bytes.NewReader(nil), codec)
+	if dr.Next() {
+		t.Errorf("Actual: %#v; Expected: %#v", true, false)
+	}
+	checkError(t, dr.Err(), nil)
+}
@@ -0,0 +1,164 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+
+package goavro
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// DecodeDecimals returns a CodecSetter which causes the codec to decode
+// bytes and fixed values of the decimal logical type as *big.Rat, and
+// to accept *big.Rat for them when encoding, in addition to []byte and
+// Fixed. Bytes hold the unscaled value as a big-endian two's
+// complement integer of as many bytes as it needs, whereas fixed
+// values sign extend it to the size of the fixed type. Encoding a
+// value which has more digits after the decimal point than the scale
+// of the schema, more digits in all than its precision, or which does
+// not fit in the size of a fixed type, returns an error. Decimal
+// schemas whose precision or scale are invalid are treated as their
+// underlying type, as the Avro specification requires.
+//
+//   codec, err := goavro.NewCodec(`{"type":"fixed","name":"money","size":8,"logicalType":"decimal","precision":18,"scale":2}`, goavro.DecodeDecimals())
+//   err = codec.Encode(w, big.NewRat(1234, 100)) // 12.34
+func DecodeDecimals() CodecSetter {
+	return func(c Codec) error {
+		someCodec, ok := c.(*codec)
+		if !ok || someCodec.opts == nil || someCodec.jdf != nil {
+			return newCodecBuildError("codec", "DecodeDecimals ought to be used with NewCodec")
+		}
+		someCodec.opts.decimals = true
+		return nil
+	}
+}
+
+// decimalSchema returns the precision and scale of a schema of the
+// decimal logical type, and whether the schema is one whose attributes
+// are valid. Size is the size of a fixed type, or -1 for bytes.
+func decimalSchema(schemaMap map[string]interface{}, size int) (int, int, bool) {
+	if logicalType, _ := schemaMap["logicalType"].(string); logicalType != "decimal" {
+		return 0, 0, false
+	}
+	p, ok := schemaMap["precision"].(float64)
+	if !ok || p < 1 || p != float64(int(p)) {
+		return 0, 0, false
+	}
+	var s float64
+	if someValue, ok := schemaMap["scale"]; ok {
+		if s, ok = someValue.(float64); !ok || s < 0 || s > p || s != float64(int(s)) {
+			return 0, 0, false
+		}
+	}
+	if size >= 0 && int(p) > maxFixedDecimalPrecision(size) {
+		return 0, 0, false
+	}
+	return int(p), int(s), true
+}
+
+// maxFixedDecimalPrecision returns the number of decimal digits a
+// two's complement integer of size bytes can always hold.
+func maxFixedDecimalPrecision(size int) int {
+	if size == 0 {
+		return 0
+	}
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(8*size-1))
+	return len(limit.Sub(limit, big.NewInt(1)).String()) - 1
+}
+
+// decimalCodec wraps the decoder, encoder, and validator of
+// someCodec, a bytes or fixed codec of the decimal logical type, so
+// that when opts.decimals is set they exchange *big.Rat values. Size
+// is the size of a fixed type, or -1 for bytes.
+func decimalCodec(opts *codecOptions, someCodec *codec, friendlyName string, precision, scale, size int) *codec {
+	c := *someCodec
+	df, ef := c.df, c.ef
+	friendlyName = fmt.Sprintf("decimal %s", friendlyName)
+	c.df = func(r io.Reader) (interface{}, error) {
+		datum, err := df(r)
+		if err != nil || !opts.decimals {
+			return datum, err
+		}
+		if someFixed, ok := datum.(Fixed); ok {
+			return decimalFromBytes(someFixed.Value, scale), nil
+		}
+		return decimalFromBytes(datum.([]byte), scale), nil
+	}
+	c.ef = func(w io.Writer, datum interface{}) error {
+		if someRat, ok := datum.(*big.Rat); ok && someRat != nil && opts.decimals {
+			buf, err := decimalBytes(someRat, precision, scale, size)
+			if err != nil {
+				return newEncoderError(friendlyName, err)
+			}
+			if size >= 0 {
+				return ef(w, Fixed{Name: c.nm.n, Value: buf})
+			}
+			datum = buf
+		}
+		return ef(w, datum)
+	}
+	c.vf = validatorFromEncoder(c.ef)
+	return &c
+}
+
+// decimalFromBytes returns the decimal whose unscaled value is the
+// big-endian two's complement integer held by buf.
+func decimalFromBytes(buf []byte, scale int) *big.Rat {
+	unscaled := new(big.Int).SetBytes(buf)
+	if len(buf) > 0 && buf[0]&0x80 != 0 {
+		// sign extend
+		unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(8*len(buf))))
+	}
+	return new(big.Rat).SetFrac(unscaled, decimalDenominator(scale))
+}
+
+// decimalBytes returns the unscaled value of someRat as a big-endian
+// two's complement integer, in as few bytes as it needs when size is
+// -1, and sign extended to size bytes otherwise.
+func decimalBytes(someRat *big.Rat, precision, scale, size int) ([]byte, error) {
+	scaled := new(big.Rat).Mul(someRat, new(big.Rat).SetInt(decimalDenominator(scale)))
+	if !scaled.IsInt() {
+		return nil, fmt.Errorf("cannot represent %s with scale %d", someRat.FloatString(scale+1), scale)
+	}
+	unscaled := scaled.Num()
+	if digits := len(new(big.Int).Abs(unscaled).String()); digits > precision {
+		return nil, fmt.Errorf("cannot represent %s with precision %d", someRat.FloatString(scale), precision)
+	}
+	magnitude := unscaled
+	if unscaled.Sign() < 0 {
+		magnitude = new(big.Int).Not(unscaled)
+	}
+	length := magnitude.BitLen()/8 + 1
+	if size >= 0 {
+		if length > size {
+			return nil, fmt.Errorf("cannot represent %s in %d bytes", someRat.FloatString(scale), size)
+		}
+		length = size
+	}
+	twos := new(big.Int).Set(unscaled)
+	if unscaled.Sign() < 0 {
+		twos.Add(twos, new(big.Int).Lsh(big.NewInt(1), uint(8*length)))
+	}
+	return twos.FillBytes(make([]byte, length)), nil
+}
+
+// decimalDenominator returns ten to the power of scale.
+func decimalDenominator(scale int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)
+}
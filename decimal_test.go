@@ -0,0 +1,118 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+
+package goavro
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func checkDecimalDecode(t *testing.T, codec Codec, bits []byte, expected *big.Rat) {
+	datum, err := codec.Decode(bytes.NewReader(bits))
+	checkErrorFatal(t, err, nil)
+	actual, ok := datum.(*big.Rat)
+	if !ok || actual.Cmp(expected) != 0 {
+		t.Errorf("Actual: %#v; Expected: %#v", datum, expected.FloatString(2))
+	}
+}
+
+func checkDecimalEncode(t *testing.T, codec Codec, datum *big.Rat, expected []byte) {
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, codec.Encode(bb, datum), nil)
+	if actual := bb.Bytes(); !bytes.Equal(actual, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+}
+
+func TestCodecDecimalFixed(t *testing.T) {
+	schema := `{"type":"fixed","name":"money","size":4,"logicalType":"decimal","precision":9,"scale":2}`
+	codec, err := NewCodec(schema, DecodeDecimals())
+	checkErrorFatal(t, err, nil)
+
+	// as written by the Java implementation, which sign extends the
+	// unscaled value to the size of the fixed type
+	checkDecimalDecode(t, codec, []byte("\x00\x00\x04\xd2"), big.NewRat(1234, 100))
+	checkDecimalDecode(t, codec, []byte("\xff\xff\xff\x9c"), big.NewRat(-1, 1))
+	checkDecimalDecode(t, codec, []byte("\xff\xff\xfb\x2e"), big.NewRat(-1234, 100))
+	checkDecimalDecode(t, codec, []byte("\x00\x00\x00\x00"), new(big.Rat))
+
+	checkDecimalEncode(t, codec, big.NewRat(1234, 100), []byte("\x00\x00\x04\xd2"))
+	checkDecimalEncode(t, codec, big.NewRat(-1, 1), []byte("\xff\xff\xff\x9c"))
+	checkDecimalEncode(t, codec, big.NewRat(-1234, 100), []byte("\xff\xff\xfb\x2e"))
+	checkDecimalEncode(t, codec, big.NewRat(1, 2), []byte("\x00\x00\x00\x32"))
+
+	checkError(t, codec.Encode(new(bytes.Buffer), big.NewRat(12345, 1000)), "cannot represent 12.345 with scale 2")
+	checkError(t, codec.Encode(new(bytes.Buffer), big.NewRat(1e9, 1)), "cannot represent 1000000000.00 with precision 9")
+	checkError(t, codec.(Validator).Validate(big.NewRat(1, 3)), "with scale 2")
+	checkError(t, codec.(Validator).Validate(big.NewRat(1, 4)), nil)
+
+	// Fixed values still encode as is
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, codec.Encode(bb, Fixed{Name: "money", Value: []byte("\x00\x00\x04\xd2")}), nil)
+	if actual, expected := bb.Bytes(), []byte("\x00\x00\x04\xd2"); !bytes.Equal(actual, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+	checkError(t, codec.Encode(new(bytes.Buffer), (*big.Rat)(nil)), "expected: Fixed; received: *big.Rat")
+}
+
+func TestCodecDecimalBytes(t *testing.T) {
+	schema := `{"type":"bytes","logicalType":"decimal","precision":6,"scale":0}`
+	codec, err := NewCodec(schema, DecodeDecimals())
+	checkErrorFatal(t, err, nil)
+
+	// bytes hold the unscaled value in as few bytes as it needs
+	checkDecimalDecode(t, codec, []byte("\x02\x9c"), big.NewRat(-100, 1))
+	checkDecimalDecode(t, codec, []byte("\x04\x00\x80"), big.NewRat(128, 1))
+	checkDecimalEncode(t, codec, big.NewRat(-100, 1), []byte("\x02\x9c"))
+	checkDecimalEncode(t, codec, big.NewRat(128, 1), []byte("\x04\x00\x80"))
+	checkDecimalEncode(t, codec, big.NewRat(-128, 1), []byte("\x02\x80"))
+	checkDecimalEncode(t, codec, new(big.Rat), []byte("\x02\x00"))
+
+	union, err := NewCodec(`["null",`+schema+`]`, DecodeDecimals())
+	checkErrorFatal(t, err, nil)
+	checkDecimalEncode(t, union, big.NewRat(128, 1), []byte("\x02\x04\x00\x80"))
+	checkDecimalDecode(t, union, []byte("\x02\x04\x00\x80"), big.NewRat(128, 1))
+}
+
+func TestCodecDecimalOptional(t *testing.T) {
+	schema := `{"type":"fixed","name":"money","size":4,"logicalType":"decimal","precision":9,"scale":2}`
+
+	// without DecodeDecimals, decimals are their underlying type
+	checkCodecDecoderResult(t, schema, []byte("\x00\x00\x04\xd2"), Fixed{Name: "money", Value: []byte("\x00\x00\x04\xd2")})
+	checkCodecEncoderError(t, schema, big.NewRat(1, 1), "expected: Fixed; received: *big.Rat")
+
+	// as are decimals whose attributes are invalid
+	for _, invalid := range []string{
+		`{"type":"fixed","name":"money","size":4,"logicalType":"decimal","precision":10,"scale":2}`,
+		`{"type":"fixed","name":"money","size":4,"logicalType":"decimal","precision":2,"scale":3}`,
+		`{"type":"bytes","logicalType":"decimal","scale":2}`,
+	} {
+		codec, err := NewCodec(invalid, DecodeDecimals())
+		checkErrorFatal(t, err, nil)
+		datum, err := codec.Decode(bytes.NewReader([]byte("\x08\x00\x00\x04\xd2")))
+		checkErrorFatal(t, err, nil)
+		if _, ok := datum.(*big.Rat); ok {
+			t.Errorf("Actual: %#v; Expected: %s", datum, "underlying type")
+		}
+	}
+
+	_, err := NewJSONCodec(schema, DecodeDecimals())
+	checkError(t, err, "DecodeDecimals ought to be used with NewCodec")
+}
@@ -22,6 +22,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"math"
 )
 
@@ -44,17 +45,136 @@ import (
 //	}
 var MaxDecodeSize = int64(math.MaxInt32)
 
-// ErrDecoder is returned when the encoder encounters an error.
+// MaxBlockCount defines the maximum number of items a single block of
+// an array or map may claim to hold. Items of some types, such as
+// null, occupy no bytes, so without a limit a few bytes of corrupt
+// data could make the decoder loop and allocate practically forever.
+//
+// Example:
+//	func init() {
+//		goavro.MaxBlockCount = (1 << 40) // 1 T items per block
+//	}
+var MaxBlockCount = int64(math.MaxInt32)
+
+// MaxEmptyItems defines the maximum number of array items occupying no
+// bytes, such as items of type null, which a single datum may hold
+// altogether. Every other item consumes input, so the size of the
+// input bounds their number, but without this limit a few bytes of
+// corrupt data could claim billions of empty items.
+//
+// Example:
+//	func init() {
+//		goavro.MaxEmptyItems = (1 << 30) // 1 G empty items per datum
+//	}
+var MaxEmptyItems = int64(1 << 20)
+
+// readBlockCount reads the count of items of the next block of an
+// array or map, along with the size of the block when the count is
+// negative, and returns the absolute value of the count.
+func readBlockCount(r io.Reader) (int64, error) {
+	someValue, err := longDecoder(r)
+	if err != nil {
+		return 0, err
+	}
+	blockCount := someValue.(int64)
+	if blockCount < 0 {
+		if blockCount == math.MinInt64 {
+			return 0, fmt.Errorf("block count too large: %d", blockCount)
+		}
+		blockCount = -blockCount
+		// next long is size of block, for which we have no use
+		if _, err = longDecoder(r); err != nil {
+			return 0, err
+		}
+	}
+	if blockCount > MaxBlockCount {
+		return 0, fmt.Errorf("implementation error: block count (%d) is greater than the max currently set with MaxBlockCount (%d)", blockCount, MaxBlockCount)
+	}
+	return blockCount, nil
+}
+
+// readLength reads size bytes from r. Rather than allocating a buffer
+// of the claimed size up front, large reads grow their buffer as data
+// arrives, so a corrupt length cannot exhaust memory on a short input.
+func readLength(r io.Reader, size int64) ([]byte, error) {
+	const chunk = 64 * 1024
+	if size <= chunk {
+		buf := make([]byte, size)
+		_, err := io.ReadFull(r, buf)
+		return buf, err
+	}
+	buf := make([]byte, 0, chunk)
+	for int64(len(buf)) < size {
+		n := size - int64(len(buf))
+		if n > chunk {
+			n = chunk
+		}
+		start := len(buf)
+		buf = append(buf, make([]byte, n)...)
+		if _, err := io.ReadFull(r, buf[start:]); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+// skipLength reads and discards size bytes from r, without buffering
+// them all at once as readLength does.
+func skipLength(r io.Reader, size int64) error {
+	n, err := io.CopyN(ioutil.Discard, r, size)
+	if err == io.EOF && n > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// skipLengthPrefixed reads and discards bytes or a string from r.
+func skipLengthPrefixed(r io.Reader, dataType string) error {
+	size, err := longDecoder(r)
+	if err != nil {
+		return newDecoderError(dataType, err)
+	}
+	if size.(int64) < 0 {
+		return newDecoderError(dataType, "negative length: %d", size)
+	}
+	if err = skipLength(r, size.(int64)); err != nil {
+		return newDecoderError(dataType, err)
+	}
+	return nil
+}
+
+// ErrDecoder is returned when the encoder encounters an error. Path
+// identifies the portion of the datum that could not be decoded, for
+// instance `addresses[3].zip`, and is empty when the top level datum
+// itself is at fault. Path is relative to the schema named by
+// SchemaName, which is the fullname of a named type, or the Avro type
+// name otherwise. Offset is the number of bytes read from the
+// io.Reader, after the call to Decode, when the error was detected.
 type ErrDecoder struct {
-	Message string
-	Err     error
+	Message    string
+	Err        error
+	Path       string
+	SchemaName string
+	Offset     int64
 }
 
 func (e ErrDecoder) Error() string {
+	message := "cannot decode " + e.Message
+	if e.Path != "" {
+		message += " at " + e.Path
+	}
 	if e.Err == nil {
-		return "cannot decode " + e.Message
+		return message
 	}
-	return "cannot decode " + e.Message + ": " + e.Err.Error()
+	return message + ": " + e.Err.Error()
+}
+
+// Unwrap returns the error that caused the decoder error, if any.
+func (e ErrDecoder) Unwrap() error {
+	return e.Err
 }
 
 func newDecoderError(dataType string, a ...interface{}) *ErrDecoder {
@@ -62,7 +182,7 @@ func newDecoderError(dataType string, a ...interface{}) *ErrDecoder {
 	var format, message string
 	var ok bool
 	if len(a) == 0 {
-		return &ErrDecoder{dataType + ": no reason given", nil}
+		return &ErrDecoder{Message: dataType + ": no reason given"}
 	}
 	// if last item is error: save it
 	if err, ok = a[len(a)-1].(error); ok {
@@ -78,7 +198,136 @@ func newDecoderError(dataType string, a ...interface{}) *ErrDecoder {
 	if message != "" {
 		message = ": " + message
 	}
-	return &ErrDecoder{dataType + message, err}
+	return &ErrDecoder{Message: dataType + message, Err: err}
+}
+
+// newDecoderPathError wraps err, which was returned while decoding the
+// child found at segment of a complex datum. The path of err is hoisted
+// to the returned error, so only the outermost error reports the path.
+func newDecoderPathError(dataType, segment string, err error) *ErrDecoder {
+	path := segment
+	if ed, ok := err.(*ErrDecoder); ok {
+		path = joinPath(segment, ed.Path)
+		ed.Path = ""
+	}
+	e := newDecoderError(dataType, err)
+	e.Path = path
+	return e
+}
+
+// causedByEOF returns true when err is io.EOF, or a decoder error
+// resulting from it.
+func causedByEOF(err error) bool {
+	for err != nil {
+		if err == io.EOF {
+			return true
+		}
+		ed, ok := err.(*ErrDecoder)
+		if !ok {
+			return false
+		}
+		err = ed.Err
+	}
+	return false
+}
+
+// replaceEOF returns err with the io.EOF that caused it replaced by
+// io.ErrUnexpectedEOF, for data which were exhausted part way through.
+func replaceEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	for e := err; e != nil; {
+		ed, ok := e.(*ErrDecoder)
+		if !ok {
+			break
+		}
+		if ed.Err == io.EOF {
+			ed.Err = io.ErrUnexpectedEOF
+			break
+		}
+		e = ed.Err
+	}
+	return err
+}
+
+// countingReader tallies the bytes read through it, so decoding errors
+// may report the offset at which they occurred. It also tracks the
+// nesting depth of the complex datum being decoded.
+type countingReader struct {
+	r     io.Reader
+	n     int64
+	depth int
+	empty int64 // array items of the current datum which occupied no bytes
+}
+
+// defaultMaxDecodeDepth is how deeply complex data may nest when
+// decoding, unless changed with MaxDecodeDepth.
+const defaultMaxDecodeDepth = 1000
+
+// maxLength returns the length limit of decoded strings and bytes,
+// along with the name of the setting which imposes it.
+func (opts *codecOptions) maxLength() (int64, string) {
+	if opts == nil || opts.maxDecodeLength <= 0 {
+		return MaxDecodeSize, "MaxDecodeSize"
+	}
+	return opts.maxDecodeLength, "MaxDecodeLength"
+}
+
+func (opts *codecOptions) maxDepth() int {
+	if opts == nil || opts.maxDecodeDepth <= 0 {
+		return defaultMaxDecodeDepth
+	}
+	return opts.maxDecodeDepth
+}
+
+// descend records that decoding from r enters a complex datum, and
+// returns an error when that nests deeper than allowed.
+func (opts *codecOptions) descend(r io.Reader) error {
+	cr, ok := r.(*countingReader)
+	if !ok {
+		return nil
+	}
+	if cr.depth++; cr.depth > opts.maxDepth() {
+		return fmt.Errorf("nesting deeper than %d levels", opts.maxDepth())
+	}
+	return nil
+}
+
+// itemOffset returns the offset of r at which an array item begins, to
+// be passed to checkItem once the item is decoded.
+func itemOffset(r io.Reader) int64 {
+	if cr, ok := r.(*countingReader); ok {
+		return cr.n
+	}
+	return -1
+}
+
+// checkItem returns an error when the array item decoded from r, which
+// began at the specified offset, occupied no bytes, and the datum
+// being decoded holds more than MaxEmptyItems such items.
+func checkItem(r io.Reader, offset int64) error {
+	cr, ok := r.(*countingReader)
+	if !ok || cr.n != offset {
+		return nil
+	}
+	if cr.empty++; cr.empty > MaxEmptyItems {
+		return fmt.Errorf("more than MaxEmptyItems (%d) items occupying no bytes", MaxEmptyItems)
+	}
+	return nil
+}
+
+// ascend records that decoding from r leaves a complex datum.
+func ascend(r io.Reader) {
+	if cr, ok := r.(*countingReader); ok {
+		cr.depth--
+	}
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
 }
 
 func nullDecoder(_ io.Reader) (interface{}, error) {
@@ -156,47 +405,68 @@ func doubleDecoder(r io.Reader) (interface{}, error) {
 }
 
 func bytesDecoder(r io.Reader) (interface{}, error) {
-	someValue, err := longDecoder(r)
+	return decodeBytes(nil, r)
+}
+
+// decodeBytes decodes bytes no longer than the limit set by opts.
+func decodeBytes(opts *codecOptions, r io.Reader) (interface{}, error) {
+	size, err := readLengthPrefix(opts, r, "bytes")
 	if err != nil {
-		return nil, newDecoderError("bytes", err)
+		return nil, err
 	}
-	size, ok := someValue.(int64)
-	if !ok {
-		return nil, newDecoderError("bytes", "expected int64; received: %T", someValue)
-	}
-	if size < 0 {
-		return nil, newDecoderError("bytes", "negative length: %d", size)
+	if buf, ok := opts.readAliased(r, size); ok {
+		return buf, nil
 	}
-	if size > MaxDecodeSize {
-		return nil, newDecoderError("bytes", "implementation error: length of bytes (%d) is greater than the max currently set with MaxDecodeSize (%d)", size, MaxDecodeSize)
-	}
-	buf := make([]byte, size)
-	if _, err = io.ReadFull(r, buf); err != nil {
+	buf, err := readLength(r, size)
+	if err != nil {
 		return nil, newDecoderError("bytes", err)
 	}
 	return buf, nil
 }
 
 func stringDecoder(r io.Reader) (interface{}, error) {
-	// NOTE: could have implemented in terms of makeBytesDecoder,
-	// but prefer to not have nested error messages
+	return decodeString(nil, r)
+}
+
+// decodeString decodes a string no longer than the limit set by opts.
+func decodeString(opts *codecOptions, r io.Reader) (interface{}, error) {
+	buf, err := readLengthPrefixed(opts, r, "string")
+	if err != nil {
+		return nil, err
+	}
+	return string(buf), nil
+}
+
+// readLengthPrefix reads the length of bytes or a string, which ought
+// to be within the limit set by opts.
+func readLengthPrefix(opts *codecOptions, r io.Reader, dataType string) (int64, error) {
 	someValue, err := longDecoder(r)
 	if err != nil {
-		return nil, newDecoderError("string", err)
+		return 0, newDecoderError(dataType, err)
 	}
 	size, ok := someValue.(int64)
 	if !ok {
-		return nil, newDecoderError("string", "expected int64; received: %T", someValue)
+		return 0, newDecoderError(dataType, "expected int64; received: %T", someValue)
 	}
 	if size < 0 {
-		return nil, newDecoderError("string", "negative length: %d", size)
+		return 0, newDecoderError(dataType, "negative length: %d", size)
 	}
-	if size > MaxDecodeSize {
-		return nil, newDecoderError("bytes", "implementation error: length of bytes (%d) is greater than the max currently set with MaxDecodeSize (%d)", size, MaxDecodeSize)
+	if limit, setting := opts.maxLength(); size > limit {
+		return 0, newDecoderError(dataType, "implementation error: length of %s (%d) is greater than the max currently set with %s (%d)", dataType, size, setting, limit)
 	}
-	buf := make([]byte, size)
-	if _, err = io.ReadFull(r, buf); err != nil {
-		return nil, newDecoderError("string", err)
+	return size, nil
+}
+
+// readLengthPrefixed reads the long length prefix of a datum of
+// dataType, either bytes or string, followed by that many bytes.
+func readLengthPrefixed(opts *codecOptions, r io.Reader, dataType string) ([]byte, error) {
+	size, err := readLengthPrefix(opts, r, dataType)
+	if err != nil {
+		return nil, err
 	}
-	return string(buf), nil
+	buf, err := readLength(r, size)
+	if err != nil {
+		return nil, newDecoderError(dataType, err)
+	}
+	return buf, nil
 }
@@ -19,6 +19,7 @@
 package goavro
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
@@ -35,17 +36,33 @@ type StringWriter interface {
 	WriteString(string) (int, error)
 }
 
-// ErrEncoder is returned when the encoder encounters an error.
+// ErrEncoder is returned when the encoder encounters an error. Path
+// identifies the portion of the datum that could not be encoded, for
+// instance `addresses[3].zip`, and is empty when the top level datum
+// itself is at fault. Path is relative to the schema named by
+// SchemaName, which is the fullname of a named type, or the Avro type
+// name otherwise.
 type ErrEncoder struct {
-	Message string
-	Err     error
+	Message    string
+	Err        error
+	Path       string
+	SchemaName string
 }
 
 func (e ErrEncoder) Error() string {
+	message := "cannot encode " + e.Message
+	if e.Path != "" {
+		message += " at " + e.Path
+	}
 	if e.Err == nil {
-		return "cannot encode " + e.Message
+		return message
 	}
-	return "cannot encode " + e.Message + ": " + e.Err.Error()
+	return message + ": " + e.Err.Error()
+}
+
+// Unwrap returns the error that caused the encoder error, if any.
+func (e ErrEncoder) Unwrap() error {
+	return e.Err
 }
 
 func newEncoderError(dataType string, a ...interface{}) *ErrEncoder {
@@ -53,7 +70,7 @@ func newEncoderError(dataType string, a ...interface{}) *ErrEncoder {
 	var format, message string
 	var ok bool
 	if len(a) == 0 {
-		return &ErrEncoder{dataType + ": no reason given", nil}
+		return &ErrEncoder{Message: dataType + ": no reason given"}
 	}
 	// if last item is error: save it
 	if err, ok = a[len(a)-1].(error); ok {
@@ -69,7 +86,21 @@ func newEncoderError(dataType string, a ...interface{}) *ErrEncoder {
 	if message != "" {
 		message = ": " + message
 	}
-	return &ErrEncoder{dataType + message, err}
+	return &ErrEncoder{Message: dataType + message, Err: err}
+}
+
+// newEncoderPathError wraps err, which was returned while encoding the
+// child found at segment of a complex datum. The path of err is hoisted
+// to the returned error, so only the outermost error reports the path.
+func newEncoderPathError(dataType, segment string, err error) *ErrEncoder {
+	path := segment
+	if ee, ok := err.(*ErrEncoder); ok {
+		path = joinPath(segment, ee.Path)
+		ee.Path = ""
+	}
+	e := newEncoderError(dataType, err)
+	e.Path = path
+	return e
 }
 
 func nullEncoder(_ io.Writer, _ interface{}) error {
@@ -147,11 +178,98 @@ func writeInt(w io.Writer, byteCount int, encoded uint64) error {
 
 }
 
+// intDatum returns datum as an int32. Besides int32, it accepts the
+// narrower signed integer types, and int and json.Number, provided
+// the value fits in 32 bits.
+func intDatum(datum interface{}) (int32, error) {
+	var wide int64
+	switch v := datum.(type) {
+	case int32:
+		return v, nil
+	case int16:
+		return int32(v), nil
+	case int8:
+		return int32(v), nil
+	case int:
+		wide = int64(v)
+	case json.Number:
+		var err error
+		if wide, err = v.Int64(); err != nil {
+			return 0, fmt.Errorf("cannot convert json.Number to int: %q", v)
+		}
+	default:
+		return 0, fmt.Errorf("expected: int32; received: %T", datum)
+	}
+	if wide < math.MinInt32 || wide > math.MaxInt32 {
+		return 0, fmt.Errorf("value out of range for 32-bit int: %d", wide)
+	}
+	return int32(wide), nil
+}
+
+// longDatum returns datum as an int64. Besides int64, it accepts int,
+// the narrower signed integer types, and json.Number.
+func longDatum(datum interface{}) (int64, error) {
+	switch v := datum.(type) {
+	case int64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int8:
+		return int64(v), nil
+	case json.Number:
+		wide, err := v.Int64()
+		if err != nil {
+			return 0, fmt.Errorf("cannot convert json.Number to long: %q", v)
+		}
+		return wide, nil
+	}
+	return 0, fmt.Errorf("expected: int64; received: %T", datum)
+}
+
+// floatDatum returns datum as a float32. Besides float32, it accepts
+// json.Number, provided the value is within the range of a float32.
+func floatDatum(datum interface{}) (float32, error) {
+	switch v := datum.(type) {
+	case float32:
+		return v, nil
+	case json.Number:
+		wide, err := v.Float64()
+		if err != nil {
+			return 0, fmt.Errorf("cannot convert json.Number to float: %q", v)
+		}
+		if math.Abs(wide) > math.MaxFloat32 {
+			return 0, fmt.Errorf("value out of range for 32-bit float: %s", v)
+		}
+		return float32(wide), nil
+	}
+	return 0, fmt.Errorf("expected: float32; received: %T", datum)
+}
+
+// doubleDatum returns datum as a float64. Besides float64, it accepts
+// json.Number.
+func doubleDatum(datum interface{}) (float64, error) {
+	switch v := datum.(type) {
+	case float64:
+		return v, nil
+	case json.Number:
+		wide, err := v.Float64()
+		if err != nil {
+			return 0, fmt.Errorf("cannot convert json.Number to double: %q", v)
+		}
+		return wide, nil
+	}
+	return 0, fmt.Errorf("expected: float64; received: %T", datum)
+}
+
 func intEncoder(w io.Writer, datum interface{}) error {
 	downShift := uint32(31)
-	someInt, ok := datum.(int32)
-	if !ok {
-		return newEncoderError("int", "expected: int32; received: %T", datum)
+	someInt, err := intDatum(datum)
+	if err != nil {
+		return newEncoderError("int", err)
 	}
 	encoded := uint64((uint32(someInt) << 1) ^ uint32(someInt>>downShift))
 	const maxByteSize = 5
@@ -160,15 +278,54 @@ func intEncoder(w io.Writer, datum interface{}) error {
 
 func longEncoder(w io.Writer, datum interface{}) error {
 	downShift := uint32(63)
-	someInt, ok := datum.(int64)
-	if !ok {
-		return newEncoderError("long", "expected: int64; received: %T", datum)
+	someInt, err := longDatum(datum)
+	if err != nil {
+		return newEncoderError("long", err)
 	}
 	encoded := ((uint64(someInt) << 1) ^ uint64(someInt>>downShift))
 	const maxByteSize = 10
 	return writeInt(w, maxByteSize, encoded)
 }
 
+// typedSlice returns the length of datum, and a function returning its
+// items, when datum is one of the typed slices an array codec accepts
+// in place of []interface{}, so callers need not box each item of a
+// large numeric array.
+func typedSlice(datum interface{}) (int, func(int) interface{}, bool) {
+	switch v := datum.(type) {
+	case []int32:
+		return len(v), func(i int) interface{} { return v[i] }, true
+	case []int64:
+		return len(v), func(i int) interface{} { return v[i] }, true
+	case []float32:
+		return len(v), func(i int) interface{} { return v[i] }, true
+	case []float64:
+		return len(v), func(i int) interface{} { return v[i] }, true
+	case []string:
+		return len(v), func(i int) interface{} { return v[i] }, true
+	case []bool:
+		return len(v), func(i int) interface{} { return v[i] }, true
+	}
+	return 0, nil, false
+}
+
+// writeSizedBlock writes a block of count array items or map entries,
+// whose encoding is in block, to w, in the form that precedes the items
+// with the negated count and the size of the block in bytes.
+func writeSizedBlock(w io.Writer, count int, block []byte) error {
+	if err := longEncoder(w, -int64(count)); err != nil {
+		return err
+	}
+	if err := longEncoder(w, int64(len(block))); err != nil {
+		return err
+	}
+	_, err := w.Write(block)
+	return err
+}
+
+// writeFloat writes the low byteCount bytes of bits least significant
+// byte first, which is the little-endian IEEE-754 encoding the Avro
+// specification requires, regardless of host byte order.
 func writeFloat(w io.Writer, byteCount int, bits uint64) error {
 	var err error
 	var bb []byte
@@ -197,9 +354,9 @@ func writeFloat(w io.Writer, byteCount int, bits uint64) error {
 }
 
 func floatEncoder(w io.Writer, datum interface{}) error {
-	someFloat, ok := datum.(float32)
-	if !ok {
-		return newEncoderError("float", "expected: float32; received: %T", datum)
+	someFloat, err := floatDatum(datum)
+	if err != nil {
+		return newEncoderError("float", err)
 	}
 	bits := uint64(math.Float32bits(someFloat))
 	const byteCount = 4
@@ -207,9 +364,9 @@ func floatEncoder(w io.Writer, datum interface{}) error {
 }
 
 func doubleEncoder(w io.Writer, datum interface{}) error {
-	someFloat, ok := datum.(float64)
-	if !ok {
-		return newEncoderError("double", "expected: float64; received: %T", datum)
+	someFloat, err := doubleDatum(datum)
+	if err != nil {
+		return newEncoderError("double", err)
 	}
 	bits := uint64(math.Float64bits(someFloat))
 	const byteCount = 8
@@ -0,0 +1,285 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+
+package goavro
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// emptyFingerprint is the CRC-64-AVRO fingerprint of the empty byte
+// sequence, as defined by the Avro specification.
+const emptyFingerprint = uint64(0xc15d213aa4d7a795)
+
+var fingerprintTable [256]uint64
+
+func init() {
+	for i := range fingerprintTable {
+		fp := uint64(i)
+		for j := 0; j < 8; j++ {
+			fp = (fp >> 1) ^ (emptyFingerprint & -(fp & 1))
+		}
+		fingerprintTable[i] = fp
+	}
+}
+
+// SchemaFingerprint returns the 64-bit Rabin fingerprint (CRC-64-AVRO)
+// of the specified schema text. The fingerprint is computed over the
+// bytes exactly as provided, so callers that need fingerprints which
+// match other Avro implementations ought to provide the schema in
+// Parsing Canonical Form.
+func SchemaFingerprint(schema string) uint64 {
+	fp := emptyFingerprint
+	for i := 0; i < len(schema); i++ {
+		fp = (fp >> 8) ^ fingerprintTable[byte(fp)^schema[i]]
+	}
+	return fp
+}
+
+// FingerprintRegistry maps the fingerprints found in single object
+// encoded messages to the codecs of their writer schemas. It is safe
+// for concurrent use.
+type FingerprintRegistry struct {
+	lock   sync.RWMutex
+	codecs map[uint64]Codec
+}
+
+// NewFingerprintRegistry returns an empty FingerprintRegistry.
+//
+//     registry := goavro.NewFingerprintRegistry()
+//     if _, err := registry.Register(someSchema); err != nil {
+//         log.Fatal(err)
+//     }
+//     datum, err := registry.Decode(message)
+//     if err != nil {
+//         log.Fatal(err)
+//     }
+func NewFingerprintRegistry() *FingerprintRegistry {
+	return &FingerprintRegistry{codecs: make(map[uint64]Codec)}
+}
+
+// Register builds a codec for the specified schema, and records it
+// under the fingerprint of the schema's Parsing Canonical Form, which
+// is returned. Registering a schema more than once has no further
+// effect.
+func (fr *FingerprintRegistry) Register(schema string) (uint64, error) {
+	canonical, err := parsingCanonicalForm(schema)
+	if err != nil {
+		return 0, err
+	}
+	fingerprint := SchemaFingerprint(canonical)
+
+	fr.lock.RLock()
+	_, ok := fr.codecs[fingerprint]
+	fr.lock.RUnlock()
+	if ok {
+		return fingerprint, nil
+	}
+
+	c, err := NewCodec(schema)
+	if err != nil {
+		return 0, err
+	}
+	fr.lock.Lock()
+	fr.codecs[fingerprint] = c
+	fr.lock.Unlock()
+	return fingerprint, nil
+}
+
+// Codec returns the codec registered under the specified fingerprint.
+// It satisfies CodecLookup, so a FingerprintRegistry may provide the
+// codecs for a FramedReader using FramingSingleObject.
+func (fr *FingerprintRegistry) Codec(fingerprint uint64) (Codec, error) {
+	fr.lock.RLock()
+	c, ok := fr.codecs[fingerprint]
+	fr.lock.RUnlock()
+	if !ok {
+		return nil, newReaderError("no schema registered with fingerprint %#016x", fingerprint)
+	}
+	return c, nil
+}
+
+// Decode reads one single object encoded message from r, and decodes
+// it using the codec registered under the fingerprint in its header.
+// It returns io.EOF when r is exhausted before the message begins.
+func (fr *FingerprintRegistry) Decode(r io.Reader) (interface{}, error) {
+	fingerprint, err := readFrameHeader(r, FramingSingleObject)
+	if err != nil {
+		return nil, err
+	}
+	c, err := fr.Codec(fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	datum, err := c.Decode(r)
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF // header without body
+	}
+	return datum, err
+}
+
+// sameCanonicalForm returns true when both schemas are valid and have
+// the same fingerprint of their Parsing Canonical Form, so that data
+// written with one may be read with the other as is.
+func sameCanonicalForm(a, b string) bool {
+	canonicalA, err := parsingCanonicalForm(a)
+	if err != nil {
+		return false
+	}
+	canonicalB, err := parsingCanonicalForm(b)
+	if err != nil {
+		return false
+	}
+	return SchemaFingerprint(canonicalA) == SchemaFingerprint(canonicalB)
+}
+
+// parsingCanonicalForm returns the Parsing Canonical Form of the
+// specified schema, as defined by the Avro specification, so that its
+// fingerprint matches the one computed by other implementations.
+func parsingCanonicalForm(someJSONSchema string) (string, error) {
+	// validate the schema before transforming it
+	if _, err := NewCodec(someJSONSchema); err != nil {
+		return "", err
+	}
+	var schema interface{}
+	if err := json.Unmarshal([]byte(someJSONSchema), &schema); err != nil {
+		return "", &ErrSchemaParse{"cannot unmarshal JSON", err}
+	}
+	bb := new(bytes.Buffer)
+	if err := writeCanonical(bb, nullNamespace, schema, make(map[string]bool)); err != nil {
+		return "", &ErrSchemaParse{"cannot build canonical form", err}
+	}
+	return bb.String(), nil
+}
+
+// writeCanonical writes the Parsing Canonical Form of schema to bb.
+// Seen holds the fullnames of named types already written, which are
+// thereafter written as references.
+func writeCanonical(bb *bytes.Buffer, enclosingNamespace string, schema interface{}, seen map[string]bool) error {
+	switch schemaType := schema.(type) {
+	case string:
+		if isPrimitiveTypeName(schemaType) {
+			return writeCanonicalString(bb, schemaType)
+		}
+		nm, err := newName(nameName(schemaType), nameEnclosingNamespace(enclosingNamespace))
+		if err != nil {
+			return err
+		}
+		return writeCanonicalString(bb, nm.n)
+	case []interface{}:
+		bb.WriteByte('[')
+		for idx, member := range schemaType {
+			if idx > 0 {
+				bb.WriteByte(',')
+			}
+			if err := writeCanonical(bb, enclosingNamespace, member, seen); err != nil {
+				return err
+			}
+		}
+		bb.WriteByte(']')
+		return nil
+	case map[string]interface{}:
+		typeName, ok := schemaType["type"].(string)
+		if !ok {
+			return writeCanonical(bb, enclosingNamespace, schemaType["type"], seen)
+		}
+		switch typeName {
+		case "array":
+			bb.WriteString(`{"type":"array","items":`)
+			if err := writeCanonical(bb, enclosingNamespace, schemaType["items"], seen); err != nil {
+				return err
+			}
+			bb.WriteByte('}')
+			return nil
+		case "map":
+			bb.WriteString(`{"type":"map","values":`)
+			if err := writeCanonical(bb, enclosingNamespace, schemaType["values"], seen); err != nil {
+				return err
+			}
+			bb.WriteByte('}')
+			return nil
+		case "record", "error", "enum", "fixed":
+			// handled below
+		default:
+			return writeCanonical(bb, enclosingNamespace, typeName, seen)
+		}
+		nm, err := newName(nameSchema(schemaType), nameEnclosingNamespace(enclosingNamespace))
+		if err != nil {
+			return err
+		}
+		if seen[nm.n] {
+			return writeCanonicalString(bb, nm.n)
+		}
+		seen[nm.n] = true
+		bb.WriteString(`{"name":`)
+		if err = writeCanonicalString(bb, nm.n); err != nil {
+			return err
+		}
+		bb.WriteString(`,"type":`)
+		if err = writeCanonicalString(bb, typeName); err != nil {
+			return err
+		}
+		switch typeName {
+		case "enum":
+			bb.WriteString(`,"symbols":`)
+			buf, err := json.Marshal(schemaType["symbols"])
+			if err != nil {
+				return err
+			}
+			bb.Write(buf)
+		case "fixed":
+			size, _ := schemaType["size"].(float64)
+			fmt.Fprintf(bb, `,"size":%d`, int64(size))
+		default:
+			bb.WriteString(`,"fields":[`)
+			fields, _ := schemaType["fields"].([]interface{})
+			for idx, field := range fields {
+				fieldMap, _ := field.(map[string]interface{})
+				if idx > 0 {
+					bb.WriteByte(',')
+				}
+				bb.WriteString(`{"name":`)
+				if err = writeCanonicalString(bb, fmt.Sprint(fieldMap["name"])); err != nil {
+					return err
+				}
+				bb.WriteString(`,"type":`)
+				if err = writeCanonical(bb, nm.namespace(), fieldMap["type"], seen); err != nil {
+					return err
+				}
+				bb.WriteByte('}')
+			}
+			bb.WriteByte(']')
+		}
+		bb.WriteByte('}')
+		return nil
+	}
+	return fmt.Errorf("unknown schema type: %T", schema)
+}
+
+func writeCanonicalString(bb *bytes.Buffer, s string) error {
+	buf, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	bb.Write(buf)
+	return nil
+}
@@ -0,0 +1,188 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+
+package goavro
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Framing identifies how individual Avro messages in a stream are
+// delimited and tagged with the identity of their writer schema.
+type Framing int
+
+const (
+	// FramingSingleObject is the Avro single object encoding: the two
+	// byte marker C3 01, the little-endian CRC-64-AVRO fingerprint of
+	// the writer schema, then the encoded datum.
+	FramingSingleObject Framing = iota
+
+	// FramingConfluent is the Confluent Schema Registry wire format: a
+	// zero byte, the big-endian 32-bit schema ID, then the encoded
+	// datum.
+	FramingConfluent
+)
+
+const (
+	singleObjectMagic0 = byte(0xc3)
+	singleObjectMagic1 = byte(0x01)
+	confluentMagic     = byte(0x00)
+)
+
+// CodecLookup functions return the Codec for the writer schema
+// identified by schemaID. For single object framing schemaID is the
+// schema fingerprint, and for Confluent framing it is the registry
+// schema ID.
+type CodecLookup func(schemaID uint64) (Codec, error)
+
+// SchemaReader is implemented by readers that report which writer
+// schema each datum was written with, so that data from Object
+// Container Files and from framed message streams may be consumed
+// alike.
+type SchemaReader interface {
+	Scan() bool
+	ReadWithSchemaID() (uint64, interface{}, error)
+}
+
+// FramedReader structure contains data necessary to read a stream of
+// individually framed Avro messages, each of which identifies its own
+// writer schema.
+type FramedReader struct {
+	Framing  Framing
+	datum    Datum
+	err      error
+	lookup   CodecLookup
+	r        io.Reader
+	schemaID uint64
+}
+
+// NewFramedReader returns an object to read a stream of framed Avro
+// messages from an io.Reader. The specified CodecLookup is invoked for
+// every message to obtain the Codec for that message's writer schema,
+// and ought to cache its results.
+//
+//     fr, err := goavro.NewFramedReader(conn, goavro.FramingConfluent, func(id uint64) (goavro.Codec, error) {
+//         return registry.Codec(id) // definition not shown
+//     })
+//     if err != nil {
+//         log.Fatal(err)
+//     }
+//     for fr.Scan() {
+//         id, datum, err := fr.ReadWithSchemaID()
+//         if err != nil {
+//             log.Println("cannot read datum: ", err)
+//             continue
+//         }
+//         fmt.Println("SCHEMA: ", id, "DATUM: ", datum)
+//     }
+//     if err := fr.Close(); err != nil {
+//         log.Fatal(err)
+//     }
+func NewFramedReader(r io.Reader, framing Framing, lookup CodecLookup) (*FramedReader, error) {
+	if r == nil {
+		return nil, newReaderInitError("must specify io.Reader")
+	}
+	if lookup == nil {
+		return nil, newReaderInitError("must specify CodecLookup")
+	}
+	switch framing {
+	case FramingSingleObject, FramingConfluent:
+		// ok
+	default:
+		return nil, newReaderInitError("unsupported framing: %d", framing)
+	}
+	return &FramedReader{Framing: framing, lookup: lookup, r: r}, nil
+}
+
+// Close releases resources and returns any FramedReader errors.
+func (fr *FramedReader) Close() error {
+	return fr.err
+}
+
+// Scan returns true if more data is ready to be read. Because messages
+// are not length prefixed, the stream cannot be resynchronized after a
+// message fails to decode, and Scan returns false thereafter.
+func (fr *FramedReader) Scan() bool {
+	if fr.err != nil {
+		return false
+	}
+	fr.schemaID, fr.err = fr.readHeader()
+	if fr.err != nil {
+		if fr.err == io.EOF {
+			fr.err = nil // clean end of stream
+		}
+		return false
+	}
+	c, err := fr.lookup(fr.schemaID)
+	if err != nil {
+		fr.err = newReaderError("cannot find codec for schema ID %d", fr.schemaID, err)
+		return false
+	}
+	fr.datum.Value, fr.datum.Err = c.Decode(fr.r)
+	if fr.datum.Err == io.EOF {
+		fr.datum.Err = io.ErrUnexpectedEOF // header without body
+	}
+	fr.err = fr.datum.Err
+	return true
+}
+
+// Read returns the next element from the FramedReader.
+func (fr *FramedReader) Read() (interface{}, error) {
+	return fr.datum.Value, fr.datum.Err
+}
+
+// ReadWithSchemaID returns the next element from the FramedReader,
+// along with the ID of the schema it was written with.
+func (fr *FramedReader) ReadWithSchemaID() (uint64, interface{}, error) {
+	return fr.schemaID, fr.datum.Value, fr.datum.Err
+}
+
+func (fr *FramedReader) readHeader() (uint64, error) {
+	return readFrameHeader(fr.r, fr.Framing)
+}
+
+// readFrameHeader reads the header of the next message from r, and
+// returns the ID of its writer schema. It returns io.EOF when r is
+// exhausted before the first byte of a header.
+func readFrameHeader(r io.Reader, framing Framing) (uint64, error) {
+	magic := make([]byte, 1)
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return 0, err
+	}
+	switch framing {
+	case FramingSingleObject:
+		buf := make([]byte, 9)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return 0, newReaderError("cannot read single object header", err)
+		}
+		if magic[0] != singleObjectMagic0 || buf[0] != singleObjectMagic1 {
+			return 0, newReaderError("invalid single object marker: %#x %#x", magic[0], buf[0])
+		}
+		return binary.LittleEndian.Uint64(buf[1:]), nil
+	default:
+		buf := make([]byte, 4)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return 0, newReaderError("cannot read confluent header", err)
+		}
+		if magic[0] != confluentMagic {
+			return 0, newReaderError("invalid confluent magic byte: %#x", magic[0])
+		}
+		return uint64(binary.BigEndian.Uint32(buf)), nil
+	}
+}
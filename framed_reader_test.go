@@ -0,0 +1,233 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+
+package goavro
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestSchemaFingerprint(t *testing.T) {
+	// values from the Avro specification test suite
+	if actual, expected := int64(SchemaFingerprint(`"null"`)), int64(7195948357588979594); actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+	if actual, expected := int64(SchemaFingerprint(`"int"`)), int64(8247732601305521295); actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+}
+
+func newTestCodecLookup(schemas map[uint64]string) CodecLookup {
+	return func(schemaID uint64) (Codec, error) {
+		schema, ok := schemas[schemaID]
+		if !ok {
+			return nil, fmt.Errorf("unknown schema ID: %d", schemaID)
+		}
+		return NewCodec(schema)
+	}
+}
+
+func TestNewFramedReaderBailsBadArguments(t *testing.T) {
+	lookup := newTestCodecLookup(nil)
+	_, err := NewFramedReader(nil, FramingConfluent, lookup)
+	checkError(t, err, "must specify io.Reader")
+	_, err = NewFramedReader(new(bytes.Buffer), FramingConfluent, nil)
+	checkError(t, err, "must specify CodecLookup")
+	_, err = NewFramedReader(new(bytes.Buffer), Framing(42), lookup)
+	checkError(t, err, "unsupported framing: 42")
+}
+
+func TestFramedReaderSingleObject(t *testing.T) {
+	intFingerprint := SchemaFingerprint(`"int"`)
+	stringFingerprint := SchemaFingerprint(`"string"`)
+	lookup := newTestCodecLookup(map[uint64]string{intFingerprint: `"int"`, stringFingerprint: `"string"`})
+
+	bb := new(bytes.Buffer)
+	header := func(fingerprint uint64) {
+		bb.Write([]byte{0xc3, 0x01})
+		binary.Write(bb, binary.LittleEndian, fingerprint)
+	}
+	header(intFingerprint)
+	bb.WriteString("\x1a")
+	header(stringFingerprint)
+	bb.WriteString("\x0ahappy")
+
+	fr, err := NewFramedReader(bb, FramingSingleObject, lookup)
+	checkErrorFatal(t, err, nil)
+	var ids []uint64
+	var data []interface{}
+	for fr.Scan() {
+		id, datum, err := fr.ReadWithSchemaID()
+		checkErrorFatal(t, err, nil)
+		ids = append(ids, id)
+		data = append(data, datum)
+	}
+	checkError(t, fr.Close(), nil)
+	if len(data) != 2 || data[0] != int32(13) || data[1] != "happy" {
+		t.Errorf("Actual: %#v; Expected: %#v", data, []interface{}{int32(13), "happy"})
+	}
+	if len(ids) != 2 || ids[0] != intFingerprint || ids[1] != stringFingerprint {
+		t.Errorf("Actual: %#v; Expected: %#v", ids, []uint64{intFingerprint, stringFingerprint})
+	}
+}
+
+func TestFramedReaderConfluent(t *testing.T) {
+	lookup := newTestCodecLookup(map[uint64]string{7: `"int"`})
+	bits := []byte("\x00\x00\x00\x00\x07\x1a\x00\x00\x00\x00\x07\x54")
+	fr, err := NewFramedReader(bytes.NewReader(bits), FramingConfluent, lookup)
+	checkErrorFatal(t, err, nil)
+	var count int
+	for fr.Scan() {
+		id, datum, err := fr.ReadWithSchemaID()
+		checkErrorFatal(t, err, nil)
+		if id != 7 {
+			t.Errorf("Actual: %#v; Expected: %#v", id, 7)
+		}
+		if _, ok := datum.(int32); !ok {
+			t.Errorf("Actual: %T; Expected: int32", datum)
+		}
+		count++
+	}
+	checkError(t, fr.Close(), nil)
+	if count != 2 {
+		t.Errorf("Actual: %#v; Expected: %#v", count, 2)
+	}
+}
+
+func TestFramedReaderBailsBadFraming(t *testing.T) {
+	lookup := newTestCodecLookup(map[uint64]string{7: `"int"`})
+
+	fr, err := NewFramedReader(bytes.NewReader([]byte("\x01\x00\x00\x00\x07\x1a")), FramingConfluent, lookup)
+	checkErrorFatal(t, err, nil)
+	if fr.Scan() {
+		t.Errorf("Actual: %#v; Expected: %#v", true, false)
+	}
+	checkError(t, fr.Close(), "invalid confluent magic byte")
+
+	fr, err = NewFramedReader(bytes.NewReader([]byte("\x00\x00\x00")), FramingConfluent, lookup)
+	checkErrorFatal(t, err, nil)
+	if fr.Scan() {
+		t.Errorf("Actual: %#v; Expected: %#v", true, false)
+	}
+	checkError(t, fr.Close(), "cannot read confluent header: unexpected EOF")
+
+	fr, err = NewFramedReader(bytes.NewReader([]byte("\x00\x00\x00\x00\x08\x1a")), FramingConfluent, lookup)
+	checkErrorFatal(t, err, nil)
+	if fr.Scan() {
+		t.Errorf("Actual: %#v; Expected: %#v", true, false)
+	}
+	checkError(t, fr.Close(), "cannot find codec for schema ID 8: unknown schema ID: 8")
+
+	fr, err = NewFramedReader(bytes.NewReader([]byte("\xc3\x02\x00\x00\x00\x00\x00\x00\x00\x00\x1a")), FramingSingleObject, lookup)
+	checkErrorFatal(t, err, nil)
+	if fr.Scan() {
+		t.Errorf("Actual: %#v; Expected: %#v", true, false)
+	}
+	checkError(t, fr.Close(), "invalid single object marker")
+}
+
+func TestReaderReadWithSchemaID(t *testing.T) {
+	var sr SchemaReader
+	fr, err := NewReader(FromReader(bytes.NewReader([]byte(nullCodecSample))))
+	checkErrorFatal(t, err, nil)
+	sr = fr
+	// the same fingerprint a FingerprintRegistry assigns the schema
+	expected, err := NewFingerprintRegistry().Register(fr.DataSchema)
+	checkErrorFatal(t, err, nil)
+	if expected == SchemaFingerprint(fr.DataSchema) {
+		t.Fatalf("schema of sample ought to differ from its canonical form")
+	}
+	for sr.Scan() {
+		id, _, err := sr.ReadWithSchemaID()
+		checkErrorFatal(t, err, nil)
+		if id != expected {
+			t.Errorf("Actual: %#v; Expected: %#v", id, expected)
+		}
+	}
+	checkError(t, fr.Close(), nil)
+}
+
+func TestParsingCanonicalForm(t *testing.T) {
+	cases := []struct {
+		schema, expected string
+	}{
+		{`{"type":"int"}`, `"int"`},
+		{`["null", {"type":"string","doc":"text"}]`, `["null","string"]`},
+		{`{"type":"map","values":{"type":"array","items":"long"}}`, `{"type":"map","values":{"type":"array","items":"long"}}`},
+		{`{"type":"fixed","size":16,"name":"md5","namespace":"org.hash","aliases":["digest"]}`, `{"name":"org.hash.md5","type":"fixed","size":16}`},
+		{`{"symbols":["A","B"],"type":"enum","doc":"letters","name":"letter"}`, `{"name":"letter","type":"enum","symbols":["A","B"]}`},
+		{
+			`{"type":"record","name":"node","namespace":"com.example","fields":[{"name":"value","type":"long","default":0},{"name":"next","type":["null","long"]},{"name":"md5","type":{"type":"fixed","name":"md5","size":16}},{"name":"other","type":"md5"}]}`,
+			`{"name":"com.example.node","type":"record","fields":[{"name":"value","type":"long"},{"name":"next","type":["null","long"]},{"name":"md5","type":{"name":"com.example.md5","type":"fixed","size":16}},{"name":"other","type":"com.example.md5"}]}`,
+		},
+	}
+	for _, c := range cases {
+		actual, err := parsingCanonicalForm(c.schema)
+		checkErrorFatal(t, err, nil)
+		if actual != c.expected {
+			t.Errorf("Actual: %#v; Expected: %#v", actual, c.expected)
+		}
+	}
+	_, err := parsingCanonicalForm(`{"type":"fixed","name":"md5"}`)
+	checkError(t, err, "size")
+}
+
+func TestFingerprintRegistry(t *testing.T) {
+	registry := NewFingerprintRegistry()
+	intFingerprint, err := registry.Register(`{"type":"int"}`)
+	checkErrorFatal(t, err, nil)
+	if expected := SchemaFingerprint(`"int"`); intFingerprint != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", intFingerprint, expected)
+	}
+	stringFingerprint, err := registry.Register(`"string"`)
+	checkErrorFatal(t, err, nil)
+	_, err = registry.Register(`{"type":"bogus"}`)
+	checkError(t, err, "bogus")
+
+	bb := new(bytes.Buffer)
+	for _, fingerprint := range []uint64{intFingerprint, stringFingerprint} {
+		bb.Write([]byte{0xc3, 0x01})
+		binary.Write(bb, binary.LittleEndian, fingerprint)
+		if fingerprint == intFingerprint {
+			bb.WriteString("\x1a")
+		} else {
+			bb.WriteString("\x0ahappy")
+		}
+	}
+	datum, err := registry.Decode(bb)
+	checkErrorFatal(t, err, nil)
+	if expected := int32(13); datum != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", datum, expected)
+	}
+	datum, err = registry.Decode(bb)
+	checkErrorFatal(t, err, nil)
+	if expected := "happy"; datum != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", datum, expected)
+	}
+	_, err = registry.Decode(bb)
+	checkError(t, err, io.EOF)
+
+	bb.Write([]byte{0xc3, 0x01})
+	binary.Write(bb, binary.LittleEndian, uint64(42))
+	_, err = registry.Decode(bb)
+	checkError(t, err, "no schema registered with fingerprint 0x000000000000002a")
+}
@@ -124,3 +124,55 @@ func TestFuzz_UnboundedAllocation(t *testing.T) {
 		}
 	}
 }
+
+// fuzzSchemas are the schemas whose codecs FuzzDecode feeds random
+// bytes, chosen to exercise every kind of decoder.
+var fuzzSchemas = []string{
+	`"null"`, `"boolean"`, `"int"`, `"long"`, `"float"`, `"double"`, `"bytes"`, `"string"`,
+	`{"type":"fixed","name":"f","size":4}`,
+	`{"type":"enum","name":"e","symbols":["A","B","C"]}`,
+	`{"type":"array","items":"null"}`,
+	`{"type":"array","items":"string"}`,
+	`{"type":"map","values":"null"}`,
+	`{"type":"map","values":{"type":"array","items":"long"}}`,
+	`["null","string",{"type":"array","items":"int"}]`,
+	`{"type":"bytes","logicalType":"decimal","precision":10,"scale":2}`,
+	`{"type":"record","name":"r","fields":[{"name":"a","type":"int"},{"name":"b","type":["null","string"]},{"name":"c","type":{"type":"map","values":"double"}}]}`,
+}
+
+// FuzzDecode feeds random bytes to the decoders of fuzzSchemas, which
+// ought to return errors rather than panic or allocate without bound
+// with the default limits, and re-encodes every datum they do decode.
+func FuzzDecode(f *testing.F) {
+	codecs := make([]Codec, len(fuzzSchemas))
+	for idx, schema := range fuzzSchemas {
+		codec, err := NewCodec(schema)
+		if err != nil {
+			f.Fatal(err)
+		}
+		codecs[idx] = codec
+	}
+	for _, seed := range [][]byte{
+		{},
+		{0},
+		{2, 'h', 'i'},
+		{1, 0x02, 0x06, 'a', 'b', 'c', 0},
+		{0x01, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01},
+		{0xfe, 0xff, 0xff, 0xff, 0x0f},
+		{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01},
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for idx, codec := range codecs {
+			datum, err := codec.Decode(bytes.NewReader(data))
+			if err != nil {
+				continue
+			}
+			if err := codec.Encode(new(bytes.Buffer), datum); err != nil {
+				t.Errorf("schema: %s; datum: %#v; cannot re-encode: %s", fuzzSchemas[idx], datum, err)
+			}
+		}
+	})
+}
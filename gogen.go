@@ -0,0 +1,285 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+
+package goavro
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"strings"
+	"unicode"
+)
+
+// GenerateGoStruct returns the source of a Go file in the specified
+// package that declares types corresponding to the specified record
+// schema, suitable for use with EncodeStruct and DecodeInto.
+//
+// Each record becomes a struct with `avro` tags naming its fields, each
+// enum a string type with a constant per symbol, and each fixed a byte
+// array type. Arrays become slices, maps become maps with string keys,
+// and unions of null and another type become pointers to that type.
+// Other unions become interface{} fields.
+//
+//   source, err := goavro.GenerateGoStruct(someRecordSchema, "models")
+//   if err != nil {
+//       return err
+//   }
+//   err = ioutil.WriteFile("models/user.go", []byte(source), 0644)
+func GenerateGoStruct(schema, pkg string) (string, error) {
+	var someSchema interface{}
+	if err := json.Unmarshal([]byte(schema), &someSchema); err != nil {
+		return "", &ErrSchemaParse{"cannot unmarshal JSON", err}
+	}
+	schemaMap, ok := someSchema.(map[string]interface{})
+	if !ok || schemaMap["type"] != "record" {
+		return "", newCodecBuildError("Go source", "schema ought to be a record")
+	}
+	g := &goGenerator{
+		typeNames: make(map[string]string),
+		avroNames: make(map[string]string),
+	}
+	if _, err := g.goType(nullNamespace, schemaMap); err != nil {
+		return "", err
+	}
+
+	var source bytes.Buffer
+	fmt.Fprintf(&source, "// Code generated by goavro.GenerateGoStruct. DO NOT EDIT.\n\npackage %s\n", pkg)
+	for _, decl := range g.decls {
+		source.WriteString("\n")
+		source.WriteString(decl)
+	}
+	formatted, err := format.Source(source.Bytes())
+	if err != nil {
+		return "", newCodecBuildError("Go source", err)
+	}
+	return string(formatted), nil
+}
+
+type goGenerator struct {
+	typeNames map[string]string // Avro fullname to Go type name
+	avroNames map[string]string // Go type name to Avro fullname
+	decls     []string
+}
+
+// goIdentifier returns the exported Go identifier for an Avro name,
+// for instance UserName for user_name.
+func goIdentifier(avroName string) string {
+	parts := strings.FieldsFunc(avroName, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for idx, part := range parts {
+		parts[idx] = strings.ToUpper(part[:1]) + part[1:]
+	}
+	return strings.Join(parts, "")
+}
+
+// declare reserves a Go type name for the named type, and a slot for
+// its declaration.
+func (g *goGenerator) declare(nm *name) (string, int, error) {
+	typeName := goIdentifier(nm.basename())
+	if other, ok := g.avroNames[typeName]; ok {
+		return "", 0, newCodecBuildError("Go source", "%s and %s both map to Go type %s", other, nm.n, typeName)
+	}
+	g.typeNames[nm.n] = typeName
+	g.avroNames[typeName] = nm.n
+	g.decls = append(g.decls, "")
+	return typeName, len(g.decls) - 1, nil
+}
+
+func (g *goGenerator) goType(enclosingNamespace string, schema interface{}) (string, error) {
+	switch schemaType := schema.(type) {
+	case string:
+		switch schemaType {
+		case "null":
+			return "", newCodecBuildError("Go source", "null ought to be a union member")
+		case "boolean":
+			return "bool", nil
+		case "int":
+			return "int32", nil
+		case "long":
+			return "int64", nil
+		case "float":
+			return "float32", nil
+		case "double":
+			return "float64", nil
+		case "bytes":
+			return "[]byte", nil
+		case "string":
+			return "string", nil
+		}
+		nm, err := newName(nameName(schemaType), nameEnclosingNamespace(enclosingNamespace))
+		if err != nil {
+			return "", newCodecBuildError("Go source", err)
+		}
+		typeName, ok := g.typeNames[nm.n]
+		if !ok {
+			return "", newCodecBuildError("Go source", "unknown type name: %s", nm.n)
+		}
+		return typeName, nil
+	case []interface{}:
+		var members []interface{}
+		for _, member := range schemaType {
+			if member != "null" {
+				members = append(members, member)
+			}
+		}
+		if len(members) == 1 && len(schemaType) == 2 {
+			typeName, err := g.goType(enclosingNamespace, members[0])
+			if err != nil {
+				return "", err
+			}
+			return "*" + typeName, nil
+		}
+		// still define any named types declared by the members
+		for _, member := range members {
+			if _, err := g.goType(enclosingNamespace, member); err != nil {
+				return "", err
+			}
+		}
+		return "interface{}", nil
+	case map[string]interface{}:
+		typeName, _ := schemaType["type"].(string)
+		switch typeName {
+		case "array":
+			itemType, err := g.goType(enclosingNamespace, schemaType["items"])
+			if err != nil {
+				return "", newCodecBuildPathError("Go source", "", err)
+			}
+			return "[]" + itemType, nil
+		case "map":
+			valueType, err := g.goType(enclosingNamespace, schemaType["values"])
+			if err != nil {
+				return "", newCodecBuildPathError("Go source", "", err)
+			}
+			return "map[string]" + valueType, nil
+		case "enum":
+			return g.enumType(enclosingNamespace, schemaType)
+		case "fixed":
+			return g.fixedType(enclosingNamespace, schemaType)
+		case "record":
+			return g.recordType(enclosingNamespace, schemaType)
+		}
+		return g.goType(enclosingNamespace, schemaType["type"])
+	}
+	return "", newCodecBuildError("Go source", "unknown schema type: %T", schema)
+}
+
+// goDocComment returns the doc attribute of the schema as a Go comment
+// with the specified indentation.
+func goDocComment(indent string, schemaMap map[string]interface{}) string {
+	doc, ok := schemaMap["doc"].(string)
+	if !ok || strings.TrimSpace(doc) == "" {
+		return ""
+	}
+	prefix := indent + "// "
+	return prefix + strings.Replace(strings.TrimSpace(doc), "\n", "\n"+prefix, -1) + "\n"
+}
+
+func (g *goGenerator) enumType(enclosingNamespace string, schemaMap map[string]interface{}) (string, error) {
+	nm, err := newName(nameSchema(schemaMap), nameEnclosingNamespace(enclosingNamespace))
+	if err != nil {
+		return "", newCodecBuildError("Go source", err)
+	}
+	typeName, slot, err := g.declare(nm)
+	if err != nil {
+		return "", err
+	}
+	symbols, ok := schemaMap["symbols"].([]interface{})
+	if !ok || len(symbols) == 0 {
+		return "", newCodecBuildError("Go source", "enum (%s) symbols ought to be non-empty array", nm.n)
+	}
+	var decl bytes.Buffer
+	decl.WriteString(goDocComment("", schemaMap))
+	fmt.Fprintf(&decl, "type %s string\n\nconst (\n", typeName)
+	for _, symbol := range symbols {
+		someString, ok := symbol.(string)
+		if !ok {
+			return "", newCodecBuildError("Go source", "enum (%s) symbols ought to be strings", nm.n)
+		}
+		fmt.Fprintf(&decl, "\t%s%s %s = %q\n", typeName, goIdentifier(someString), typeName, someString)
+	}
+	decl.WriteString(")\n")
+	g.decls[slot] = decl.String()
+	return typeName, nil
+}
+
+func (g *goGenerator) fixedType(enclosingNamespace string, schemaMap map[string]interface{}) (string, error) {
+	nm, err := newName(nameSchema(schemaMap), nameEnclosingNamespace(enclosingNamespace))
+	if err != nil {
+		return "", newCodecBuildError("Go source", err)
+	}
+	typeName, slot, err := g.declare(nm)
+	if err != nil {
+		return "", err
+	}
+	size, ok := schemaMap["size"].(float64)
+	if !ok || size < 0 {
+		return "", newCodecBuildError("Go source", "fixed (%s) size ought to be number", nm.n)
+	}
+	g.decls[slot] = fmt.Sprintf("%stype %s [%d]byte\n", goDocComment("", schemaMap), typeName, int(size))
+	return typeName, nil
+}
+
+func (g *goGenerator) recordType(enclosingNamespace string, schemaMap map[string]interface{}) (string, error) {
+	nm, err := newName(nameSchema(schemaMap), nameEnclosingNamespace(enclosingNamespace))
+	if err != nil {
+		return "", newCodecBuildError("Go source", err)
+	}
+	typeName, slot, err := g.declare(nm)
+	if err != nil {
+		return "", err
+	}
+	friendlyName := fmt.Sprintf("record (%s)", nm.n)
+	fields, ok := schemaMap["fields"].([]interface{})
+	if !ok {
+		return "", newCodecBuildError(friendlyName, "fields ought to be array")
+	}
+	var decl bytes.Buffer
+	decl.WriteString(goDocComment("", schemaMap))
+	fmt.Fprintf(&decl, "type %s struct {\n", typeName)
+	fieldNames := make(map[string]string)
+	for _, field := range fields {
+		fieldMap, ok := field.(map[string]interface{})
+		if !ok {
+			return "", newCodecBuildError(friendlyName, "record field ought to be object")
+		}
+		fieldName, ok := fieldMap["name"].(string)
+		if !ok || fieldName == "" {
+			return "", newCodecBuildError(friendlyName, "record field name ought to be non-empty string")
+		}
+		goName := goIdentifier(fieldName)
+		if other, ok := fieldNames[goName]; ok {
+			return "", newCodecBuildError(friendlyName, "fields %s and %s both map to Go field %s", other, fieldName, goName)
+		}
+		fieldNames[goName] = fieldName
+		fieldType, err := g.goType(nm.namespace(), fieldMap["type"])
+		if err != nil {
+			return "", newCodecBuildPathError(friendlyName, fieldName, err)
+		}
+		if fieldType == typeName {
+			return "", newCodecBuildPathError(friendlyName, fieldName, newCodecBuildError("Go source", "recursive record ought to be referenced through a union, array or map"))
+		}
+		decl.WriteString(goDocComment("\t", fieldMap))
+		fmt.Fprintf(&decl, "\t%s %s `%s:%q`\n", goName, fieldType, structTag, fieldName)
+	}
+	decl.WriteString("}\n")
+	g.decls[slot] = decl.String()
+	return typeName, nil
+}
@@ -0,0 +1,153 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+
+package goavro
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"testing"
+)
+
+const generateSchema = `{"type":"record","name":"user_profile","namespace":"com.example","doc":"A registered user.","fields":[
+{"name":"user_name","type":"string","doc":"Login name."},
+{"name":"email","type":["null","string"]},
+{"name":"age","type":"int"},
+{"name":"color","type":{"type":"enum","name":"color","symbols":["RED","dark_green"]}},
+{"name":"hash","type":{"type":"fixed","name":"md5","size":4}},
+{"name":"home","type":{"type":"record","name":"address","fields":[{"name":"city","type":"string"}]}},
+{"name":"work","type":["null","address"]},
+{"name":"visits","type":{"type":"array","items":"address"}},
+{"name":"scores","type":{"type":"map","values":"double"}},
+{"name":"extra","type":["null","int","string"]}]}`
+
+// The types expected to be generated from generateSchema.
+
+// A registered user.
+type UserProfile struct {
+	// Login name.
+	UserName string             `avro:"user_name"`
+	Email    *string            `avro:"email"`
+	Age      int32              `avro:"age"`
+	Color    Color              `avro:"color"`
+	Hash     Md5                `avro:"hash"`
+	Home     Address            `avro:"home"`
+	Work     *Address           `avro:"work"`
+	Visits   []Address          `avro:"visits"`
+	Scores   map[string]float64 `avro:"scores"`
+	Extra    interface{}        `avro:"extra"`
+}
+
+type Color string
+
+const (
+	ColorRED       Color = "RED"
+	ColorDarkGreen Color = "dark_green"
+)
+
+type Md5 [4]byte
+
+type Address struct {
+	City string `avro:"city"`
+}
+
+func TestGenerateGoStruct(t *testing.T) {
+	source, err := GenerateGoStruct(generateSchema, "models")
+	checkErrorFatal(t, err, nil)
+
+	expected := `// Code generated by goavro.GenerateGoStruct. DO NOT EDIT.
+
+package models
+
+// A registered user.
+type UserProfile struct {
+	// Login name.
+	UserName string             ` + "`avro:\"user_name\"`" + `
+	Email    *string            ` + "`avro:\"email\"`" + `
+	Age      int32              ` + "`avro:\"age\"`" + `
+	Color    Color              ` + "`avro:\"color\"`" + `
+	Hash     Md5                ` + "`avro:\"hash\"`" + `
+	Home     Address            ` + "`avro:\"home\"`" + `
+	Work     *Address           ` + "`avro:\"work\"`" + `
+	Visits   []Address          ` + "`avro:\"visits\"`" + `
+	Scores   map[string]float64 ` + "`avro:\"scores\"`" + `
+	Extra    interface{}        ` + "`avro:\"extra\"`" + `
+}
+
+type Color string
+
+const (
+	ColorRED       Color = "RED"
+	ColorDarkGreen Color = "dark_green"
+)
+
+type Md5 [4]byte
+
+type Address struct {
+	City string ` + "`avro:\"city\"`" + `
+}
+`
+	if source != expected {
+		t.Errorf("Actual: %s; Expected: %s", source, expected)
+	}
+
+	// generated source type checks
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "user_profile.go", source, parser.ParseComments)
+	checkErrorFatal(t, err, nil)
+	_, err = new(types.Config).Check("models", fset, []*ast.File{f}, nil)
+	checkErrorFatal(t, err, nil)
+
+	// types equivalent to the generated source round trip
+	codec, err := NewCodec(generateSchema)
+	checkErrorFatal(t, err, nil)
+	email := "homer@example.com"
+	in := UserProfile{
+		UserName: "homer",
+		Email:    &email,
+		Age:      39,
+		Color:    ColorDarkGreen,
+		Hash:     Md5{1, 2, 3, 4},
+		Home:     Address{City: "Springfield"},
+		Visits:   []Address{{City: "Shelbyville"}},
+		Scores:   map[string]float64{"bowling": 200},
+		Extra:    "strike",
+	}
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, codec.(StructCodec).EncodeStruct(bb, in), nil)
+	var out UserProfile
+	checkErrorFatal(t, codec.(StructCodec).DecodeInto(bb, &out), nil)
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("Actual: %#v; Expected: %#v", out, in)
+	}
+}
+
+func TestGenerateGoStructErrors(t *testing.T) {
+	_, err := GenerateGoStruct(`"int"`, "models")
+	checkError(t, err, "schema ought to be a record")
+
+	_, err = GenerateGoStruct(`{"type":"record","name":"r","fields":[{"name":"a_b","type":"int"},{"name":"aB","type":"int"}]}`, "models")
+	checkError(t, err, "fields a_b and aB both map to Go field AB")
+
+	_, err = GenerateGoStruct(`{"type":"record","name":"r","fields":[{"name":"next","type":"r"}]}`, "models")
+	checkError(t, err, "recursive record ought to be referenced through a union, array or map")
+}
@@ -20,7 +20,7 @@ package goavro
 
 import (
 	"bytes"
-	"fmt"
+	"errors"
 	"io"
 	"strings"
 	"testing"
@@ -61,7 +61,7 @@ func checkError(t *testing.T, actualError error, expectedError interface{}) {
 			var expected error
 			switch expectedError.(type) {
 			case string:
-				expected = fmt.Errorf(expectedError.(string))
+				expected = errors.New(expectedError.(string))
 			case error:
 				expected = expectedError.(error)
 			}
@@ -73,7 +73,7 @@ func checkError(t *testing.T, actualError error, expectedError interface{}) {
 	}
 }
 
-func checkErrorFatal(t *testing.T, actualError error, expectedError interface{}) {
+func checkErrorFatal(t testing.TB, actualError error, expectedError interface{}) {
 	if expectedError == nil {
 		if actualError != nil {
 			t.Fatalf("Actual: %#v; Expected: %#v", actualError.Error(), expectedError)
@@ -28,24 +28,32 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"reflect"
+	"strings"
 )
 
 // NOTE: use Go type names because for runtime resolution of
 // union member, it gets the Go type name of the datum sent to
 // the union encoder, and uses that string as a key into the
 // encoders map
-func newJSONSymbolTable() *symtabJSON {
+func newJSONSymbolTable(opts *codecOptions) *symtabJSON {
 	return &symtabJSON{
 		name:         make(map[string]*codec),
+		defs:         make(map[string]namedDefinition),
+		opts:         opts,
 		nullCodec:    &codec{nm: &name{n: "null"}, df: nullJSONDecoder, ef: nullJSONEncoder},
 		booleanCodec: &codec{nm: &name{n: "bool"}, df: booleanJSONDecoder, ef: booleanJSONEncoder},
-		intCodec:     &codec{nm: &name{n: "int32"}, df: intJSONDecoder, ef: intJSONEncoder},
-		longCodec:    longJSONCodec(),
-		floatCodec:   &codec{nm: &name{n: "float32"}, df: floatJSONDecoder, ef: floatJSONEncoder},
-		doubleCodec:  &codec{nm: &name{n: "float64"}, df: doubleJSONDecoder, ef: doubleJSONEncoder},
-		bytesCodec:   &codec{nm: &name{n: "[]uint8"}, df: bytesJSONDecoder, ef: bytesJSONEncoder},
-		stringCodec:  &codec{nm: &name{n: "string"}, df: stringJSONDecoder, ef: stringJSONEncoder},
+		intCodec:     timeCodec(opts, numericStringCodec(opts, &codec{nm: &name{n: "int32"}, df: intJSONDecoder, ef: intJSONEncoder}), epochDays),
+		longCodec:    timeCodec(opts, numericStringCodec(opts, longJSONCodec()), epochMillis),
+		floatCodec: numericStringCodec(opts, &codec{nm: &name{n: "float32"},
+			df: func(r io.Reader) (interface{}, error) { return floatJSONDecoder(opts, r) },
+			ef: func(w io.Writer, datum interface{}) error { return floatJSONEncoder(opts, w, datum) }}),
+		doubleCodec: numericStringCodec(opts, &codec{nm: &name{n: "float64"},
+			df: func(r io.Reader) (interface{}, error) { return doubleJSONDecoder(opts, r) },
+			ef: func(w io.Writer, datum interface{}) error { return doubleJSONEncoder(opts, w, datum) }}),
+		bytesCodec:  &codec{nm: &name{n: "[]uint8"}, df: bytesJSONDecoder, ef: bytesJSONEncoder},
+		stringCodec: &codec{nm: &name{n: "string"}, df: stringJSONDecoder, ef: stringJSONEncoder},
 	}
 
 }
@@ -56,6 +64,8 @@ func longJSONCodec() *codec {
 
 type symtabJSON struct {
 	name map[string]*codec // map full name to codec
+	defs map[string]namedDefinition
+	opts *codecOptions
 
 	//cache primitive codecs
 	nullCodec    *codec
@@ -121,13 +131,26 @@ func NewJSONCodec(someJSONSchema string, setters ...CodecSetter) (Codec, error)
 
 	// each codec gets a unified namespace of symbols to
 	// respective codecs
-	st := newJSONSymbolTable()
+	st := newJSONSymbolTable(new(codecOptions))
 
 	newCodec, err := st.buildCodec(nullNamespace, schema)
 	if err != nil {
 		return nil, err
 	}
 
+	// Avro JSON and binary encodings accept the same data, so borrow the
+	// validator from the binary codec for the same schema, which is kept
+	// for transcoding and shares the options of the JSON codec.
+	binaryCodec, err := newSymbolTable(st.opts).buildCodec(nullNamespace, schema)
+	if err != nil {
+		return nil, err
+	}
+	newCodec.borrowValidators(binaryCodec, make(map[*codec]bool))
+	newCodec.opts = st.opts
+	binaryCodec.opts = st.opts
+	binaryCodec.schema = string(compressedSchema)
+	newCodec.twin = &twinCodec{codec: binaryCodec}
+
 	for _, setter := range setters {
 		err = setter(newCodec)
 		if err != nil {
@@ -135,9 +158,342 @@ func NewJSONCodec(someJSONSchema string, setters ...CodecSetter) (Codec, error)
 		}
 	}
 	newCodec.schema = string(compressedSchema)
+	newCodec.original = someJSONSchema
 	return newCodec, nil
 }
 
+// borrowValidators copies the validator and native functions of the
+// binary codec, and those of its record field codecs, to the JSON
+// codec for the same schema, so codecs returned by FieldCodec may also
+// validate and convert data.
+func (c *codec) borrowValidators(binary *codec, seen map[*codec]bool) {
+	if seen[c] {
+		return
+	}
+	seen[c] = true
+	c.vf = binary.vf
+	c.nf = binary.nf
+	c.jdf = c.df
+	for fieldName, child := range c.fc {
+		if binaryChild, ok := binary.fc[fieldName]; ok {
+			child.borrowValidators(binaryChild, seen)
+		}
+	}
+}
+
+// JSONEmptyStringAsNull returns a CodecSetter which causes a codec
+// created by NewJSONCodec to decode an empty string as null, for unions
+// that include null. Both a bare empty string and an empty string
+// member, {"string":""}, decode as null. Other types, and unions without
+// a null member, are unaffected.
+//
+//   codec, err := goavro.NewJSONCodec(someSchema, goavro.JSONEmptyStringAsNull())
+func JSONEmptyStringAsNull() CodecSetter {
+	return func(c Codec) error {
+		someCodec, ok := c.(*codec)
+		if !ok || someCodec.jdf == nil || someCodec.opts == nil {
+			return newCodecBuildError("codec", "JSONEmptyStringAsNull ought to be used with NewJSONCodec")
+		}
+		someCodec.opts.emptyStringAsNull = true
+		return nil
+	}
+}
+
+// JSONRelaxedUnions returns a CodecSetter which causes a codec created
+// by NewJSONCodec to also decode union values written as plain JSON,
+// such as 42 or "hello", rather than wrapped in an object naming their
+// member, such as {"int":42}. The member is inferred from the shape of
+// the JSON value: a boolean, a number, a string (for string, bytes,
+// enum and fixed members), an array, or an object (for map and record
+// members). A value which more than one member has the shape of, such
+// as a number for ["null","int","long"], is ambiguous, and is an error
+// unless wrapped. Objects with a single key naming a member are still
+// taken to be wrapped values.
+//
+//   codec, err := goavro.NewJSONCodec(`["null","int","string"]`, goavro.JSONRelaxedUnions())
+func JSONRelaxedUnions() CodecSetter {
+	return func(c Codec) error {
+		someCodec, ok := c.(*codec)
+		if !ok || someCodec.jdf == nil || someCodec.opts == nil {
+			return newCodecBuildError("codec", "JSONRelaxedUnions ought to be used with NewJSONCodec")
+		}
+		someCodec.opts.relaxedUnions = true
+		return nil
+	}
+}
+
+// JSONBareOptionals returns a CodecSetter which causes a codec created
+// by NewJSONCodec to encode the values of unions of null and one other
+// type, such as ["null","string"], as the bare JSON value, such as
+// "hello", rather than wrapped in an object naming their member, such
+// as {"string":"hello"}, which is friendlier to clients unaware of
+// Avro. Null is encoded as null either way, and other unions are
+// unaffected. Such JSON is not Avro JSON; decode it with a codec
+// created with JSONRelaxedUnions.
+//
+//   codec, err := goavro.NewJSONCodec(someSchema, goavro.JSONBareOptionals())
+func JSONBareOptionals() CodecSetter {
+	return func(c Codec) error {
+		someCodec, ok := c.(*codec)
+		if !ok || someCodec.jdf == nil || someCodec.opts == nil {
+			return newCodecBuildError("codec", "JSONBareOptionals ought to be used with NewJSONCodec")
+		}
+		someCodec.opts.bareOptionals = true
+		return nil
+	}
+}
+
+// NonFiniteFloats selects how a codec created by NewJSONCodec encodes
+// float and double values which are NaN or infinite, which JSON
+// numbers cannot represent.
+type NonFiniteFloats int
+
+const (
+	// NonFiniteError causes encoding a NaN or infinite value to return
+	// an error. This is the default.
+	NonFiniteError NonFiniteFloats = iota
+
+	// NonFiniteAsStrings encodes NaN and infinite values as the JSON
+	// strings "NaN", "Infinity", and "-Infinity", as some Avro JSON
+	// dialects do, and decodes those strings as such values.
+	NonFiniteAsStrings
+
+	// NonFiniteAsNull encodes NaN and infinite values as JSON null, and
+	// decodes null as NaN.
+	NonFiniteAsNull
+)
+
+// JSONNonFiniteFloats returns a CodecSetter which selects how a codec
+// created by NewJSONCodec encodes and decodes float and double values
+// which are NaN or infinite.
+//
+//   codec, err := goavro.NewJSONCodec(`"double"`, goavro.JSONNonFiniteFloats(goavro.NonFiniteAsStrings))
+func JSONNonFiniteFloats(mode NonFiniteFloats) CodecSetter {
+	return func(c Codec) error {
+		someCodec, ok := c.(*codec)
+		if !ok || someCodec.jdf == nil || someCodec.opts == nil {
+			return newCodecBuildError("codec", "JSONNonFiniteFloats ought to be used with NewJSONCodec")
+		}
+		switch mode {
+		case NonFiniteError, NonFiniteAsStrings, NonFiniteAsNull:
+		default:
+			return newCodecBuildError("codec", "JSONNonFiniteFloats mode unknown: %d", mode)
+		}
+		someCodec.opts.nonFiniteFloats = mode
+		return nil
+	}
+}
+
+// unionMemberShape pairs the type name of a union member with the shape
+// of the JSON values of that member, as returned by jsonShape.
+type unionMemberShape struct {
+	typeName string
+	shape    string
+}
+
+// unionMemberJSONShape returns the shape of the JSON values of the union
+// member built as c, whose type name is unionTypeName.
+func unionMemberJSONShape(c *codec, unionTypeName string) string {
+	switch unionTypeName {
+	case "null", "boolean", "array":
+		return unionTypeName
+	case "int", "long", "float", "double":
+		return "number"
+	case "string", "bytes":
+		return "string"
+	case "map":
+		return "object"
+	}
+	if c.fc != nil {
+		return "object" // record
+	}
+	return "string" // enum or fixed
+}
+
+// jsonShape returns the shape of a JSON value decoded by jsonDecode.
+func jsonShape(jsonValue interface{}) string {
+	switch jsonValue.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number, float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
+
+// isUnionWrapper returns true when jsonMap is the Avro JSON encoding of
+// a union value: an object with a single key naming a member.
+func isUnionWrapper(jsonMap map[string]interface{}, members map[string]decoderFunction) bool {
+	if len(jsonMap) != 1 {
+		return false
+	}
+	for k := range jsonMap {
+		_, ok := members[k]
+		return ok
+	}
+	return false
+}
+
+// inferUnionMember returns the type name of the only union member whose
+// values have the shape of jsonValue.
+func inferUnionMember(friendlyName string, members []unionMemberShape, jsonValue interface{}) (string, error) {
+	shape := jsonShape(jsonValue)
+	var candidates []string
+	for _, member := range members {
+		if member.shape == shape {
+			candidates = append(candidates, member.typeName)
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return "", newDecoderError(friendlyName, "no member for %s value: %v", shape, jsonValue)
+	case 1:
+		return candidates[0], nil
+	}
+	return "", newDecoderError(friendlyName, "ambiguous %s value matches members %s; wrap it as {\"%s\": ...}", shape, strings.Join(candidates, ", "), candidates[0])
+}
+
+// JSONDecodeNative will read the next datum from the specified
+// io.Reader, which must contain Avro JSON encoded data, and return it
+// as plain Go values rather than the typed structures returned by
+// Decode: records become map[string]interface{} keyed by field name, or
+// OrderedMap for codecs created with DecodeRecordsAsMaps, enums become
+// their symbol string, and fixed values become []byte.
+// Arrays, maps and scalars are returned as Decode returns them.
+//
+// Codecs created with NewCodec build an Avro JSON decoder for their
+// schema, with the same options, the first time this is called.
+func (c codec) JSONDecodeNative(r io.Reader) (interface{}, error) {
+	jc, _, err := c.transcodingCodecs()
+	if err != nil {
+		return nil, err
+	}
+	datum, err := jc.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	return nativeDatum(datum), nil
+}
+
+// JSONEncodeIndent writes the Avro JSON encoding of datum to the
+// specified io.Writer, indented as by json.Indent with the specified
+// prefix and indent. Record fields are written in schema order, as
+// Encode writes them for codecs created with NewJSONCodec, which
+// continue to write compact JSON.
+//
+// Codecs created with NewCodec build an Avro JSON encoder for their
+// schema, with the same options, the first time this is called.
+//
+//   err := codec.JSONEncodeIndent(os.Stdout, someRecord, "", "  ")
+func (c codec) JSONEncodeIndent(w io.Writer, datum interface{}, prefix, indent string) error {
+	jc, _, err := c.transcodingCodecs()
+	if err != nil {
+		return err
+	}
+	compact := new(bytes.Buffer)
+	if err := jc.Encode(compact, datum); err != nil {
+		return err
+	}
+	indented := new(bytes.Buffer)
+	if err := json.Indent(indented, compact.Bytes(), prefix, indent); err != nil {
+		return newEncoderError(c.schemaName(), err)
+	}
+	_, err = indented.WriteTo(w)
+	return err
+}
+
+// JSONToBinary reads the next datum from the specified io.Reader,
+// which must contain Avro JSON encoded data, and writes its Avro binary
+// encoding to the specified io.Writer.
+//
+//   err := codec.JSONToBinary(strings.NewReader(`{"name":"Alice"}`), w)
+func (c codec) JSONToBinary(jsonReader io.Reader, binWriter io.Writer) error {
+	jc, bc, err := c.transcodingCodecs()
+	if err != nil {
+		return err
+	}
+	datum, err := jc.Decode(jsonReader)
+	if err != nil {
+		return err
+	}
+	return bc.Encode(binWriter, datum)
+}
+
+// BinaryToJSON reads the next datum from the specified io.Reader, which
+// must contain Avro binary encoded data, and writes its Avro JSON
+// encoding to the specified io.Writer.
+//
+//   err := codec.BinaryToJSON(r, os.Stdout)
+func (c codec) BinaryToJSON(binReader io.Reader, jsonWriter io.Writer) error {
+	jc, bc, err := c.transcodingCodecs()
+	if err != nil {
+		return err
+	}
+	datum, err := bc.Decode(binReader)
+	if err != nil {
+		return err
+	}
+	return jc.Encode(jsonWriter, datum)
+}
+
+// transcodingCodecs returns the Avro JSON and Avro binary codecs for the
+// schema of c, one of which is c itself.
+func (c codec) transcodingCodecs() (*codec, *codec, error) {
+	other, err := c.twinCodec()
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.jdf != nil {
+		return &c, other, nil
+	}
+	return other, &c, nil
+}
+
+// twinCodec returns the codec for the schema of c in the other encoding,
+// building it the first time it is needed. It shares the options of c,
+// so setters given when c was created also apply to it.
+func (c codec) twinCodec() (*codec, error) {
+	if c.twin == nil {
+		return c.buildTwinCodec()
+	}
+	c.twin.once.Do(func() {
+		if c.twin.codec == nil {
+			c.twin.codec, c.twin.err = c.buildTwinCodec()
+		}
+	})
+	return c.twin.codec, c.twin.err
+}
+
+func (c codec) buildTwinCodec() (*codec, error) {
+	var schema interface{}
+	if err := json.Unmarshal([]byte(c.schema), &schema); err != nil {
+		return nil, &ErrSchemaParse{"cannot unmarshal JSON", err}
+	}
+	opts := c.opts
+	if opts == nil {
+		opts = new(codecOptions)
+	}
+	var other *codec
+	var err error
+	if c.jdf != nil {
+		other, err = newSymbolTable(opts).buildCodec(nullNamespace, schema)
+	} else if other, err = newJSONSymbolTable(opts).buildCodec(nullNamespace, schema); err == nil {
+		other.borrowValidators(&c, make(map[*codec]bool))
+	}
+	if err != nil {
+		return nil, err
+	}
+	other.opts = opts
+	other.schema = c.schema
+	return other, nil
+}
+
 func (st symtabJSON) buildCodec(enclosingNamespace string, schema interface{}) (*codec, error) {
 	switch schemaType := schema.(type) {
 	case string:
@@ -188,7 +544,7 @@ func (st symtabJSON) buildString(enclosingNamespace, typeName string, schema int
 		return st.bytesCodec, nil
 	case "string":
 		return st.stringCodec, nil
-	case "record":
+	case "record", "error":
 		return st.makeRecordCodec(enclosingNamespace, schema)
 	case "enum":
 		return st.makeEnumCodec(enclosingNamespace, schema)
@@ -223,8 +579,8 @@ func getUnionTypeName(friendlyName string, enclosingNamespace string, schema int
 
 	switch schema.(type) {
 	case string:
-		// "primitive"
-		return schema.(string), nil
+		// "primitive" or reference to a named type
+		return unionTypeNameForString(enclosingNamespace, schema.(string))
 	}
 
 	schemaJSONMap, ok := schema.(map[string]interface{})
@@ -243,18 +599,36 @@ func getUnionTypeName(friendlyName string, enclosingNamespace string, schema int
 	}
 
 	switch unionTypeName {
-	case "record", "enum", "fixed":
+	case "record", "error", "enum", "fixed":
 		// The union type name is the fully qualified name of the named type.
 		name, err := newName(nameSchema(schemaJSONMap), nameEnclosingNamespace(enclosingNamespace))
 		if err != nil {
 			return "", err
 		}
 		unionTypeName = name.n
+	case "array", "map":
+		// unnamed complex types are keyed by type
+	default:
+		return unionTypeNameForString(enclosingNamespace, unionTypeName)
 	}
 
 	return unionTypeName, nil
 }
 
+// unionTypeNameForString returns the union type name of a primitive
+// type name, or the fullname of a named type referenced by name,
+// resolved against the enclosing namespace.
+func unionTypeNameForString(enclosingNamespace, typeName string) (string, error) {
+	if isPrimitiveTypeName(typeName) {
+		return typeName, nil
+	}
+	nm, err := newName(nameName(typeName), nameEnclosingNamespace(enclosingNamespace))
+	if err != nil {
+		return "", err
+	}
+	return nm.n, nil
+}
+
 func (st symtabJSON) makeUnionCodec(enclosingNamespace string, schema interface{}) (*codec, error) {
 	errorNamespace := "null namespace"
 	if enclosingNamespace != nullNamespace {
@@ -273,21 +647,44 @@ func (st symtabJSON) makeUnionCodec(enclosingNamespace string, schema interface{
 
 	// setup
 	nameToUnionEncoder := make(map[string]unionJSONEncoder)
+	typeNameToUnionEncoder := make(map[string]unionJSONEncoder)
 	nameToJSONDecoder := make(map[string]decoderFunction)
-
-	for _, unionMemberSchema := range schemaArray {
+	nameToBranch := make(map[string]int)
+	var memberShapes []unionMemberShape
+	var memberEncoders []unionJSONEncoder
+	var memberCodecs []*codec
+	var recordNames []string
+
+	for branch, unionMemberSchema := range schemaArray {
+		if _, ok := unionMemberSchema.([]interface{}); ok {
+			return nil, newCodecBuildError(friendlyName, "member ought not to be union: %d", branch)
+		}
 		c, err := st.buildCodec(enclosingNamespace, unionMemberSchema)
 		if err != nil {
-			return nil, newCodecBuildError(friendlyName, "member ought to be decodable: %s", err)
+			return nil, newCodecBuildPathError(friendlyName, "", err, "member ought to be decodable")
 		}
 		unionTypeName, err := getUnionTypeName(friendlyName, enclosingNamespace, unionMemberSchema)
 		if err != nil {
 			return nil, newCodecBuildError(friendlyName, "Can't get union type name: %s", err)
 		}
+		if _, ok := nameToBranch[unionTypeName]; ok {
+			return nil, newCodecBuildError(friendlyName, "duplicate member type: %s", unionTypeName)
+		}
 		nameToJSONDecoder[unionTypeName] = c.df
+		nameToBranch[unionTypeName] = branch
+		memberShapes = append(memberShapes, unionMemberShape{typeName: unionTypeName, shape: unionMemberJSONShape(c, unionTypeName)})
 		nameToUnionEncoder[c.nm.n] = unionJSONEncoder{ef: c.ef, utn: unionTypeName}
+		memberEncoders = append(memberEncoders, nameToUnionEncoder[c.nm.n])
+		memberCodecs = append(memberCodecs, c)
+		typeNameToUnionEncoder[unionTypeName] = nameToUnionEncoder[c.nm.n]
+		if c.fc != nil {
+			recordNames = append(recordNames, unionTypeName)
+		}
 	}
 
+	_, hasNull := nameToJSONDecoder["null"]
+	isOptional := hasNull && len(schemaArray) == 2
+
 	nm, _ := newName(nameName("union"))
 	friendlyName = fmt.Sprintf("union (%s)", nm.n)
 
@@ -313,6 +710,20 @@ func (st symtabJSON) makeUnionCodec(enclosingNamespace string, schema interface{
 				return nil, err
 			}
 
+			// Optionally treat empty strings as null
+			if hasNull && st.opts.emptyStringAsNull {
+				switch v := jsonValue.(type) {
+				case string:
+					if v == "" {
+						return nil, nil
+					}
+				case map[string]interface{}:
+					if s, ok := v["string"]; ok && len(v) == 1 && s == "" {
+						return nil, nil
+					}
+				}
+			}
+
 			// 2. Figure out the union type.
 			var unionTypeName string
 			switch jsonValue.(type) {
@@ -322,15 +733,31 @@ func (st symtabJSON) makeUnionCodec(enclosingNamespace string, schema interface{
 			case map[string]interface{}:
 				// Single key: value with key = type
 				jsonMap := jsonValue.(map[string]interface{})
+				if st.opts.relaxedUnions && !isUnionWrapper(jsonMap, nameToJSONDecoder) {
+					if unionTypeName, err = inferUnionMember(friendlyName, memberShapes, jsonValue); err != nil {
+						return nil, err
+					}
+					break
+				}
+				if len(jsonMap) != 1 {
+					return nil, newDecoderError(friendlyName, "expected: object with one key naming the member type; received: %d keys", len(jsonMap))
+				}
 
 				// extract the first and only key and value
 				for k, v := range jsonMap {
 					unionTypeName = k
 					jsonValue = v
-					break
+				}
+				if unionTypeName == "null" {
+					return nil, newDecoderError(friendlyName, "expected: bare null for null member; received: %v", jsonMap)
 				}
 			default:
-				return nil, newDecoderError(friendlyName, "unsupported union value %v", jsonValue)
+				if !st.opts.relaxedUnions {
+					return nil, newDecoderError(friendlyName, "unsupported union value %v", jsonValue)
+				}
+				if unionTypeName, err = inferUnionMember(friendlyName, memberShapes, jsonValue); err != nil {
+					return nil, err
+				}
 			}
 
 			// 3. Lookup the Avro decoder for the union type.
@@ -346,7 +773,11 @@ func (st symtabJSON) makeUnionCodec(enclosingNamespace string, schema interface{
 			}
 
 			// 5. Run the Avro decoder on the bytes.
-			return jsonDecoderFunc(bytes.NewReader(b))
+			datum, err := jsonDecoderFunc(bytes.NewReader(b))
+			if err != nil || !st.opts.unionValues {
+				return datum, err
+			}
+			return UnionValue{Branch: nameToBranch[unionTypeName], TypeName: unionTypeName, Value: datum}, nil
 		},
 		ef: func(w io.Writer, datum interface{}) error {
 			// Convert from regular JSON to Avro JSON for a union.
@@ -362,8 +793,17 @@ func (st symtabJSON) makeUnionCodec(enclosingNamespace string, schema interface{
 			// 6. Marshal the json map
 
 			// 1. Lookup the union type
+			datum = unionMemberDatum(datum)
 			var unionTypeName string
+			var ue unionJSONEncoder
+			var ok bool
 			switch datum.(type) {
+			case UnionValue:
+				uv := datum.(UnionValue)
+				unionTypeName, datum = uv.TypeName, uv.Value
+				if ue, ok = typeNameToUnionEncoder[unionTypeName]; !ok {
+					return newEncoderError(friendlyName, "union json encode error: no member named %v", unionTypeName)
+				}
 			default:
 				unionTypeName = reflect.TypeOf(datum).String()
 			case map[string]interface{}:
@@ -381,7 +821,30 @@ func (st symtabJSON) makeUnionCodec(enclosingNamespace string, schema interface{
 			}
 
 			// 2. Lookup the union encoder based on the union type.
-			ue, ok := nameToUnionEncoder[unionTypeName]
+			if !ok {
+				ue, ok = nameToUnionEncoder[unionTypeName]
+			}
+			for _, alternate := range unionMemberAlternates(datum) {
+				if ok {
+					break
+				}
+				ue, ok = nameToUnionEncoder[alternate]
+			}
+			_, isMap := datum.(map[string]interface{})
+			_, isOrdered := datum.(OrderedMap)
+			if (isMap || isOrdered) && !ok {
+				// without a map member, the map is a record
+				var idx int
+				idx, ok = recordMemberForKeys(memberCodecs, datum, st.opts.ignoreUnknownFields, func(idx int) bool {
+					return memberEncoders[idx].ef(ioutil.Discard, datum) == nil
+				})
+				if ok {
+					ue = memberEncoders[idx]
+				}
+			}
+			if _, isRecord := datum.(*Record); isRecord && !ok {
+				return newEncoderError(friendlyName, "union json encode error: expected record: %s; received record: %v", strings.Join(recordNames, ", "), unionTypeName)
+			}
 			if !ok {
 				return newEncoderError(friendlyName, "union json encode error: invalid type %v", unionTypeName)
 			}
@@ -389,7 +852,7 @@ func (st symtabJSON) makeUnionCodec(enclosingNamespace string, schema interface{
 			// 3. Short circuit null
 			if unionTypeName == "null" {
 				if err := ue.ef(w, datum); err != nil {
-					return newEncoderError(friendlyName, "union json encode error: %v", err)
+					return newEncoderPathError(friendlyName, "", err)
 				}
 				return nil
 			}
@@ -397,7 +860,11 @@ func (st symtabJSON) makeUnionCodec(enclosingNamespace string, schema interface{
 			// 4. Recursively encode the json_value
 			var buff bytes.Buffer
 			if err := ue.ef(&buff, datum); err != nil {
-				return newEncoderError(friendlyName, "union json encode error: %v", err)
+				return newEncoderPathError(friendlyName, "", err)
+			}
+			if isOptional && st.opts.bareOptionals {
+				_, err := buff.WriteTo(w)
+				return err
 			}
 
 			// 5. Create a json map {"union type name" -> avro_json_value}
@@ -441,11 +908,19 @@ func (st symtabJSON) makeEnumCodec(enclosingNamespace string, schema interface{}
 	if !ok || len(symtab) == 0 {
 		return nil, newCodecBuildError(friendlyName, "symbols ought to be non-empty array")
 	}
+	seenSymbols := make(map[string]bool, len(symtab))
 	for _, v := range symtab {
-		_, ok := v.(string)
+		symbol, ok := v.(string)
 		if !ok {
 			return nil, newCodecBuildError(friendlyName, "symbols array member ought to be string")
 		}
+		if err := checkName(symbol); err != nil {
+			return nil, newCodecBuildError(friendlyName, &ErrSchemaParse{"invalid symbol", err})
+		}
+		if seenSymbols[symbol] {
+			return nil, newCodecBuildError(friendlyName, "duplicate symbol: %s", symbol)
+		}
+		seenSymbols[symbol] = true
 	}
 	c := &codec{
 		nm: nm,
@@ -468,6 +943,9 @@ func (st symtabJSON) makeEnumCodec(enclosingNamespace string, schema interface{}
 			switch datum.(type) {
 			case Enum:
 				someString = datum.(Enum).Value
+				if someString == "" {
+					return newEncoderError(friendlyName, emptyEnumValue, datum.(Enum).Name)
+				}
 			case string:
 				someString = datum.(string)
 			default:
@@ -481,7 +959,9 @@ func (st symtabJSON) makeEnumCodec(enclosingNamespace string, schema interface{}
 			return newEncoderError(friendlyName, "symbol not defined: %s", someString)
 		},
 	}
-	st.name[nm.n] = c
+	if err := registerNamed(st.name, st.defs, friendlyName, nm, enclosingNamespace, schema, c); err != nil {
+		return nil, err
+	}
 	return c, nil
 }
 
@@ -515,7 +995,7 @@ func (st symtabJSON) makeFixedCodec(enclosingNamespace string, schema interface{
 		nm: nm,
 		df: func(r io.Reader) (interface{}, error) {
 			// Fixed is treated in Avro JSON as a string.
-			someValue, err := stringJSONDecoder(r)
+			someValue, err := bytesJSONDecoder(r)
 			if err != nil {
 				return nil, newDecoderError(friendlyName, err)
 			}
@@ -523,6 +1003,9 @@ func (st symtabJSON) makeFixedCodec(enclosingNamespace string, schema interface{
 			if len(someFixed) < int(size) {
 				return nil, newDecoderError(friendlyName, "buffer underrun")
 			}
+			if len(someFixed) > int(size) {
+				return nil, newDecoderError(friendlyName, "expected: %d bytes; received: %d", size, len(someFixed))
+			}
 			return Fixed{nm.n, someFixed}, nil
 		},
 		ef: func(w io.Writer, datum interface{}) error {
@@ -534,10 +1017,12 @@ func (st symtabJSON) makeFixedCodec(enclosingNamespace string, schema interface{
 			if len(someFixed.Value) != int(size) {
 				return newEncoderError(friendlyName, "expected: %d bytes; received: %d", size, len(someFixed.Value))
 			}
-			return stringJSONEncoder(w, string(someFixed.Value))
+			return writeBytesJSON(w, someFixed.Value)
 		},
 	}
-	st.name[nm.n] = c
+	if err := registerNamed(st.name, st.defs, friendlyName, nm, enclosingNamespace, schema, c); err != nil {
+		return nil, err
+	}
 	return c, nil
 }
 
@@ -562,24 +1047,58 @@ func (st symtabJSON) makeRecordCodec(enclosingNamespace string, schema interface
 	fieldCodecMap := make(map[string]*codec)
 	for idx, field := range recordTemplate.Fields {
 		var err error
+		if _, ok := fieldCodecMap[field.Name]; ok {
+			return nil, newCodecBuildError(friendlyName, "duplicate field name: %s", name{n: field.Name}.basename())
+		}
 		fieldCodecs[idx], err = st.buildCodec(recordTemplate.n.namespace(), field.schema)
 		if err != nil {
-			return nil, newCodecBuildError(friendlyName, "record field ought to be codec: %+v", st, err)
+			return nil, newCodecBuildPathError(friendlyName, name{n: field.Name}.basename(), err, "record field ought to be codec")
 		}
 		fieldCodecMap[field.Name] = fieldCodecs[idx]
 	}
 
 	friendlyName = fmt.Sprintf("record (%s)", recordTemplate.Name)
 
+	// convert defaults to data the field codecs accept, such as *Record
+	// for a record default; a default that does not convert is left as
+	// parsed, for the field codec to report should it be used, except
+	// for unions, whose defaults ought to match their first member
+	for _, field := range recordTemplate.Fields {
+		fieldSchema := field.schema.(map[string]interface{})
+		if val, ok := fieldSchema["default"]; ok {
+			defval, err := nativeDefault(st.defs, recordTemplate.n.namespace(), fieldSchema["type"], val)
+			if err == nil {
+				field.defval = defval
+			} else if _, isUnion := fieldSchema["type"].([]interface{}); isUnion {
+				return nil, newCodecBuildPathError(friendlyName, name{n: field.Name}.basename(), &ErrSchemaParse{"union default ought to match first member", err})
+			}
+		}
+	}
+
+	fieldCodecsByName := make(map[string]*codec, len(fieldCodecs))
+	converterKeys := make(map[string][]string, len(fieldCodecs))
+	for idx, keys := range fieldConverterKeys(recordTemplate) {
+		field := recordTemplate.Fields[idx]
+		fieldCodecsByName[name{n: field.Name}.basename()] = fieldCodecs[idx]
+		converterKeys[field.Name] = keys
+	}
+
+	// records are decoded into, and built from maps as, copies of a
+	// blank record, rather than records NewRecord parses from the
+	// schema anew
+	blank, _ := NewRecord(recordSchemaRaw(schema), RecordEnclosingNamespace(enclosingNamespace))
+	keys := recordMapKeys(blank)
+
 	c := &codec{
 		nm: recordTemplate.n,
+		fc: fieldCodecsByName,
 		df: func(r io.Reader) (interface{}, error) {
 			// Record is Avro JSON encoded as a map with field names as key field values
 			// recursively Avro JSON encoded.
 			// 1. Unmarshal the bytes as regular JSON.
 			// 2. Go through each field and convert from regular JSON to Avro JSON.
 
-			someRecord, _ := NewRecord(recordSchemaRaw(schema), RecordEnclosingNamespace(enclosingNamespace))
+			someRecord := blank.shallowCopy()
 
 			// 1. Unmarshal the bytes as regular JSON.
 			datum, err := jsonDecode(r, friendlyName)
@@ -593,33 +1112,52 @@ func (st symtabJSON) makeRecordCodec(enclosingNamespace string, schema interface
 
 			// 2. Go through each field and convert from regular JSON to Avro JSON.
 			for key, value := range jsonMap {
-				b, err := json.Marshal(value)
-				if err != nil {
-					return nil, newDecoderError(friendlyName, err)
-				}
 				field, err := someRecord.getField(key)
 				if err != nil {
+					if st.opts.ignoreUnknownFields {
+						continue
+					}
 					return nil, newDecoderError(friendlyName, "Got unknown field %v", key)
 				}
-				fieldDatum, err := fieldCodecMap[field.Name].Decode(bytes.NewBuffer(b))
+				b, err := json.Marshal(value)
 				if err != nil {
 					return nil, newDecoderError(friendlyName, err)
 				}
+				fieldDatum, err := fieldCodecMap[field.Name].Decode(bytes.NewBuffer(b))
+				if err == nil {
+					fieldDatum, err = st.opts.convertField(converterKeys[field.Name], fieldDatum)
+				}
+				if err != nil {
+					return nil, newDecoderPathError(friendlyName, name{n: field.Name}.basename(), err)
+				}
 				field.Datum = fieldDatum
 			}
+			if st.opts.recordsAsMaps {
+				fields := make(OrderedMap, len(someRecord.Fields))
+				for idx, field := range someRecord.Fields {
+					fields[idx] = KeyVal{name{n: field.Name}.basename(), field.Datum}
+				}
+				return fields, nil
+			}
 			return someRecord, nil
 		},
 		ef: func(w io.Writer, datum interface{}) error {
 			// Record is Avro JSON encoded as a map with field names as key field values
 			// recursively Avro JSON encoded.
 
-			someRecord, ok := datum.(*Record)
+			someRecord, ok, err := recordFromDatum(blank, keys, datum, st.opts.ignoreUnknownFields)
+			if err != nil {
+				return newEncoderError(friendlyName, err)
+			}
 			if !ok {
-				return newEncoderError(friendlyName, "expected: Record; received: %T", datum)
+				return newEncoderError(friendlyName, "expected: Record or map[string]interface{}; received: %T", datum)
 			}
 			if someRecord.Name != recordTemplate.Name {
 				return newEncoderError(friendlyName, "expected: %v; received: %v", recordTemplate.Name, someRecord.Name)
 			}
+			if err = checkRecordFields(recordTemplate, someRecord); err != nil {
+				return newEncoderError(friendlyName, err)
+			}
 
 			// Recursively Avro JSON encode each field in the right order.
 			var orderedMap OrderedMap
@@ -628,8 +1166,8 @@ func (st symtabJSON) makeRecordCodec(enclosingNamespace string, schema interface
 				// check whether field datum is valid
 				if reflect.ValueOf(field.Datum).IsValid() {
 					value = field.Datum
-				} else if field.hasDefault {
-					value = field.defval
+				} else if template := recordTemplate.Fields[idx]; template.hasDefault {
+					value = template.defval
 				} else {
 					return newEncoderError(friendlyName, "field has no data and no default set: %v", field.Name)
 				}
@@ -639,7 +1177,7 @@ func (st symtabJSON) makeRecordCodec(enclosingNamespace string, schema interface
 				var buff bytes.Buffer
 				err = fieldCodecs[idx].Encode(&buff, value)
 				if err != nil {
-					return newEncoderError(friendlyName, err)
+					return newEncoderPathError(friendlyName, name{n: field.Name}.basename(), err)
 				}
 				jsonValue, err := jsonDecode(&buff, friendlyName)
 				if err != nil {
@@ -654,14 +1192,16 @@ func (st symtabJSON) makeRecordCodec(enclosingNamespace string, schema interface
 				orderedMap = append(orderedMap, KeyVal{n.basename(), jsonValue})
 			}
 
-			err := jsonEncode(w, orderedMap)
+			err = jsonEncode(w, orderedMap)
 			if err != nil {
 				return newEncoderError(friendlyName, "record json encode error: %v", err)
 			}
 			return nil
 		},
 	}
-	st.name[recordTemplate.Name] = c
+	if err := registerNamed(st.name, st.defs, friendlyName, recordTemplate.n, enclosingNamespace, schema, c); err != nil {
+		return nil, err
+	}
 	return c, nil
 }
 
@@ -683,7 +1223,7 @@ func (st symtabJSON) makeMapCodec(enclosingNamespace string, schema interface{})
 	}
 	valuesCodec, err := st.buildCodec(enclosingNamespace, v)
 	if err != nil {
-		return nil, newCodecBuildError(friendlyName, err)
+		return nil, newCodecBuildPathError(friendlyName, "", err)
 	}
 
 	nm := &name{n: "map"}
@@ -712,7 +1252,7 @@ func (st symtabJSON) makeMapCodec(enclosingNamespace string, schema interface{})
 				}
 				datum, err := valuesCodec.Decode(bytes.NewReader(b))
 				if err != nil {
-					return nil, newDecoderError(friendlyName, err)
+					return nil, newDecoderPathError(friendlyName, itemPath("", k), err)
 				}
 				data[k] = datum
 			}
@@ -730,7 +1270,7 @@ func (st symtabJSON) makeMapCodec(enclosingNamespace string, schema interface{})
 			for k, v := range jsonMap {
 				var buff bytes.Buffer
 				if err := valuesCodec.Encode(&buff, v); err != nil {
-					return newEncoderError(friendlyName, err)
+					return newEncoderPathError(friendlyName, itemPath("", k), err)
 				}
 				avroValue, err := jsonDecode(&buff, friendlyName)
 				if err != nil {
@@ -765,7 +1305,7 @@ func (st symtabJSON) makeArrayCodec(enclosingNamespace string, schema interface{
 	}
 	valuesCodec, err := st.buildCodec(enclosingNamespace, v)
 	if err != nil {
-		return nil, newCodecBuildError(friendlyName, err)
+		return nil, newCodecBuildPathError(friendlyName, "", err)
 	}
 
 	const itemsPerArrayBlock = 10
@@ -786,14 +1326,14 @@ func (st symtabJSON) makeArrayCodec(enclosingNamespace string, schema interface{
 			}
 
 			var jsonArray []interface{}
-			for _, avroValue := range avroArray {
+			for idx, avroValue := range avroArray {
 				b, err := json.Marshal(avroValue)
 				if err != nil {
 					return nil, newDecoderError(friendlyName, err)
 				}
 				datum, err := valuesCodec.Decode(bytes.NewReader(b))
 				if err != nil {
-					return nil, newDecoderError(friendlyName, err)
+					return nil, newDecoderPathError(friendlyName, itemPath("", idx), err)
 				}
 				jsonArray = append(jsonArray, datum)
 			}
@@ -803,14 +1343,21 @@ func (st symtabJSON) makeArrayCodec(enclosingNamespace string, schema interface{
 			// Avro JSON Encode each array value.
 			someArray, ok := datum.([]interface{})
 			if !ok {
-				return newEncoderError(friendlyName, "expected: []interface{}; received: %T", datum)
+				count, item, ok := typedSlice(datum)
+				if !ok {
+					return newEncoderError(friendlyName, "expected: []interface{}; received: %T", datum)
+				}
+				someArray = make([]interface{}, count)
+				for idx := range someArray {
+					someArray[idx] = item(idx)
+				}
 			}
 
 			var avroArray []interface{}
-			for _, someValue := range someArray {
+			for idx, someValue := range someArray {
 				var buff bytes.Buffer
 				if err := valuesCodec.Encode(&buff, someValue); err != nil {
-					return newEncoderError(friendlyName, err)
+					return newEncoderPathError(friendlyName, itemPath("", idx), err)
 				}
 				avroValue, err := jsonDecode(&buff, friendlyName)
 				if err != nil {
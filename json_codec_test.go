@@ -21,7 +21,10 @@ package goavro
 import (
 	"bytes"
 	"encoding/json"
+	"io"
+	"math"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -192,12 +195,11 @@ func TestJSONCodecDecoderPrimitives(t *testing.T) {
 	// null
 	checkCodecJSONDecoderResult(t, `"null"`, []byte("null"), nil)
 	// boolean
-	checkCodecJSONDecoderError(t, `"boolean"`, []byte(""), "cannot decode boolean")
-	checkCodecJSONDecoderError(t, `"boolean"`, []byte(""), "cannot decode boolean: EOF")
+	checkCodecJSONDecoderError(t, `"boolean"`, []byte(""), io.EOF)
 	checkCodecJSONDecoderResult(t, `"boolean"`, []byte("false"), false)
 	checkCodecJSONDecoderResult(t, `"boolean"`, []byte("true"), true)
 	// int
-	checkCodecJSONDecoderError(t, `"int"`, []byte(""), "cannot decode int: EOF")
+	checkCodecJSONDecoderError(t, `"int"`, []byte(""), io.EOF)
 	checkCodecJSONDecoderResult(t, `"int"`, []byte("0"), int32(0))
 	checkCodecJSONDecoderResult(t, `"int"`, []byte("-3"), int32(-3))
 	checkCodecJSONDecoderResult(t, `"int"`, []byte("3"), int32(3))
@@ -207,7 +209,7 @@ func TestJSONCodecDecoderPrimitives(t *testing.T) {
 	checkCodecJSONDecoderResult(t, `"int"`, []byte("66052"), int32(66052))
 	checkCodecJSONDecoderResult(t, `"int"`, []byte("8454660"), int32(8454660))
 	// long
-	checkCodecJSONDecoderError(t, `"long"`, []byte(""), "cannot decode long: EOF")
+	checkCodecJSONDecoderError(t, `"long"`, []byte(""), io.EOF)
 	checkCodecJSONDecoderResult(t, `"long"`, []byte("0"), int64(0))
 	checkCodecJSONDecoderResult(t, `"long"`, []byte("-3"), int64(-3))
 	checkCodecJSONDecoderResult(t, `"long"`, []byte("3"), int64(3))
@@ -221,17 +223,17 @@ func TestJSONCodecDecoderPrimitives(t *testing.T) {
 	checkCodecJSONDecoderResult(t, `"long"`, []byte("2269530520879620"), int64(2269530520879620))
 	checkCodecJSONDecoderResult(t, `"long"`, []byte("-5513458701470791632"), int64(-5513458701470791632)) // https://github.com/linkedin/goavro/issues/49
 	// float
-	checkCodecJSONDecoderError(t, `"float"`, []byte(""), "cannot decode float: EOF")
+	checkCodecJSONDecoderError(t, `"float"`, []byte(""), io.EOF)
 	checkCodecJSONDecoderResult(t, `"float"`, []byte("3.5"), float32(3.5))
 	// double
-	checkCodecJSONDecoderError(t, `"double"`, []byte(""), "cannot decode double: EOF")
+	checkCodecJSONDecoderError(t, `"double"`, []byte(""), io.EOF)
 	checkCodecJSONDecoderResult(t, `"double"`, []byte("3.5"), float64(3.5))
 	// bytes
-	checkCodecJSONDecoderError(t, `"bytes"`, []byte(""), "cannot decode bytes: EOF")
+	checkCodecJSONDecoderError(t, `"bytes"`, []byte(""), io.EOF)
 	checkCodecJSONDecoderResult(t, `"bytes"`, []byte("\"\""), []byte(""))
 	checkCodecJSONDecoderResult(t, `"bytes"`, []byte("\"some bytes\""), []byte("some bytes"))
 	// string
-	checkCodecJSONDecoderError(t, `"string"`, []byte(""), "cannot decode string: EOF")
+	checkCodecJSONDecoderError(t, `"string"`, []byte(""), io.EOF)
 	checkCodecJSONDecoderResult(t, `"string"`, []byte("\"\""), "")
 	checkCodecJSONDecoderResult(t, `"string"`, []byte("\"some string\""), "some string")
 }
@@ -325,6 +327,7 @@ func TestCodecJSONEncoderUnionEnum(t *testing.T) {
 	checkCodecJSONEncoderResult(t, `["null",{"type":"enum","name":"color_enum","symbols":["red","blue","green"]}]`, nil, []byte("null"))
 	checkCodecJSONEncoderResult(t, `["null",{"type":"enum","name":"color_enum","symbols":["red","blue","green"]}]`, Enum{"color_enum", "blue"}, []byte("{\"color_enum\":\"blue\"}"))
 	checkCodecJSONEncoderError(t, `["null",{"type":"enum","name":"color_enum","symbols":["red","blue","green"]}]`, Enum{"color_enum", "purple"}, "symbol not defined: purple")
+	checkCodecJSONEncoderError(t, `["null",{"type":"enum","name":"color_enum","symbols":["red","blue","green"]}]`, Enum{Name: "color_enum"}, "Enum Value ought to be the symbol to encode")
 }
 
 func TestCodecJSONEncoderUnionMap(t *testing.T) {
@@ -357,6 +360,8 @@ func TestCodecJSONDecoderEnum(t *testing.T) {
 	schema := `{"type":"enum","name":"cards","symbols":["HEARTS","DIAMONDS","SPADES","CLUBS"]}`
 	checkCodecJSONDecoderError(t, schema, []byte("\x01"), "cannot decode enum (cards)")
 	checkCodecJSONDecoderResult(t, schema, []byte("\"SPADES\""), Enum{"cards", "SPADES"})
+	checkCodecJSONDecoderError(t, schema, []byte("\"PINEAPPLE\""), "cannot decode enum (cards): symbol not defined: PINEAPPLE")
+	checkCodecJSONDecoderError(t, `["null",`+schema+`]`, []byte(`{"cards":"spades"}`), "symbol not defined: spades")
 }
 
 func TestCodecJSONEncoderEnum(t *testing.T) {
@@ -369,12 +374,16 @@ func TestCodecJSONEncoderEnum(t *testing.T) {
 
 func TestCodecJSONFixed(t *testing.T) {
 	schema := `{"type":"fixed","name":"fixed1","size":5}`
-	checkCodecDecoderError(t, schema, []byte(""), "EOF")
+	checkCodecDecoderError(t, schema, []byte(""), io.EOF)
 	checkCodecDecoderError(t, schema, []byte("hap"), "buffer underrun")
 	checkCodecEncoderError(t, schema, "happy day", "expected: Fixed; received: string")
 	checkCodecEncoderError(t, schema, Fixed{Name: "fixed1", Value: []byte("day")}, "expected: 5 bytes; received: 3")
 	checkCodecEncoderError(t, schema, Fixed{Name: "fixed1", Value: []byte("happy day")}, "expected: 5 bytes; received: 9")
 	checkCodecEncoderResult(t, schema, Fixed{Name: "fixed1", Value: []byte("happy")}, []byte("happy"))
+	checkCodecJSONDecoderError(t, schema, []byte(`"hap"`), "buffer underrun")
+	checkCodecJSONDecoderError(t, schema, []byte(`"happy day"`), "cannot decode fixed (fixed1): expected: 5 bytes; received: 9")
+	checkCodecJSONDecoderResult(t, schema, []byte(`"happ\u00ff"`), Fixed{Name: "fixed1", Value: []byte("happ\xff")})
+	checkCodecJSONDecoderResult(t, schema, []byte(`"happy"`), Fixed{Name: "fixed1", Value: []byte("happy")})
 }
 
 func TestCodecFixedJSONDecoder(t *testing.T) {
@@ -414,7 +423,8 @@ func TestCodecJSONEncoderArrayChecksSchema(t *testing.T) {
 
 func TestCodecJSONDecoderArrayEOF(t *testing.T) {
 	schema := `{"type":"array","items":"string"}`
-	checkCodecJSONDecoderError(t, schema, []byte(""), "cannot decode array")
+	checkCodecJSONDecoderError(t, schema, []byte(""), io.EOF)
+	checkCodecJSONDecoderError(t, schema, []byte(`["a"`), "cannot decode array")
 }
 
 func TestCodecJSONDecoderArrayEmpty(t *testing.T) {
@@ -592,7 +602,7 @@ func TestCodecJSONMapChecksSchema(t *testing.T) {
 
 func TestCodecJSONDecoderMapEOF(t *testing.T) {
 	schema := `{"type":"map","values":"string"}`
-	checkCodecJSONDecoderError(t, schema, []byte(""), "cannot decode map (map): EOF")
+	checkCodecJSONDecoderError(t, schema, []byte(""), io.EOF)
 }
 
 func TestCodecJSONDecoderMapZeroBlocks(t *testing.T) {
@@ -738,3 +748,524 @@ func TestCodecJSONEncoderRecordWithFieldDefaultString(t *testing.T) {
 	bits := []byte("{\"field1\":64,\"field2\":\"happy\"}")
 	checkCodecJSONEncoderResult(t, recordSchemaJSON, someRecord, bits)
 }
+
+func TestCodecJSONValidate(t *testing.T) {
+	codec, err := NewJSONCodec(`{"type":"array","items":"int"}`)
+	checkErrorFatal(t, err, nil)
+	checkError(t, codec.(Validator).Validate([]interface{}{int32(1), int32(2)}), nil)
+	checkError(t, codec.(Validator).Validate([]interface{}{int32(1), "two"}), "invalid datum at [1]")
+}
+
+func TestCodecJSONErrorReportsPath(t *testing.T) {
+	schema := `{"type":"record","name":"user","fields":[{"name":"scores","type":{"type":"map","values":"int"}}]}`
+	codec, err := NewJSONCodec(schema)
+	checkErrorFatal(t, err, nil)
+
+	someRecord, err := NewRecord(RecordSchema(schema))
+	checkErrorFatal(t, err, nil)
+	someRecord.Set("scores", map[string]interface{}{"math": "A"})
+	err = codec.Encode(new(bytes.Buffer), someRecord)
+	checkError(t, err, "cannot encode record (user) at scores[math]: ")
+
+	_, err = codec.Decode(bytes.NewBufferString(`{"scores":{"math":"A"}}`))
+	checkError(t, err, "cannot decode record (user) at scores[math]: ")
+}
+
+func TestCodecJSONDecodeNative(t *testing.T) {
+	schema := `{"type":"record","name":"user","fields":[
+{"name":"name","type":"string"},
+{"name":"color","type":{"type":"enum","name":"color","symbols":["RED","GREEN"]}},
+{"name":"home","type":{"type":"record","name":"address","fields":[{"name":"city","type":"string"}]}},
+{"name":"visits","type":{"type":"array","items":"address"}},
+{"name":"scores","type":{"type":"map","values":"int"}}]}`
+	text := `{"name":"Homer","color":"GREEN","home":{"city":"Springfield"},"visits":[{"city":"Shelbyville"}],"scores":{"bowling":200}}`
+	expected := map[string]interface{}{
+		"name":   "Homer",
+		"color":  "GREEN",
+		"home":   map[string]interface{}{"city": "Springfield"},
+		"visits": []interface{}{map[string]interface{}{"city": "Shelbyville"}},
+		"scores": map[string]interface{}{"bowling": int32(200)},
+	}
+
+	jsonCodec, err := NewJSONCodec(schema)
+	checkErrorFatal(t, err, nil)
+	actual, err := jsonCodec.(JSONTranscoder).JSONDecodeNative(bytes.NewBufferString(text))
+	checkErrorFatal(t, err, nil)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+
+	// binary codecs decode Avro JSON as well
+	binaryCodec, err := NewCodec(schema)
+	checkErrorFatal(t, err, nil)
+	actual, err = binaryCodec.(JSONTranscoder).JSONDecodeNative(bytes.NewBufferString(text))
+	checkErrorFatal(t, err, nil)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+
+	_, err = jsonCodec.(JSONTranscoder).JSONDecodeNative(bytes.NewBufferString(`{"name":"Homer","color":"BLUE"}`))
+	checkError(t, err, "cannot decode record (user) at color: ")
+}
+
+func TestCodecJSONEncoderRecordFromMap(t *testing.T) {
+	schema := `{"type":"record","name":"user","fields":[{"name":"name","type":"string"},{"name":"age","type":"int","default":7},{"name":"home","type":["null",{"type":"record","name":"address","fields":[{"name":"city","type":"string"}]}]}]}`
+	checkCodecJSONEncoderResult(t, schema, map[string]interface{}{"name": "Bo", "home": map[string]interface{}{"city": "X"}}, []byte(`{"name":"Bo","age":7,"home":{"address":{"city":"X"}}}`))
+	checkCodecJSONEncoderError(t, schema, map[string]interface{}{"name": "Bo", "nickname": "B"}, `no such field: "nickname"`)
+
+	union := `["null",{"type":"record","name":"a","fields":[{"name":"x","type":"int"}]},{"type":"record","name":"b","fields":[{"name":"x","type":"string"}]},{"type":"record","name":"c","fields":[{"name":"y","type":"int"}]}]`
+	checkCodecJSONEncoderResult(t, union, map[string]interface{}{"x": "s"}, []byte(`{"b":{"x":"s"}}`))
+	checkCodecJSONEncoderResult(t, union, OrderedMap{{"y", int32(1)}}, []byte(`{"c":{"y":1}}`))
+}
+
+func TestCodecJSONEmptyStringAsNull(t *testing.T) {
+	schema := `{"type":"record","name":"r","fields":[{"name":"a","type":["null","string"]},{"name":"b","type":"string"},{"name":"c","type":["string","int"]}]}`
+	text := `{"a":{"string":""},"b":"","c":{"string":""}}`
+
+	// without the option empty strings are strings
+	codec, err := NewJSONCodec(schema)
+	checkErrorFatal(t, err, nil)
+	datum, err := codec.Decode(bytes.NewBufferString(text))
+	checkErrorFatal(t, err, nil)
+	if a, _ := datum.(*Record).Get("a"); a != "" {
+		t.Errorf("Actual: %#v; Expected: %#v", a, "")
+	}
+
+	codec, err = NewJSONCodec(schema, JSONEmptyStringAsNull())
+	checkErrorFatal(t, err, nil)
+	for _, text := range []string{text, `{"a":"","b":"","c":{"string":""}}`} {
+		datum, err = codec.Decode(bytes.NewBufferString(text))
+		checkErrorFatal(t, err, nil)
+		expected := map[string]interface{}{"a": nil, "b": "", "c": ""}
+		if actual := nativeDatum(datum); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+		}
+	}
+
+	_, err = NewCodec(schema, JSONEmptyStringAsNull())
+	checkError(t, err, "JSONEmptyStringAsNull ought to be used with NewJSONCodec")
+}
+
+func TestCodecJSONDecodeRecordsAsMaps(t *testing.T) {
+	schema := `{"type":"record","name":"user","fields":[{"name":"name","type":"string"},{"name":"home","type":{"type":"record","name":"address","fields":[{"name":"zip","type":"int"},{"name":"city","type":"string"}]}}]}`
+	codec, err := NewJSONCodec(schema, DecodeRecordsAsMaps())
+	checkErrorFatal(t, err, nil)
+	datum, err := codec.Decode(bytes.NewBufferString(`{"home":{"city":"X","zip":1},"name":"Bo"}`))
+	checkErrorFatal(t, err, nil)
+	expected := OrderedMap{
+		{"name", "Bo"},
+		{"home", OrderedMap{{"zip", int32(1)}, {"city", "X"}}},
+	}
+	if !reflect.DeepEqual(datum, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", datum, expected)
+	}
+}
+
+func TestCodecJSONEncodeIndent(t *testing.T) {
+	schema := `{"type":"record","name":"r","fields":[{"name":"z","type":"int"},{"name":"a","type":{"type":"array","items":"string"}},{"name":"u","type":["null","long"]}]}`
+	datum := map[string]interface{}{"z": int32(1), "a": []interface{}{"x"}, "u": int64(2)}
+	expected := "{\n>\t\"z\": 1,\n>\t\"a\": [\n>\t\t\"x\"\n>\t],\n>\t\"u\": {\n>\t\t\"long\": 2\n>\t}\n>}"
+
+	for _, build := range []func(string, ...CodecSetter) (Codec, error){NewJSONCodec, NewCodec} {
+		codec, err := build(schema)
+		checkErrorFatal(t, err, nil)
+		bb := new(bytes.Buffer)
+		checkErrorFatal(t, codec.(JSONTranscoder).JSONEncodeIndent(bb, datum, ">", "\t"), nil)
+		if actual := bb.String(); actual != expected {
+			t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+		}
+	}
+
+	// the default remains compact
+	codec, err := NewJSONCodec(schema)
+	checkErrorFatal(t, err, nil)
+	checkCodecJSONEncoderResult(t, schema, datum, []byte(`{"z":1,"a":["x"],"u":{"long":2}}`))
+	err = codec.(JSONTranscoder).JSONEncodeIndent(new(bytes.Buffer), map[string]interface{}{"z": "one"}, "", " ")
+	checkError(t, err, "cannot encode record (r) at z")
+}
+
+func TestCodecJSONBytesCodePoints(t *testing.T) {
+	all := make([]byte, 256)
+	for i := range all {
+		all[i] = byte(i)
+	}
+	checkCodecJSONRoundTrip(t, `"bytes"`, all)
+	checkCodecJSONRoundTrip(t, `{"type":"fixed","name":"f","size":256}`, Fixed{Name: "f", Value: all})
+
+	checkCodecJSONEncoderResult(t, `"bytes"`, []byte("\x00a\"\\\x7f\xe9\xff"), []byte(`"\u0000a\"\\\u007f\u00e9\u00ff"`))
+	checkCodecJSONEncoderResult(t, `{"type":"fixed","name":"f","size":2}`, Fixed{Name: "f", Value: []byte("\x01\xfe")}, []byte(`"\u0001\u00fe"`))
+
+	// a character is one byte, whether escaped or written as UTF-8
+	checkCodecJSONDecoderResult(t, `"bytes"`, []byte(`"éé\n"`), []byte("\xe9\xe9\n"))
+	checkCodecJSONDecoderResult(t, `{"type":"fixed","name":"f","size":2}`, []byte(`"ÿA"`), Fixed{Name: "f", Value: []byte("\xffA")})
+	checkCodecJSONDecoderError(t, `"bytes"`, []byte(`"Ā"`), "expected string of code points 0-255")
+}
+
+func TestCodecJSONUnionMembers(t *testing.T) {
+	schema := `["null","int",{"type":"array","items":"long"},{"type":"map","values":"string"},{"type":"enum","name":"e","namespace":"n","symbols":["A","B"]},{"type":"record","name":"r","namespace":"n","fields":[{"name":"f","type":"int"}]}]`
+
+	checkCodecJSONDecoderResult(t, schema, []byte(`null`), nil)
+	checkCodecJSONDecoderResult(t, schema, []byte(`{"int":0}`), int32(0))
+	checkCodecJSONDecoderResult(t, schema, []byte(`{"n.e":"B"}`), Enum{Name: "n.e", Value: "B"})
+
+	codec, err := NewJSONCodec(schema)
+	checkErrorFatal(t, err, nil)
+	for encoded, expected := range map[string]interface{}{
+		`{"array":[]}`:     []interface{}(nil),
+		`{"array":[0,3]}`:  []interface{}{int64(0), int64(3)},
+		`{"map":{}}`:       map[string]interface{}{},
+		`{"map":{"k":""}}`: map[string]interface{}{"k": ""},
+	} {
+		actual, err := codec.Decode(bytes.NewReader([]byte(encoded)))
+		checkErrorFatal(t, err, nil)
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+		}
+	}
+	datum, err := codec.Decode(bytes.NewReader([]byte(`{"n.r":{"f":0}}`)))
+	checkErrorFatal(t, err, nil)
+	record, ok := datum.(*Record)
+	if !ok {
+		t.Fatalf("Actual: %#v; Expected: %#v", datum, "*Record")
+	}
+	if value, err := record.Get("f"); err != nil || value != int32(0) {
+		t.Errorf("Actual: %#v; Expected: %#v", value, int32(0))
+	}
+
+	checkCodecJSONDecoderError(t, schema, []byte(`0`), "unsupported union value 0")
+	checkCodecJSONDecoderError(t, schema, []byte(`{}`), "received: 0 keys")
+	checkCodecJSONDecoderError(t, schema, []byte(`{"int":1,"long":2}`), "received: 2 keys")
+	checkCodecJSONDecoderError(t, schema, []byte(`{"null":null}`), "expected: bare null for null member")
+	checkCodecJSONDecoderError(t, schema, []byte(`{"string":"x"}`), "unknown union type string")
+}
+
+func TestCodecJSONUnionFullnames(t *testing.T) {
+	schema := `{"type":"record","name":"outer","namespace":"com.example","fields":[
+		{"name":"first","type":{"type":"record","name":"item","namespace":"com.a","fields":[{"name":"a","type":"int"}]}},
+		{"name":"second","type":{"type":"record","name":"item","namespace":"com.b","fields":[{"name":"b","type":"string"}]}},
+		{"name":"color","type":{"type":"enum","name":"color","symbols":["RED"]}},
+		{"name":"either","type":["null","com.a.item","com.b.item"]},
+		{"name":"local","type":["null","color",{"type":"fixed","name":"hash","size":2}]}]}`
+	codec, err := NewJSONCodec(schema)
+	checkErrorFatal(t, err, nil)
+
+	encoded := `{"first":{"a":1},"second":{"b":"x"},"color":"RED","either":{"com.b.item":{"b":"y"}},"local":{"com.example.hash":"hi"}}`
+	datum, err := codec.Decode(bytes.NewReader([]byte(encoded)))
+	checkErrorFatal(t, err, nil)
+	record := datum.(*Record)
+	either, err := record.Get("either")
+	checkErrorFatal(t, err, nil)
+	if actual, expected := either.(*Record).Name, "com.b.item"; actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, codec.Encode(bb, datum), nil)
+	if actual := bb.String(); actual != encoded {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, encoded)
+	}
+
+	checkErrorFatal(t, record.Set("either", map[string]interface{}{"a": int32(2)}), nil)
+	checkErrorFatal(t, record.Set("local", Enum{Name: "com.example.color", Value: "RED"}), nil)
+	bb.Reset()
+	checkErrorFatal(t, codec.Encode(bb, record), nil)
+	expected := `{"first":{"a":1},"second":{"b":"x"},"color":"RED","either":{"com.a.item":{"a":2}},"local":{"com.example.color":"RED"}}`
+	if actual := bb.String(); actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+
+	_, err = codec.Decode(bytes.NewReader([]byte(`{"first":{"a":1},"second":{"b":"x"},"color":"RED","either":{"item":{"a":2}},"local":null}`)))
+	checkError(t, err, "unknown union type item")
+}
+
+func TestCodecJSONEncoderUnionPointers(t *testing.T) {
+	someLong, someString := int64(13), "happy"
+	checkCodecJSONEncoderResult(t, `["null","long"]`, &someLong, []byte(`{"long":13}`))
+	checkCodecJSONEncoderResult(t, `["null","string"]`, &someString, []byte(`{"string":"happy"}`))
+	checkCodecJSONEncoderResult(t, `["null","string"]`, (*string)(nil), []byte(`null`))
+	checkCodecJSONEncoderResult(t, `["null",{"type":"record","name":"p","fields":[{"name":"a","type":"long"}]}]`, (*Record)(nil), []byte(`null`))
+}
+
+func TestCodecJSONEncoderUnionNumericAndSlices(t *testing.T) {
+	checkCodecJSONEncoderResult(t, `["null","long"]`, json.Number("13"), []byte(`{"long":13}`))
+	checkCodecJSONEncoderResult(t, `["null","long"]`, 13, []byte(`{"long":13}`))
+	checkCodecJSONEncoderResult(t, `["null","long"]`, int32(13), []byte(`{"long":13}`))
+	checkCodecJSONEncoderResult(t, `["null","int"]`, 13, []byte(`{"int":13}`))
+	checkCodecJSONEncoderResult(t, `["null","double"]`, json.Number("3.5"), []byte(`{"double":3.5}`))
+	checkCodecJSONEncoderResult(t, `["null",{"type":"array","items":"string"}]`, []string{"a"}, []byte(`{"array":["a"]}`))
+}
+
+func TestCodecJSONUnionValue(t *testing.T) {
+	schema := `["null","int","long"]`
+	checkCodecJSONEncoderResult(t, schema, Union("long", int64(1)), []byte(`{"long":1}`))
+	checkCodecJSONEncoderResult(t, schema, Union("int", int32(1)), []byte(`{"int":1}`))
+	checkCodecJSONEncoderError(t, schema, Union("int64", int64(1)), "no member named int64")
+
+	codec, err := NewJSONCodec(schema, DecodeUnionValues())
+	checkErrorFatal(t, err, nil)
+	datum, err := codec.Decode(bytes.NewReader([]byte(`{"long":1}`)))
+	checkErrorFatal(t, err, nil)
+	if expected := (UnionValue{Branch: 2, TypeName: "long", Value: int64(1)}); datum != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", datum, expected)
+	}
+}
+
+func TestCodecJSONEncoderUnionRecordBranches(t *testing.T) {
+	schema := `["null",{"type":"record","name":"a","namespace":"n","fields":[{"name":"x","type":"int"}]},{"type":"record","name":"b","namespace":"n","fields":[{"name":"y","type":"string"}]}]`
+	c, err := NewRecord(RecordSchema(`{"type":"record","name":"c","namespace":"n","fields":[{"name":"y","type":"string"}]}`))
+	checkErrorFatal(t, err, nil)
+	checkErrorFatal(t, c.Set("y", "hi"), nil)
+	checkCodecJSONEncoderError(t, schema, c, "expected record: n.a, n.b; received record: n.c")
+}
+
+func TestCodecTranscoding(t *testing.T) {
+	schema := `{"type":"record","name":"r","fields":[{"name":"name","type":"string"},{"name":"age","type":["null","int"]}]}`
+	jsonText := `{"name":"Alice","age":{"int":42}}`
+	binary := []byte("\x0aAlice\x02\x54")
+
+	binaryCodec, err := NewCodec(schema)
+	checkErrorFatal(t, err, nil)
+	jsonCodec, err := NewJSONCodec(schema)
+	checkErrorFatal(t, err, nil)
+
+	for _, codec := range []Codec{binaryCodec, jsonCodec} {
+		bb := new(bytes.Buffer)
+		checkErrorFatal(t, codec.(JSONTranscoder).JSONToBinary(bytes.NewReader([]byte(jsonText)), bb), nil)
+		if actual := bb.Bytes(); !bytes.Equal(actual, binary) {
+			t.Errorf("Actual: %#v; Expected: %#v", actual, binary)
+		}
+		bb.Reset()
+		checkErrorFatal(t, codec.(JSONTranscoder).BinaryToJSON(bytes.NewReader(binary), bb), nil)
+		if actual := bb.String(); actual != jsonText {
+			t.Errorf("Actual: %#v; Expected: %#v", actual, jsonText)
+		}
+		checkError(t, codec.(JSONTranscoder).JSONToBinary(bytes.NewReader([]byte(`{"name":13}`)), new(bytes.Buffer)), "expected: string")
+	}
+}
+
+func TestCodecTranscodingOptions(t *testing.T) {
+	schema := `{"type":"record","name":"r","fields":[{"name":"name","type":"string"},{"name":"age","type":["null","int"]}]}`
+	binary := []byte("\x0aAlice\x02\x54")
+
+	// the JSON decoder of a binary codec honors its options
+	binaryCodec, err := NewCodec(schema, IgnoreUnknownFields())
+	checkErrorFatal(t, err, nil)
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, binaryCodec.(JSONTranscoder).JSONToBinary(bytes.NewReader([]byte(`{"name":"Alice","age":{"int":42},"extra":true}`)), bb), nil)
+	if actual := bb.Bytes(); !bytes.Equal(actual, binary) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, binary)
+	}
+
+	// and is built only once
+	first, _, err := binaryCodec.(*codec).transcodingCodecs()
+	checkErrorFatal(t, err, nil)
+	second, _, err := binaryCodec.(*codec).transcodingCodecs()
+	checkErrorFatal(t, err, nil)
+	if first != second {
+		t.Errorf("Actual: %#v; Expected: %#v", second, first)
+	}
+
+	// the JSON encoder of a JSON codec still honors its options
+	jsonCodec, err := NewJSONCodec(schema, JSONBareOptionals())
+	checkErrorFatal(t, err, nil)
+	bb.Reset()
+	checkErrorFatal(t, jsonCodec.(JSONTranscoder).BinaryToJSON(bytes.NewReader(binary), bb), nil)
+	if actual, expected := bb.String(), `{"name":"Alice","age":42}`; actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+
+	// as do field codecs
+	fieldCodec, err := binaryCodec.(SchemaInspector).FieldCodec("age")
+	checkErrorFatal(t, err, nil)
+	bb.Reset()
+	checkErrorFatal(t, fieldCodec.(JSONTranscoder).BinaryToJSON(bytes.NewReader([]byte("\x02\x54")), bb), nil)
+	if actual, expected := bb.String(), `{"int":42}`; actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+}
+
+func TestCodecJSONRelaxedUnions(t *testing.T) {
+	schema := `["null","boolean","int","string",{"type":"array","items":"int"},{"type":"record","name":"r","fields":[{"name":"a","type":"int"}]}]`
+	codec, err := NewJSONCodec(schema, JSONRelaxedUnions())
+	checkErrorFatal(t, err, nil)
+	for _, tc := range []struct {
+		json     string
+		expected interface{}
+	}{
+		{`null`, nil},
+		{`true`, true},
+		{`42`, int32(42)},
+		{`"hello"`, "hello"},
+		{`[1,2]`, []interface{}{int32(1), int32(2)}},
+		{`{"int":42}`, int32(42)},
+		{`{"string":"wrapped"}`, "wrapped"},
+	} {
+		datum, err := codec.Decode(bytes.NewReader([]byte(tc.json)))
+		checkErrorFatal(t, err, nil)
+		if !reflect.DeepEqual(datum, tc.expected) {
+			t.Errorf("Actual: %#v; Expected: %#v", datum, tc.expected)
+		}
+	}
+	datum, err := codec.Decode(bytes.NewReader([]byte(`{"a":1}`)))
+	checkErrorFatal(t, err, nil)
+	if actual, _ := datum.(*Record).Get("a"); actual != int32(1) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, int32(1))
+	}
+
+	codec, err = NewJSONCodec(`["null","int","long"]`, JSONRelaxedUnions())
+	checkErrorFatal(t, err, nil)
+	_, err = codec.Decode(bytes.NewReader([]byte(`42`)))
+	checkError(t, err, `ambiguous number value matches members int, long; wrap it as {"int": ...}`)
+	_, err = codec.Decode(bytes.NewReader([]byte(`"x"`)))
+	checkError(t, err, "no member for string value: x")
+	datum, err = codec.Decode(bytes.NewReader([]byte(`{"long":42}`)))
+	checkErrorFatal(t, err, nil)
+	if datum != int64(42) {
+		t.Errorf("Actual: %#v; Expected: %#v", datum, int64(42))
+	}
+
+	// unwrapped values are rejected by default
+	checkCodecJSONDecoderError(t, `["null","int"]`, []byte(`42`), "unsupported union value 42")
+	_, err = NewCodec(`["null","int"]`, JSONRelaxedUnions())
+	checkError(t, err, "JSONRelaxedUnions ought to be used with NewJSONCodec")
+}
+
+func TestCodecJSONBareOptionals(t *testing.T) {
+	schema := `{"type":"record","name":"r","fields":[
+{"name":"name","type":["null","string"]},
+{"name":"age","type":["null","int"],"default":null},
+{"name":"either","type":["null","int","string"]}]}`
+	codec, err := NewJSONCodec(schema, JSONBareOptionals())
+	checkErrorFatal(t, err, nil)
+	record, err := NewRecord(RecordSchema(schema))
+	checkErrorFatal(t, err, nil)
+	checkErrorFatal(t, record.Set("name", "Alice"), nil)
+	checkErrorFatal(t, record.Set("either", int32(1)), nil)
+
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, codec.Encode(bb, record), nil)
+	expected := `{"name":"Alice","age":null,"either":{"int":1}}`
+	if actual := bb.String(); actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+
+	relaxed, err := NewJSONCodec(schema, JSONRelaxedUnions())
+	checkErrorFatal(t, err, nil)
+	datum, err := relaxed.Decode(bb)
+	checkErrorFatal(t, err, nil)
+	if actual, _ := datum.(*Record).Get("name"); actual != "Alice" {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, "Alice")
+	}
+
+	// strict Avro JSON remains the default
+	checkCodecJSONEncoderResult(t, `["null","string"]`, "Alice", []byte(`{"string":"Alice"}`))
+}
+
+func TestCodecJSONNonFiniteFloats(t *testing.T) {
+	codec, err := NewJSONCodec(`"double"`)
+	checkErrorFatal(t, err, nil)
+	checkError(t, codec.Encode(new(bytes.Buffer), math.NaN()), "cannot represent NaN as JSON number")
+
+	for _, tc := range []struct {
+		mode  NonFiniteFloats
+		datum float64
+		json  string
+	}{
+		{NonFiniteAsStrings, math.NaN(), `"NaN"`},
+		{NonFiniteAsStrings, math.Inf(1), `"Infinity"`},
+		{NonFiniteAsStrings, math.Inf(-1), `"-Infinity"`},
+		{NonFiniteAsNull, math.Inf(1), `null`},
+	} {
+		for _, schema := range []string{`"float"`, `"double"`} {
+			codec, err := NewJSONCodec(schema, JSONNonFiniteFloats(tc.mode))
+			checkErrorFatal(t, err, nil)
+			var datum interface{} = tc.datum
+			if schema == `"float"` {
+				datum = float32(tc.datum)
+			}
+			bb := new(bytes.Buffer)
+			checkErrorFatal(t, codec.Encode(bb, datum), nil)
+			if actual := bb.String(); actual != tc.json {
+				t.Errorf("Actual: %#v; Expected: %#v", actual, tc.json)
+			}
+			datum, err = codec.Decode(bb)
+			checkErrorFatal(t, err, nil)
+			actual, ok := datum.(float64)
+			if f, isFloat := datum.(float32); isFloat {
+				actual, ok = float64(f), true
+			}
+			if !ok {
+				t.Fatalf("Actual: %#v; Expected: %#v", datum, tc.datum)
+			}
+			if tc.mode == NonFiniteAsNull {
+				if !math.IsNaN(actual) {
+					t.Errorf("Actual: %#v; Expected: %#v", actual, math.NaN())
+				}
+			} else if actual != tc.datum && !(math.IsNaN(actual) && math.IsNaN(tc.datum)) {
+				t.Errorf("Actual: %#v; Expected: %#v", actual, tc.datum)
+			}
+		}
+	}
+
+	_, err = NewCodec(`"double"`, JSONNonFiniteFloats(NonFiniteAsStrings))
+	checkError(t, err, "ought to be used with NewJSONCodec")
+}
+
+func TestCodecJSONLongPrecision(t *testing.T) {
+	schema := `{"type":"record","name":"r","fields":[
+{"name":"l","type":"long"},
+{"name":"a","type":{"type":"array","items":"long"}},
+{"name":"m","type":{"type":"map","values":"long"}},
+{"name":"u","type":["null","long"]},
+{"name":"n","type":{"type":"record","name":"inner","fields":[{"name":"l","type":"long"}]}}]}`
+	text := `{"l":9223372036854775807,"a":[9223372036854775807,-9223372036854775808],"m":{"k":9223372036854775806},"u":{"long":9223372036854775807},"n":{"l":-9223372036854775807}}`
+
+	codec, err := NewJSONCodec(schema)
+	checkErrorFatal(t, err, nil)
+	datum, err := codec.Decode(bytes.NewBufferString(text))
+	checkErrorFatal(t, err, nil)
+	record := datum.(*Record)
+	if actual, _ := record.Get("l"); actual != int64(math.MaxInt64) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, int64(math.MaxInt64))
+	}
+	if actual, _ := record.Get("a"); !reflect.DeepEqual(actual, []interface{}{int64(math.MaxInt64), int64(math.MinInt64)}) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, []interface{}{int64(math.MaxInt64), int64(math.MinInt64)})
+	}
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, codec.Encode(bb, datum), nil)
+	if actual := bb.String(); actual != text {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, text)
+	}
+
+	// through the binary encoding and back
+	binaryCodec, err := NewCodec(schema)
+	checkErrorFatal(t, err, nil)
+	bits := new(bytes.Buffer)
+	checkErrorFatal(t, binaryCodec.(JSONTranscoder).JSONToBinary(bytes.NewBufferString(text), bits), nil)
+	bb.Reset()
+	checkErrorFatal(t, binaryCodec.(JSONTranscoder).BinaryToJSON(bits, bb), nil)
+	if actual := bb.String(); actual != text {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, text)
+	}
+
+	native, err := binaryCodec.(JSONTranscoder).JSONDecodeNative(bytes.NewBufferString(text))
+	checkErrorFatal(t, err, nil)
+	if actual := native.(map[string]interface{})["n"]; !reflect.DeepEqual(actual, map[string]interface{}{"l": int64(-math.MaxInt64)}) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, map[string]interface{}{"l": int64(-math.MaxInt64)})
+	}
+	bb.Reset()
+	checkErrorFatal(t, binaryCodec.(JSONTranscoder).JSONEncodeIndent(bb, datum, "", ""), nil)
+	if actual := bb.String(); !strings.Contains(actual, `"l": 9223372036854775807`) {
+		t.Errorf("Actual: %#v; Expected to contain: %#v", actual, `"l": 9223372036854775807`)
+	}
+
+	checkCodecJSONDecoderError(t, `"long"`, []byte("9223372036854775808"), "cannot decode long: expected int64: received 9223372036854775808")
+	checkCodecJSONDecoderError(t, `"long"`, []byte("1.5"), "cannot decode long: expected int64: received 1.5")
+
+	relaxed, err := NewJSONCodec(schema, JSONRelaxedUnions(), JSONBareOptionals())
+	checkErrorFatal(t, err, nil)
+	datum, err = relaxed.Decode(bytes.NewBufferString(`{"l":1,"a":[],"m":{},"u":9223372036854775807,"n":{"l":1}}`))
+	checkErrorFatal(t, err, nil)
+	if actual, _ := datum.(*Record).Get("u"); actual != int64(math.MaxInt64) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, int64(math.MaxInt64))
+	}
+}
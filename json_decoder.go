@@ -21,6 +21,7 @@ package goavro
 import (
 	"encoding/json"
 	"io"
+	"math"
 )
 
 func jsonDecode(r io.Reader, friendlyName string) (interface{}, error) {
@@ -72,16 +73,24 @@ func longJSONDecoder(r io.Reader) (interface{}, error) {
 	}
 	someNumber, ok := someValue.(json.Number)
 	if !ok {
-		return nil, newDecoderError("long", "expected json.Number: received %T", someNumber)
+		return nil, newDecoderError("long", "expected json.Number: received %T", someValue)
 	}
-	return someNumber.Int64()
+	// parse the digits, rather than a float64 approximation of them
+	someLong, err := someNumber.Int64()
+	if err != nil {
+		return nil, newDecoderError("long", "expected int64: received %v", someNumber)
+	}
+	return someLong, nil
 }
 
-func floatJSONDecoder(r io.Reader) (interface{}, error) {
+func floatJSONDecoder(opts *codecOptions, r io.Reader) (interface{}, error) {
 	someValue, err := newJSONDecoder("float")(r)
 	if err != nil {
 		return nil, err
 	}
+	if someFloat, ok := opts.nonFiniteFromJSON(someValue); ok {
+		return float32(someFloat), nil
+	}
 	someNumber, ok := someValue.(json.Number)
 	if !ok {
 		return nil, newDecoderError("float", "expected json.Number: received %T", someNumber)
@@ -93,11 +102,14 @@ func floatJSONDecoder(r io.Reader) (interface{}, error) {
 	return float32(someFloat), nil
 }
 
-func doubleJSONDecoder(r io.Reader) (interface{}, error) {
+func doubleJSONDecoder(opts *codecOptions, r io.Reader) (interface{}, error) {
 	someValue, err := newJSONDecoder("double")(r)
 	if err != nil {
 		return nil, err
 	}
+	if someFloat, ok := opts.nonFiniteFromJSON(someValue); ok {
+		return someFloat, nil
+	}
 	someNumber, ok := someValue.(json.Number)
 	if !ok {
 		return nil, newDecoderError("double", "expected json.Number: received %T", someNumber)
@@ -105,6 +117,31 @@ func doubleJSONDecoder(r io.Reader) (interface{}, error) {
 	return someNumber.Float64()
 }
 
+// nonFiniteFromJSON returns the NaN or infinite value which someValue,
+// a decoded JSON value, stands for under the NonFiniteFloats mode of
+// opts, and whether it stands for one.
+func (opts *codecOptions) nonFiniteFromJSON(someValue interface{}) (float64, bool) {
+	if opts == nil {
+		return 0, false
+	}
+	switch opts.nonFiniteFloats {
+	case NonFiniteAsStrings:
+		switch someValue {
+		case "NaN":
+			return math.NaN(), true
+		case "Infinity":
+			return math.Inf(1), true
+		case "-Infinity":
+			return math.Inf(-1), true
+		}
+	case NonFiniteAsNull:
+		if someValue == nil {
+			return math.NaN(), true
+		}
+	}
+	return 0, false
+}
+
 func bytesJSONDecoder(r io.Reader) (interface{}, error) {
 	someValue, err := newJSONDecoder("bytes")(r)
 	if err != nil {
@@ -114,7 +151,11 @@ func bytesJSONDecoder(r io.Reader) (interface{}, error) {
 	if !ok {
 		return nil, newDecoderError("bytes", "expected string: received %T", someValue)
 	}
-	return []byte(someString), nil
+	someBytes, ok := bytesDefault(someString)
+	if !ok {
+		return nil, newDecoderError("bytes", "expected string of code points 0-255: received %q", someString)
+	}
+	return someBytes, nil
 }
 
 func stringJSONDecoder(r io.Reader) (interface{}, error) {
@@ -22,6 +22,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 )
 
 func jsonEncode(w io.Writer, datum interface{}) error {
@@ -52,43 +53,99 @@ func booleanJSONEncoder(w io.Writer, datum interface{}) error {
 }
 
 func intJSONEncoder(w io.Writer, datum interface{}) error {
-	someNumber, ok := datum.(int32)
-	if !ok {
-		return newEncoderError("int", "expected: int32 received %T", datum)
+	someNumber, err := intDatum(datum)
+	if err != nil {
+		return newEncoderError("int", err)
 	}
 	return newJSONEncoder("int32")(w, someNumber)
 }
 
 func longJSONEncoder(w io.Writer, datum interface{}) error {
-	someNumber, ok := datum.(int64)
-	if !ok {
-		return newEncoderError("long", "expected: int64 received %T", datum)
+	someNumber, err := longDatum(datum)
+	if err != nil {
+		return newEncoderError("long", err)
 	}
 	return newJSONEncoder("int64")(w, someNumber)
 }
 
-func floatJSONEncoder(w io.Writer, datum interface{}) error {
-	someNumber, ok := datum.(float32)
-	if !ok {
-		return newEncoderError("float", "expected: float32 received %T", datum)
+func floatJSONEncoder(opts *codecOptions, w io.Writer, datum interface{}) error {
+	someNumber, err := floatDatum(datum)
+	if err != nil {
+		return newEncoderError("float", err)
+	}
+	if someFloat := float64(someNumber); math.IsNaN(someFloat) || math.IsInf(someFloat, 0) {
+		return opts.writeNonFiniteJSON(w, "float", someFloat)
 	}
 	return newJSONEncoder("float32")(w, someNumber)
 }
 
-func doubleJSONEncoder(w io.Writer, datum interface{}) error {
-	someNumber, ok := datum.(float64)
-	if !ok {
-		return newEncoderError("float", "expected: float64 received %T", datum)
+func doubleJSONEncoder(opts *codecOptions, w io.Writer, datum interface{}) error {
+	someNumber, err := doubleDatum(datum)
+	if err != nil {
+		return newEncoderError("double", err)
+	}
+	if math.IsNaN(someNumber) || math.IsInf(someNumber, 0) {
+		return opts.writeNonFiniteJSON(w, "double", someNumber)
 	}
 	return newJSONEncoder("float64")(w, someNumber)
 }
 
+// writeNonFiniteJSON writes someFloat, which is NaN or infinite, as
+// the NonFiniteFloats mode of opts requires.
+func (opts *codecOptions) writeNonFiniteJSON(w io.Writer, dataType string, someFloat float64) error {
+	var s string
+	switch {
+	case math.IsNaN(someFloat):
+		s = "NaN"
+	case someFloat > 0:
+		s = "Infinity"
+	default:
+		s = "-Infinity"
+	}
+	mode := NonFiniteError
+	if opts != nil {
+		mode = opts.nonFiniteFloats
+	}
+	switch mode {
+	case NonFiniteAsStrings:
+		_, err := io.WriteString(w, `"`+s+`"`)
+		return err
+	case NonFiniteAsNull:
+		_, err := io.WriteString(w, "null")
+		return err
+	}
+	return newEncoderError(dataType, "cannot represent %s as JSON number; see JSONNonFiniteFloats", s)
+}
+
 func bytesJSONEncoder(w io.Writer, datum interface{}) error {
 	someBytes, ok := datum.([]byte)
 	if !ok {
 		return newEncoderError("bytes", "expected: []byte received %T", datum)
 	}
-	return newJSONEncoder("[]uint8")(w, string(someBytes))
+	return writeBytesJSON(w, someBytes)
+}
+
+// writeBytesJSON writes someBytes as a JSON string in which each byte is
+// the code point of one character, as the Avro specification requires
+// for bytes and fixed values. Bytes other than printable ASCII are
+// written as \u00XX escapes, so the output is ASCII whatever the data.
+func writeBytesJSON(w io.Writer, someBytes []byte) error {
+	const hexDigits = "0123456789abcdef"
+	buf := make([]byte, 0, len(someBytes)+2)
+	buf = append(buf, '"')
+	for _, b := range someBytes {
+		switch {
+		case b == '"' || b == '\\':
+			buf = append(buf, '\\', b)
+		case b < 0x20 || b > 0x7e:
+			buf = append(buf, '\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&15])
+		default:
+			buf = append(buf, b)
+		}
+	}
+	buf = append(buf, '"')
+	_, err := w.Write(buf)
+	return err
 }
 
 func stringJSONEncoder(w io.Writer, datum interface{}) error {
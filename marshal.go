@@ -0,0 +1,72 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+package goavro
+
+import (
+	"bytes"
+	"io"
+)
+
+// Marshal returns the binary encoding of datum with the codec, in the
+// manner of json.Marshal, for callers holding encoded data in byte
+// slices rather than streaming it.
+//
+//   buf, err := goavro.Marshal(codec, datum)
+//   if err != nil {
+//       return err
+//   }
+func Marshal(c Codec, datum interface{}) ([]byte, error) {
+	bb := new(bytes.Buffer)
+	if err := c.Encode(bb, datum); err != nil {
+		return nil, err
+	}
+	return bb.Bytes(), nil
+}
+
+// Unmarshal decodes data, which ought to hold exactly one datum encoded
+// with the codec, and stores the datum in the value v points to, in the
+// manner of json.Unmarshal. Data ending before the datum does, and data
+// remaining after it, are errors.
+//
+//   var datum interface{}
+//   if err := goavro.Unmarshal(codec, buf, &datum); err != nil {
+//       return err
+//   }
+func Unmarshal(c Codec, data []byte, v *interface{}) error {
+	if v == nil {
+		return newDecoderError("unmarshal", "expected: non-nil pointer; received: %T", v)
+	}
+	var datum interface{}
+	var err error
+	if sd, ok := c.(StrictDecoder); ok {
+		datum, err = sd.DecodeStrict(bytes.NewReader(data))
+	} else {
+		br := bytes.NewReader(data)
+		if datum, err = c.Decode(br); err == nil && br.Len() > 0 {
+			err = newDecoderError("unmarshal", "%d trailing bytes after datum", br.Len())
+		}
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return err
+	}
+	*v = datum
+	return nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+package goavro
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestMarshalUnmarshal(t *testing.T) {
+	codec, err := NewCodec(`{"type":"record","name":"r","fields":[{"name":"s","type":"string"},{"name":"n","type":"long"}]}`)
+	checkErrorFatal(t, err, nil)
+	buf, err := Marshal(codec, map[string]interface{}{"s": "hi", "n": int64(3)})
+	checkErrorFatal(t, err, nil)
+	expected := []byte("\x04hi\x06")
+	if !bytes.Equal(buf, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", buf, expected)
+	}
+
+	var datum interface{}
+	checkErrorFatal(t, Unmarshal(codec, buf, &datum), nil)
+	if actual, _ := datum.(*Record).Get("s"); actual != "hi" {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, "hi")
+	}
+
+	checkError(t, Unmarshal(codec, append(buf, 0), &datum), "1 trailing bytes after datum")
+	checkError(t, Unmarshal(codec, buf[:2], &datum), "unexpected EOF")
+	if err := Unmarshal(codec, nil, &datum); err != io.ErrUnexpectedEOF {
+		t.Errorf("Actual: %#v; Expected: %#v", err, io.ErrUnexpectedEOF)
+	}
+	checkError(t, Unmarshal(codec, buf, nil), "expected: non-nil pointer")
+
+	_, err = Marshal(codec, "bad")
+	checkError(t, err, "cannot encode record (r)")
+}
+
+// minimalCodec implements only the methods of Codec, as codecs defined
+// outside this package may.
+type minimalCodec struct {
+	c Codec
+}
+
+func (m minimalCodec) Decode(r io.Reader) (interface{}, error) { return m.c.Decode(r) }
+
+func (m minimalCodec) Encode(w io.Writer, datum interface{}) error { return m.c.Encode(w, datum) }
+
+func (m minimalCodec) Schema() string { return m.c.Schema() }
+
+func (m minimalCodec) NewWriter(setters ...WriterSetter) (*Writer, error) {
+	return m.c.NewWriter(setters...)
+}
+
+func TestMarshalUnmarshalMinimalCodec(t *testing.T) {
+	someCodec, err := NewCodec(`"long"`)
+	checkErrorFatal(t, err, nil)
+	for _, ok := range []bool{
+		implements(someCodec, (*Validator)(nil)),
+		implements(someCodec, (*StrictDecoder)(nil)),
+		implements(someCodec, (*ReuseDecoder)(nil)),
+		implements(someCodec, (*BufferCodec)(nil)),
+		implements(someCodec, (*StructCodec)(nil)),
+		implements(someCodec, (*ArrayStreamCodec)(nil)),
+		implements(someCodec, (*Comparer)(nil)),
+		implements(someCodec, (*SchemaInspector)(nil)),
+		implements(someCodec, (*JSONTranscoder)(nil)),
+	} {
+		if !ok {
+			t.Errorf("Actual: %#v; Expected: %#v", ok, true)
+		}
+	}
+
+	codec := minimalCodec{someCodec}
+	buf, err := Marshal(codec, int64(3))
+	checkErrorFatal(t, err, nil)
+	var datum interface{}
+	checkErrorFatal(t, Unmarshal(codec, buf, &datum), nil)
+	if datum != int64(3) {
+		t.Errorf("Actual: %#v; Expected: %#v", datum, int64(3))
+	}
+	checkError(t, Unmarshal(codec, append(buf, 0), &datum), "1 trailing bytes after datum")
+}
+
+// implements returns whether someCodec implements the interface iface
+// points to.
+func implements(someCodec Codec, iface interface{}) bool {
+	return reflect.TypeOf(someCodec).Implements(reflect.TypeOf(iface).Elem())
+}
@@ -28,9 +28,10 @@ const (
 )
 
 type name struct {
-	n   string // name
-	ns  string // namespace
-	ens string // enclosing namespace
+	n     string // name
+	ns    string // namespace
+	ens   string // enclosing namespace
+	nsSet bool   // namespace given by schema, even if empty
 }
 
 type nameSetter func(*name) error
@@ -43,11 +44,13 @@ func newName(setters ...nameSetter) (*name, error) {
 			return nil, err
 		}
 	}
-	// if name contains dot, then ignore namespace and enclosing namespace
+	// if name contains dot, then ignore namespace and enclosing namespace;
+	// a namespace given as the empty string is the null namespace, rather
+	// than an absent namespace, so the enclosing namespace is ignored too
 	if !strings.ContainsRune(n.n, '.') {
 		if n.ns != "" {
 			n.n = n.ns + "." + n.n
-		} else if n.ens != "" {
+		} else if n.ens != "" && !n.nsSet {
 			n.n = n.ens + "." + n.n
 		}
 	}
@@ -64,11 +67,20 @@ func nameSchema(schema map[string]interface{}) nameSetter {
 		if !ok || len(n.n) == 0 {
 			return fmt.Errorf("name ought to be non-empty string: %T", n)
 		}
+		if err := checkFullname(n.n); err != nil {
+			return &ErrSchemaParse{"invalid name", err}
+		}
 		if val, ok := schema["namespace"]; ok {
 			n.ns, ok = val.(string)
 			if !ok {
 				return fmt.Errorf("namespace ought to be a string: %T", n)
 			}
+			n.nsSet = true
+			if n.ns != "" {
+				if err := checkFullname(n.ns); err != nil {
+					return &ErrSchemaParse{"invalid namespace", err}
+				}
+			}
 		}
 		return nil
 	}
@@ -85,7 +97,7 @@ func (e ErrInvalidName) Error() string {
 }
 
 func isRuneInvalidForFirstCharacter(r rune) bool {
-	if (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') || r == '_' || r == '.' {
+	if (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') || r == '_' {
 		return false
 	}
 	return true
@@ -98,22 +110,35 @@ func isRuneInvalidForOtherCharacters(r rune) bool {
 	return isRuneInvalidForFirstCharacter(r)
 }
 
+// checkName returns an error unless s is a valid name without a
+// namespace, such as a record field name or an enum symbol.
 func checkName(s string) error {
 	if len(s) == 0 {
 		return &ErrInvalidName{"not be empty"}
 	}
 	if strings.IndexFunc(s[:1], isRuneInvalidForFirstCharacter) != -1 {
-		return &ErrInvalidName{"start with [A-Za-z_]"}
+		return &ErrInvalidName{fmt.Sprintf("start with [A-Za-z_]: %q", s)}
 	}
 	if strings.IndexFunc(s[1:], isRuneInvalidForOtherCharacters) != -1 {
-		return &ErrInvalidName{"have second and remaining characters contain only [A-Za-z0-9_]"}
+		return &ErrInvalidName{fmt.Sprintf("have second and remaining characters contain only [A-Za-z0-9_]: %q", s)}
+	}
+	return nil
+}
+
+// checkFullname returns an error unless each dot separated component of
+// s, which is either a name or a namespace, is a valid name.
+func checkFullname(s string) error {
+	for _, component := range strings.Split(s, ".") {
+		if err := checkName(component); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
 func nameName(someName string) nameSetter {
 	return func(n *name) (err error) {
-		if err = checkName(someName); err == nil {
+		if err = checkFullname(someName); err == nil {
 			n.n = someName
 		}
 		return
@@ -19,6 +19,8 @@
 package goavro
 
 import (
+	"errors"
+	"strings"
 	"testing"
 )
 
@@ -175,3 +177,105 @@ func TestNameNamespaceWithoutNamespace(t *testing.T) {
 		t.Errorf("Actual: %#v; Expected: %#v", someNamespace, "")
 	}
 }
+
+func TestNameRejectsInvalidSchemaNames(t *testing.T) {
+	cases := []struct {
+		schema, expected string
+	}{
+		{`{"type":"record","name":"my-record","fields":[{"name":"a","type":"int"}]}`, `contain only [A-Za-z0-9_]: "my-record"`},
+		{`{"type":"record","name":"com.2x.r","fields":[{"name":"a","type":"int"}]}`, `start with [A-Za-z_]: "2x"`},
+		{`{"type":"record","name":"r","namespace":"com..example","fields":[{"name":"a","type":"int"}]}`, "invalid namespace"},
+		{`{"type":"record","name":"r","fields":[{"name":"first name","type":"int"}]}`, `invalid field name`},
+		{`{"type":"record","name":"r","fields":[{"name":"a.b","type":"int"}]}`, `contain only [A-Za-z0-9_]: "a.b"`},
+		{`{"type":"enum","name":"e","symbols":["OK","NOT-OK"]}`, `invalid symbol`},
+		{`{"type":"fixed","name":"9f","size":1}`, `start with [A-Za-z_]: "9f"`},
+	}
+	for _, c := range cases {
+		for _, build := range []func(string, ...CodecSetter) (Codec, error){NewCodec, NewJSONCodec} {
+			_, err := build(c.schema)
+			checkError(t, err, c.expected)
+			var parseError *ErrSchemaParse
+			if !errors.As(err, &parseError) {
+				t.Errorf("Actual: %#v; Expected: %#v", err, "*ErrSchemaParse")
+			}
+		}
+	}
+
+	_, err := NewCodec(`{"type":"record","name":"r","namespace":"com.example_1","fields":[{"name":"_a1","type":{"type":"enum","name":"e","symbols":["A_1","_b"]}}]}`)
+	checkError(t, err, nil)
+}
+
+func TestNameNestedNamespaceInheritance(t *testing.T) {
+	// Inner inherits a.b from Outer; the dotted x.y.Other ignores both its
+	// enclosing namespace and its namespace attribute; Deep inherits x.y
+	// from Other rather than a.b from Outer.
+	schema := `{"type":"record","name":"Outer","namespace":"a.b","fields":[
+		{"name":"inner","type":{"type":"record","name":"Inner","fields":[{"name":"x","type":"int"}]}},
+		{"name":"again","type":"Inner"},
+		{"name":"other","type":{"type":"record","name":"x.y.Other","namespace":"ignored","fields":[
+			{"name":"deep","type":{"type":"enum","name":"Deep","symbols":["A"]}},
+			{"name":"deepAgain","type":"Deep"}]}},
+		{"name":"deepQualified","type":"x.y.Deep"},
+		{"name":"u","type":["null",{"type":"record","name":"U","fields":[{"name":"z","type":"int"}]}]},
+		{"name":"uAgain","type":"a.b.U"}]}`
+	for _, build := range []func(string, ...CodecSetter) (Codec, error){NewCodec, NewJSONCodec} {
+		_, err := build(schema)
+		checkErrorFatal(t, err, nil)
+
+		_, err = build(`{"type":"record","name":"Outer","namespace":"a.b","fields":[{"name":"other","type":{"type":"record","name":"x.y.Other","fields":[{"name":"d","type":"int"}]}},{"name":"bad","type":"a.b.Other"}]}`)
+		checkError(t, err, "unknown type name: a.b.Other")
+		_, err = build(`{"type":"record","name":"Outer","namespace":"a.b","fields":[{"name":"other","type":{"type":"record","name":"x.y.Other","fields":[{"name":"d","type":{"type":"fixed","name":"F","size":1}}]}},{"name":"bad","type":"a.b.F"}]}`)
+		checkError(t, err, "unknown type name: a.b.F")
+	}
+
+	// field names of a record are qualified by the namespace of its
+	// fullname, not by a namespace attribute its dotted name overrides
+	other, err := NewRecord(RecordSchema(`{"type":"record","name":"x.y.Other","namespace":"ignored","fields":[{"name":"d","type":"int"}]}`))
+	checkErrorFatal(t, err, nil)
+	checkErrorFatal(t, other.Set("d", int32(1)), nil)
+	d, err := other.Get("d")
+	checkErrorFatal(t, err, nil)
+	if d != int32(1) {
+		t.Errorf("Actual: %#v; Expected: %#v", d, int32(1))
+	}
+	if actual := other.Fields[0].Name; actual != "x.y.d" {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, "x.y.d")
+	}
+}
+
+func TestNameEmptyNamespaceIsNullNamespace(t *testing.T) {
+	a, err := newName(nameSchema(map[string]interface{}{"name": "X", "namespace": ""}), nameEnclosingNamespace("enclosing.namespace"))
+	checkErrorFatal(t, err, nil)
+	if expected := (&name{n: "X"}); !a.equals(expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", a, expected)
+	}
+	a, err = newName(nameSchema(map[string]interface{}{"name": "X"}), nameEnclosingNamespace("enclosing.namespace"))
+	checkErrorFatal(t, err, nil)
+	if expected := (&name{n: "enclosing.namespace.X"}); !a.equals(expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", a, expected)
+	}
+
+	// Root is in the null namespace despite being defined inside
+	// com.example.Outer, and its own nested types inherit the null
+	// namespace; it may be referred to from outside any namespace
+	schema := `{"type":"record","name":"Outer","namespace":"com.example","fields":[
+		{"name":"root","type":{"type":"record","name":"Root","namespace":"","fields":[
+			{"name":"kind","type":{"type":"enum","name":"Kind","symbols":["A"]}}]}},
+		{"name":"inner","type":{"type":"record","name":"Inner","fields":[{"name":"x","type":"int"}]}},
+		{"name":"innerAgain","type":"com.example.Inner"}]}`
+	for _, build := range []func(string, ...CodecSetter) (Codec, error){NewCodec, NewJSONCodec} {
+		codec, err := build(schema)
+		checkErrorFatal(t, err, nil)
+		tree := codec.(SchemaInspector).SchemaTree().(*RecordNode)
+		root := tree.Fields[0].Type.(*RecordNode)
+		if root.Name != "Root" || root.Fields[0].Type.(*EnumNode).Name != "Kind" {
+			t.Errorf("Actual: %#v; Expected: %#v", root, "Root")
+		}
+		// the inlined schema keeps Root in the null namespace
+		_, err = NewCodec(codec.(SchemaInspector).InlinedSchema())
+		checkErrorFatal(t, err, nil)
+		if inlined := codec.(SchemaInspector).InlinedSchema(); !strings.Contains(inlined, `"name":"Root","namespace":""`) {
+			t.Errorf("Actual: %#v; Expected: %#v", inlined, `"name":"Root","namespace":""`)
+		}
+	}
+}
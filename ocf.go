@@ -25,10 +25,13 @@ const (
 )
 
 // Compression codecs that Reader and Writer instances can process.
+// Files compressed with CompressionBzip2 may be read, but not written,
+// since the standard library only decompresses bzip2.
 const (
 	CompressionNull    = "null"
 	CompressionDeflate = "deflate"
 	CompressionSnappy  = "snappy"
+	CompressionBzip2   = "bzip2"
 )
 
 var (
@@ -36,14 +39,16 @@ var (
 )
 
 func init() {
-	metadataCodec, _ = NewCodec(metadataSchema)
+	// sorting keys makes the header of a data file depend only on its
+	// metadata, so that Sync suffices to reproduce a file byte for byte
+	metadataCodec, _ = NewCodec(metadataSchema, SortMapKeys())
 }
 
 // IsCompressionCodecSupported returns true if and only if the specified codec
-// string is supported by this library.
+// string is supported by this library, for reading at least.
 func IsCompressionCodecSupported(someCodec string) bool {
 	switch someCodec {
-	case CompressionNull, CompressionDeflate, CompressionSnappy:
+	case CompressionNull, CompressionDeflate, CompressionSnappy, CompressionBzip2:
 		return true
 	default:
 		return false
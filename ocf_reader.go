@@ -21,12 +21,14 @@ package goavro
 import (
 	"bufio"
 	"bytes"
+	"compress/bzip2"
 	"compress/flate"
 	"encoding/binary"
 	"fmt"
 	"hash/crc32"
 	"io"
 	"io/ioutil"
+	"sync"
 
 	"github.com/golang/snappy"
 )
@@ -96,16 +98,51 @@ func FromReader(r io.Reader) ReaderSetter {
 	}
 }
 
+// ReaderConcurrency specifies the number of blocks the Reader
+// decompresses and decodes at once. Blocks of an Object Container File
+// are independent, so decoding them concurrently can improve throughput
+// for large or compressed files. Data are still read in file order. The
+// default, 1, decodes one block at a time.
+func ReaderConcurrency(blocks int) ReaderSetter {
+	return func(fr *Reader) error {
+		if blocks < 1 {
+			return fmt.Errorf("concurrency ought to be positive: %d", blocks)
+		}
+		fr.concurrency = blocks
+		return nil
+	}
+}
+
+// ReaderUseCodec specifies that a Reader should decode data with an
+// existing Codec rather than compiling the writer schema read from
+// the file header, as UseCodec does for a Writer. The schema of the
+// Codec ought to have the same parsing canonical form as the writer
+// schema of the file.
+func ReaderUseCodec(codec Codec) ReaderSetter {
+	return func(fr *Reader) error {
+		if codec == nil {
+			return fmt.Errorf("invalid Codec")
+		}
+		fr.dataCodec = codec
+		return nil
+	}
+}
+
 // Reader structure contains data necessary to read Avro files.
 type Reader struct {
 	CompressionCodec string
 	DataSchema       string
+	Fingerprint      uint64            // SchemaFingerprint of the Parsing Canonical Form of DataSchema
+	Metadata         map[string][]byte // every header metadata key, including custom ones
 	Sync             []byte
 	dataCodec        Codec
 	datum            Datum
 	deblocked        chan Datum
-	err              error
+	concurrency      int
+	err              error // error which stopped the pipeline, guarded by errLock
+	errLock          sync.Mutex
 	r                io.Reader
+	scanned          bool // datum made available by Scan but not yet Read
 }
 
 // NewReader returns a object to read data from an io.Reader using the
@@ -160,6 +197,10 @@ func NewReader(setters ...ReaderSetter) (*Reader, error) {
 	if err != nil {
 		return nil, newReaderInitError("cannot read header metadata", err)
 	}
+	fr.Metadata = make(map[string][]byte, len(meta))
+	for k, v := range meta {
+		fr.Metadata[k] = v.([]byte)
+	}
 	fr.CompressionCodec, err = getHeaderString("avro.codec", meta)
 	if err != nil {
 		fr.CompressionCodec = CompressionNull
@@ -171,40 +212,155 @@ func NewReader(setters ...ReaderSetter) (*Reader, error) {
 	if err != nil {
 		return nil, newReaderInitError("cannot read header metadata", err)
 	}
-	if fr.dataCodec, err = NewCodec(fr.DataSchema); err != nil {
+	if fr.dataCodec == nil {
+		if fr.dataCodec, err = NewCodec(fr.DataSchema); err != nil {
+			return nil, newReaderInitError("cannot compile schema", err)
+		}
+	} else if !sameCanonicalForm(fr.dataCodec.Schema(), fr.DataSchema) {
+		return nil, newReaderInitError("codec schema does not match writer schema: %s", fr.DataSchema)
+	}
+	canonical, err := parsingCanonicalForm(fr.DataSchema)
+	if err != nil {
 		return nil, newReaderInitError("cannot compile schema", err)
 	}
+	fr.Fingerprint = SchemaFingerprint(canonical)
 	fr.Sync = make([]byte, syncLength)
 	if _, err = io.ReadFull(fr.r, fr.Sync); err != nil {
 		return nil, newReaderInitError("cannot read sync marker", err)
 	}
 	// setup reading pipeline
 	toDecompress := make(chan *readerBlock)
-	toDecode := make(chan *readerBlock)
 	fr.deblocked = make(chan Datum)
 	go read(fr, longCodec(), toDecompress)
+	if fr.concurrency > 1 {
+		go decodeConcurrently(fr, toDecompress)
+		return fr, nil
+	}
+	toDecode := make(chan *readerBlock)
 	go decompress(fr, toDecompress, toDecode)
 	go decode(fr, toDecode)
 	return fr, nil
 }
 
+// Codec returns the Codec the Reader decodes data with: either the one
+// compiled from the writer schema in the file header, or the one
+// provided by ReaderUseCodec.
+func (fr *Reader) Codec() Codec {
+	return fr.dataCodec
+}
+
+// WriterSchema returns the schema the file was written with, as found
+// in the avro.schema key of the header metadata.
+func (fr *Reader) WriterSchema() string {
+	return fr.DataSchema
+}
+
 // Close releases resources and returns any Reader errors.
 func (fr *Reader) Close() error {
+	return fr.readErr()
+}
+
+// readErr returns the error which stopped the goroutine reading blocks,
+// if any. It is safe to call while that goroutine runs.
+func (fr *Reader) readErr() error {
+	fr.errLock.Lock()
+	defer fr.errLock.Unlock()
 	return fr.err
 }
 
+func (fr *Reader) setReadErr(err error) {
+	fr.errLock.Lock()
+	fr.err = err
+	fr.errLock.Unlock()
+}
+
 // Scan returns true if more data is ready to be read.
 func (fr *Reader) Scan() bool {
-	var ok bool
-	fr.datum, ok = <-fr.deblocked
-	return ok
+	fr.datum, fr.scanned = <-fr.deblocked
+	return fr.scanned
 }
 
-// Read returns the next element from the Reader.
+// Read returns the next element from the Reader: the one made
+// available by the preceding call to Scan. Without a preceding Scan,
+// Read advances to the next element itself, much as Writer.Write
+// takes the next element, and returns io.EOF once the file is
+// exhausted, or the error which stopped the Reader.
+//
+//     for {
+//         datum, err := fr.Read()
+//         if err == io.EOF {
+//             break
+//         }
+//         if err != nil {
+//             log.Fatal(err)
+//         }
+//         fmt.Println("RECORD: ", datum)
+//     }
 func (fr *Reader) Read() (interface{}, error) {
+	if !fr.scanned && !fr.Scan() {
+		if err := fr.readErr(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	fr.scanned = false
 	return fr.datum.Value, fr.datum.Err
 }
 
+// ReadWithSchemaID returns the next element from the Reader, as Read
+// does, along with the Fingerprint of the schema it was written with,
+// which matches the fingerprint FramedReader reports for data written
+// with the same schema. Every datum in an Object Container File shares
+// the same writer schema.
+func (fr *Reader) ReadWithSchemaID() (uint64, interface{}, error) {
+	datum, err := fr.Read()
+	return fr.Fingerprint, datum, err
+}
+
+// Stream returns a channel on which the Reader sends each remaining
+// datum as it is decoded from the blocks of the file. Sends block until
+// the datum is received. When the file is exhausted, or the Reader
+// encounters an error reading blocks, any such error is sent as a final
+// Datum and the channel is closed. Stream ought not be combined with
+// Scan on the same Reader, and the channel ought to be drained to
+// release the Reader's goroutines.
+func (fr *Reader) Stream() <-chan Datum {
+	stream := make(chan Datum)
+	go func() {
+		for datum := range fr.deblocked {
+			stream <- datum
+		}
+		if err := fr.readErr(); err != nil {
+			stream <- Datum{Err: err}
+		}
+		close(stream)
+	}()
+	return stream
+}
+
+// StreamOCF reads the header of the Object Container File from the
+// specified io.Reader, then returns a channel of its decoded data, as
+// described for Reader.Stream. Errors reading the header are returned
+// immediately.
+//
+//     stream, err := goavro.StreamOCF(fh)
+//     if err != nil {
+//         log.Fatal(err)
+//     }
+//     for datum := range stream {
+//         if datum.Err != nil {
+//             log.Fatal(datum.Err)
+//         }
+//         fmt.Println("RECORD: ", datum.Value)
+//     }
+func StreamOCF(r io.Reader) (<-chan Datum, error) {
+	fr, err := NewReader(BufferFromReader(r))
+	if err != nil {
+		return nil, err
+	}
+	return fr.Stream(), nil
+}
+
 func decodeHeaderMetadata(r io.Reader) (map[string]interface{}, error) {
 	md, err := metadataCodec.Decode(r)
 	if err != nil {
@@ -267,26 +423,27 @@ func read(fr *Reader, lCodec *codec, toDecompress chan<- *readerBlock) {
 
 	blockCount, blockSize, err := readBlockCountAndSize(fr.r, lCodec)
 	if err != nil {
-		fr.err = err
+		fr.setReadErr(err)
 		blockCount = 0
 	}
 	for blockCount != 0 {
 		// Use a new buffer for every block because it will be shared with other goroutines
 		bits := make([]byte, blockSize)
 		if _, err = io.ReadFull(fr.r, bits); err != nil {
-			fr.err = newReaderError("cannot read block", err)
+			fr.setReadErr(newReaderError("cannot read block", err))
 			break
 		}
 		toDecompress <- &readerBlock{datumCount: blockCount, r: bytes.NewReader(bits)}
 		if _, err := io.ReadFull(fr.r, sync); err != nil {
-			fr.err = newReaderError("cannot read sync marker", err)
+			fr.setReadErr(newReaderError("cannot read sync marker", err))
 			break
 		}
 		if !bytes.Equal(fr.Sync, sync) {
-			fr.err = newReaderError(fmt.Sprintf("sync marker mismatch: %#v != %#v", sync, fr.Sync))
+			fr.setReadErr(newReaderError(fmt.Sprintf("sync marker mismatch: %#v != %#v", sync, fr.Sync)))
 			break
 		}
-		if blockCount, blockSize, fr.err = readBlockCountAndSize(fr.r, lCodec); fr.err != nil {
+		if blockCount, blockSize, err = readBlockCountAndSize(fr.r, lCodec); err != nil {
+			fr.setReadErr(err)
 			break
 		}
 	}
@@ -296,7 +453,7 @@ func read(fr *Reader, lCodec *codec, toDecompress chan<- *readerBlock) {
 func readBlockCountAndSize(r io.Reader, lcodec *codec) (int, int, error) {
 	bc, err := lcodec.Decode(r)
 	if err != nil {
-		if ed, ok := err.(*ErrDecoder); ok && ed.Err == io.EOF {
+		if err == io.EOF {
 			return 0, 0, nil // we're done
 		}
 		return 0, 0, &ErrReaderBlockCount{err}
@@ -305,82 +462,81 @@ func readBlockCountAndSize(r io.Reader, lcodec *codec) (int, int, error) {
 	if err != nil {
 		return 0, 0, &ErrReaderBlockCount{err}
 	}
-	return int(bc.(int64)), int(bs.(int64)), nil
+	blockCount, blockSize := bc.(int64), bs.(int64)
+	if blockCount < 0 || blockCount > MaxBlockCount {
+		return 0, 0, &ErrReaderBlockCount{fmt.Errorf("block count ought to be between 0 and MaxBlockCount (%d): %d", MaxBlockCount, blockCount)}
+	}
+	if blockSize < 0 || blockSize > MaxDecodeSize {
+		return 0, 0, &ErrReaderBlockCount{fmt.Errorf("block size ought to be between 0 and MaxDecodeSize (%d): %d", MaxDecodeSize, blockSize)}
+	}
+	return int(blockCount), int(blockSize), nil
 }
 
 func decompress(fr *Reader, toDecompress <-chan *readerBlock, toDecode chan<- *readerBlock) {
-	switch fr.CompressionCodec {
-	case CompressionNull:
-		for block := range toDecompress {
-			toDecode <- block
-		}
+	for block := range toDecompress {
+		decompressBlock(fr.CompressionCodec, block)
+		toDecode <- block
+	}
+	close(toDecode)
+}
 
+// decompressBlock replaces the reader of the block with one that reads
+// its decompressed contents, or sets the error of the block.
+func decompressBlock(compressionCodec string, block *readerBlock) {
+	switch compressionCodec {
 	case CompressionDeflate:
-		var rc io.ReadCloser
-		var bits []byte
-		for block := range toDecompress {
-			rc = flate.NewReader(block.r)
-			bits, block.err = ioutil.ReadAll(rc)
-			if block.err != nil {
-				block.err = newReaderError("cannot read from deflate", block.err)
-				toDecode <- block
-				_ = rc.Close() // already have the read error; ignore the close error
-				continue
-			}
-			block.err = rc.Close()
-			if block.err != nil {
-				block.err = newReaderError("cannot close deflate", block.err)
-				toDecode <- block
-				continue
-			}
-			block.r = bytes.NewReader(bits)
-			toDecode <- block
+		rc := flate.NewReader(block.r)
+		bits, err := ioutil.ReadAll(rc)
+		if err != nil {
+			block.err = newReaderError("cannot read from deflate", err)
+			_ = rc.Close() // already have the read error; ignore the close error
+			return
+		}
+		if err = rc.Close(); err != nil {
+			block.err = newReaderError("cannot close deflate", err)
+			return
 		}
+		block.r = bytes.NewReader(bits)
 
-	case CompressionSnappy:
-		var (
-			src, dst []byte
-			crc      uint32
-		)
-		for block := range toDecompress {
-			src, block.err = ioutil.ReadAll(block.r)
-			if block.err != nil {
-				block.err = newReaderError("cannot read", block.err)
-				toDecode <- block
-				continue
-			}
-			if len(src) < 4 {
-				block.err = newReaderError(fmt.Sprintf("too small of a block (%d bytes)", len(src)))
-				toDecode <- block
-				continue
-			}
-			index := len(src) - 4 // last 4 bytes is crc32 of decoded blob
+	case CompressionBzip2:
+		bits, err := ioutil.ReadAll(bzip2.NewReader(block.r))
+		if err != nil {
+			block.err = newReaderError("cannot read from bzip2", err)
+			return
+		}
+		block.r = bytes.NewReader(bits)
 
-			dst, block.err = snappy.Decode(nil, src[:index])
-			if block.err != nil {
-				block.err = newReaderError("cannot decompress", block.err)
-				toDecode <- block
-				continue
-			}
+	case CompressionSnappy:
+		var crc uint32
+		src, err := ioutil.ReadAll(block.r)
+		if err != nil {
+			block.err = newReaderError("cannot read", err)
+			return
+		}
+		if len(src) < 4 {
+			block.err = newReaderError(fmt.Sprintf("too small of a block (%d bytes)", len(src)))
+			return
+		}
+		index := len(src) - 4 // last 4 bytes is crc32 of decoded blob
 
-			block.err = binary.Read(bytes.NewReader(src[index:index+4]), binary.BigEndian, &crc)
-			if block.err != nil {
-				block.err = newReaderError("failed to read crc checksum after snappy block", block.err)
-				toDecode <- block
-				continue
-			}
+		dst, err := snappy.Decode(nil, src[:index])
+		if err != nil {
+			block.err = newReaderError("cannot decompress", err)
+			return
+		}
 
-			if crc != crc32.ChecksumIEEE(dst) {
-				block.err = newReaderError("snappy crc checksum mismatch", block.err)
-				toDecode <- block
-				continue
-			}
+		if err = binary.Read(bytes.NewReader(src[index:index+4]), binary.BigEndian, &crc); err != nil {
+			block.err = newReaderError("failed to read crc checksum after snappy block", err)
+			return
+		}
 
-			block.r = bytes.NewReader(dst)
-			toDecode <- block
+		if crc != crc32.ChecksumIEEE(dst) {
+			block.err = newReaderError("snappy crc checksum mismatch")
+			return
 		}
+
+		block.r = bytes.NewReader(dst)
 	}
-	close(toDecode)
 }
 
 func decode(fr *Reader, toDecode <-chan *readerBlock) {
@@ -392,6 +548,9 @@ decodeLoop:
 			for i := 0; i < block.datumCount; i++ {
 				var datum Datum
 				datum.Value, datum.Err = fr.dataCodec.Decode(block.r)
+				if datum.Err == io.EOF {
+					datum.Err = newReaderError("block ended before its datum count", io.ErrUnexpectedEOF)
+				}
 				if datum.Value == nil && datum.Err == nil {
 					break decodeLoop
 				}
@@ -401,3 +560,48 @@ decodeLoop:
 	}
 	close(fr.deblocked)
 }
+
+// decodeConcurrently decompresses and decodes up to fr.concurrency
+// blocks at once, sending their data in file order. Each block is given
+// a channel for its data, and those channels are queued in file order.
+func decodeConcurrently(fr *Reader, toDecompress <-chan *readerBlock) {
+	queue := make(chan chan []Datum, fr.concurrency-1)
+	go func() {
+		for block := range toDecompress {
+			data := make(chan []Datum, 1)
+			queue <- data // blocks while too many blocks are in flight
+			go func(block *readerBlock) {
+				data <- decodeBlock(fr, block)
+			}(block)
+		}
+		close(queue)
+	}()
+	for data := range queue {
+		for _, datum := range <-data {
+			fr.deblocked <- datum
+		}
+	}
+	close(fr.deblocked)
+}
+
+// decodeBlock returns the data of a block, or its error.
+func decodeBlock(fr *Reader, block *readerBlock) []Datum {
+	if decompressBlock(fr.CompressionCodec, block); block.err != nil {
+		return []Datum{{Err: block.err}}
+	}
+	// the datum count comes from the file, so rather than trusting it for
+	// the capacity, the slice grows as data are decoded
+	var data []Datum
+	for i := 0; i < block.datumCount; i++ {
+		var datum Datum
+		datum.Value, datum.Err = fr.dataCodec.Decode(block.r)
+		if datum.Err == io.EOF {
+			datum.Err = newReaderError("block ended before its datum count", io.ErrUnexpectedEOF)
+		}
+		if datum.Value == nil && datum.Err == nil {
+			break
+		}
+		data = append(data, datum)
+	}
+	return data
+}
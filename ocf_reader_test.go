@@ -105,6 +105,20 @@ func TestReadBlockCountAndSizeWithNothing(t *testing.T) {
 	}
 }
 
+func TestReadBlockCountAndSizeOutOfRange(t *testing.T) {
+	// negative count
+	_, _, err := readBlockCountAndSize(bytes.NewReader([]byte("\x01\x02")), longCodec())
+	checkError(t, err, "block count ought to be between 0 and MaxBlockCount")
+	// negative size
+	_, _, err = readBlockCountAndSize(bytes.NewReader([]byte("\x02\x01")), longCodec())
+	checkError(t, err, "block size ought to be between 0 and MaxDecodeSize")
+
+	defer func(max int64) { MaxBlockCount = max }(MaxBlockCount)
+	MaxBlockCount = 1
+	_, _, err = readBlockCountAndSize(bytes.NewReader([]byte("\x04\x02")), longCodec())
+	checkError(t, err, "block count ought to be between 0 and MaxBlockCount (1): 2")
+}
+
 func TestFileReadNullCodecBs1(t *testing.T) {
 	fr, err := NewReader(FromReader(bytes.NewReader([]byte(nullCodecSample))))
 	checkErrorFatal(t, err, nil)
@@ -203,3 +217,180 @@ func (obr *shortReader) Read(p []byte) (int, error) {
 	// Read up to 1 byte at a time
 	return obr.r.Read(p[:1])
 }
+
+func TestStreamOCF(t *testing.T) {
+	stream, err := StreamOCF(bytes.NewReader([]byte(snappyCodecSample)))
+	checkErrorFatal(t, err, nil)
+	var count int
+	for datum := range stream {
+		checkErrorFatal(t, datum.Err, nil)
+		if _, ok := datum.Value.(*Record); !ok {
+			t.Errorf("Actual: %T; Expected: %T", datum.Value, &Record{})
+		}
+		count++
+	}
+	if count != 5 {
+		t.Errorf("Actual: %#v; Expected: %#v", count, 5)
+	}
+
+	_, err = StreamOCF(bytes.NewReader([]byte("Obj\x02")))
+	checkError(t, err, "invalid magic number")
+}
+
+func TestStreamOCFSendsReaderError(t *testing.T) {
+	// block declares 2 bytes of data but the file ends after 1
+	bits := []byte("Obj\x01\x04\x14avro.codec\x08null\x16avro.schema\x0a\x22int\x22\x00\x21\x0f\xc7\xbb\x81\x86\x39\xac\x48\xa4\xc6\xaf\xa2\xf1\x58\x1a\x02\x04\x00")
+	stream, err := StreamOCF(bytes.NewReader(bits))
+	checkErrorFatal(t, err, nil)
+	datum, ok := <-stream
+	if !ok {
+		t.Fatalf("Actual: %#v; Expected: %#v", ok, true)
+	}
+	checkError(t, datum.Err, "cannot read block: unexpected EOF")
+	if _, ok = <-stream; ok {
+		t.Errorf("Actual: %#v; Expected: %#v", ok, false)
+	}
+}
+
+func TestReaderConcurrencyPreservesOrder(t *testing.T) {
+	for _, compression := range []string{CompressionNull, CompressionDeflate, CompressionSnappy} {
+		bb := new(bytes.Buffer)
+		fw, err := NewWriter(ToWriter(bb), WriterSchema(`"long"`), BlockSize(3), Compression(compression))
+		checkErrorFatal(t, err, nil)
+		for i := int64(0); i < 100; i++ {
+			fw.Write(i)
+		}
+		checkErrorFatal(t, fw.Close(), nil)
+
+		fr, err := NewReader(FromReader(bytes.NewReader(bb.Bytes())), ReaderConcurrency(4))
+		checkErrorFatal(t, err, nil)
+		var expected int64
+		for fr.Scan() {
+			datum, err := fr.Read()
+			checkErrorFatal(t, err, nil)
+			if datum != expected {
+				t.Fatalf("%s: Actual: %#v; Expected: %#v", compression, datum, expected)
+			}
+			expected++
+		}
+		checkError(t, fr.Close(), nil)
+		if expected != 100 {
+			t.Errorf("%s: Actual: %#v; Expected: %#v", compression, expected, 100)
+		}
+	}
+
+	_, err := NewReader(FromReader(bytes.NewReader([]byte(nullCodecSample))), ReaderConcurrency(0))
+	checkError(t, err, "concurrency ought to be positive: 0")
+}
+
+func TestFileReadSnappyCodecConcurrently(t *testing.T) {
+	fr, err := NewReader(FromReader(bytes.NewReader([]byte(snappyCodecSample))), ReaderConcurrency(2))
+	checkErrorFatal(t, err, nil)
+	testFileReader(t, fr)
+}
+
+func TestReaderReadWithoutScan(t *testing.T) {
+	bb := new(bytes.Buffer)
+	fw, err := NewWriter(ToWriter(bb), WriterSchema(`"long"`), BlockSize(2))
+	checkErrorFatal(t, err, nil)
+	for i := int64(0); i < 5; i++ {
+		fw.Write(i)
+	}
+	checkErrorFatal(t, fw.Close(), nil)
+
+	codec, err := NewCodec(`"long"`)
+	checkErrorFatal(t, err, nil)
+	fr, err := NewReader(FromReader(bytes.NewReader(bb.Bytes())), ReaderUseCodec(codec))
+	checkErrorFatal(t, err, nil)
+	for i := int64(0); i < 5; i++ {
+		datum, err := fr.Read()
+		checkErrorFatal(t, err, nil)
+		if datum != i {
+			t.Errorf("Actual: %#v; Expected: %#v", datum, i)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		_, err = fr.Read()
+		checkError(t, err, io.EOF)
+	}
+	checkError(t, fr.Close(), nil)
+}
+
+func TestReaderUseCodecMismatch(t *testing.T) {
+	codec, err := NewCodec(`"string"`)
+	checkErrorFatal(t, err, nil)
+	_, err = NewReader(FromReader(bytes.NewReader([]byte(nullCodecSample))), ReaderUseCodec(codec))
+	checkError(t, err, "codec schema does not match writer schema")
+}
+
+func TestReaderUseCodecCanonicalForm(t *testing.T) {
+	bb := bytes.NewBufferString(magicBytes)
+	header := map[string]interface{}{
+		"avro.schema": []byte(`{"type":"record","name":"r","namespace":"com.example","doc":"d","fields":[{"name":"a","type":"long"}]}`),
+	}
+	checkErrorFatal(t, metadataCodec.Encode(bb, header), nil)
+	bb.Write(make([]byte, syncLength))
+
+	codec, err := NewCodec(`{"fields":[{"type":{"type":"long"},"name":"a"}],"name":"com.example.r","type":"record"}`)
+	checkErrorFatal(t, err, nil)
+	fr, err := NewReader(FromReader(bytes.NewReader(bb.Bytes())), ReaderUseCodec(codec))
+	checkErrorFatal(t, err, nil)
+	checkError(t, fr.Close(), nil)
+}
+
+func TestReaderExposesWriterSchemaAndMetadata(t *testing.T) {
+	bb := bytes.NewBufferString(magicBytes)
+	header := map[string]interface{}{
+		"avro.schema": []byte(`"long"`),
+		"my.custom":   []byte("some value"),
+	}
+	checkErrorFatal(t, metadataCodec.Encode(bb, header), nil)
+	bb.Write(make([]byte, syncLength))
+
+	fr, err := NewReader(FromReader(bytes.NewReader(bb.Bytes())))
+	checkErrorFatal(t, err, nil)
+	defer fr.Close()
+	if actual, expected := fr.WriterSchema(), `"long"`; actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+	if actual, expected := fr.Codec().Schema(), `"long"`; actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+	if actual, expected := string(fr.Metadata["my.custom"]), "some value"; actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+	if actual, expected := len(fr.Metadata), 2; actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+}
+
+func TestFileReadBzip2Codec(t *testing.T) {
+	bb := bytes.NewBufferString(magicBytes)
+	header := map[string]interface{}{
+		"avro.codec":  []byte(CompressionBzip2),
+		"avro.schema": []byte(`"int"`),
+	}
+	checkErrorFatal(t, metadataCodec.Encode(bb, header), nil)
+	bb.Write(defaultSync)
+	// three ints, 13, 42 and 54, compressed with bzip2
+	block := []byte("\x42\x5a\x68\x39\x31\x41\x59\x26\x53\x59\xd5\xff\xfc\xbc\x00\x00\x00\x23\x00\x00\x10\x04\x00\x00\x04\x20\x00\x21\x98\x19\x84\x61\x77\x24\x53\x85\x09\x0d\x5f\xff\xcb\xc0")
+	bb.Write([]byte{6, byte(2 * len(block))})
+	bb.Write(block)
+	bb.Write(defaultSync)
+
+	fr, err := NewReader(FromReader(bytes.NewReader(bb.Bytes())))
+	checkErrorFatal(t, err, nil)
+	for _, expected := range []int32{13, 42, 54} {
+		datum, err := fr.Read()
+		checkErrorFatal(t, err, nil)
+		if datum != expected {
+			t.Errorf("Actual: %#v; Expected: %#v", datum, expected)
+		}
+	}
+	_, err = fr.Read()
+	checkError(t, err, io.EOF)
+	checkError(t, fr.Close(), nil)
+
+	_, err = NewWriter(ToWriter(new(bytes.Buffer)), WriterSchema(`"int"`), Compression(CompressionBzip2))
+	checkError(t, err, "codec unsupported for writing: bzip2")
+}
@@ -28,6 +28,7 @@ import (
 	"io"
 	"log"
 	"math/rand"
+	"strings"
 	"time"
 
 	"github.com/golang/snappy"
@@ -69,6 +70,12 @@ type WriterSetter func(*Writer) error
 // stream without waiting for the addition 7 items to complete the
 // BlockSize.
 //
+// In the Object Container Files format, each block starts with the
+// number of items it holds and its size in bytes after compression,
+// and ends with the sync marker of the file. Larger blocks compress
+// better and spend fewer bytes on those, at the cost of holding more
+// items in memory while writing and while reading.
+//
 // By default, BlockSize is set to DefaultWriterBlockSize.
 func BlockSize(blockSize int64) WriterSetter {
 	return func(fw *Writer) error {
@@ -129,6 +136,13 @@ func Compression(someCompressionCodec string) WriterSetter {
 // does not check that it has been set to something other than the
 // zero value. Usually you can elide the `Sync` call and allow it
 // to create a random byte sequence.
+//
+// In the Object Container Files format, the sync marker ends the
+// header of the file and every block, which lets readers find the
+// boundaries of blocks, and so split a file or skip a corrupt block.
+// As the header metadata is written in key order, a Writer given the
+// same sync marker, BlockSize, and data, and no BlockTick, writes the
+// same bytes every time, which is useful for golden file tests.
 func Sync(someSync []byte) WriterSetter {
 	return func(fw *Writer) error {
 		if syncLength != len(someSync) {
@@ -164,6 +178,26 @@ func UseCodec(codec Codec) WriterSetter {
 	}
 }
 
+// WriterMetadata is used to add custom key-value pairs to the header
+// metadata of a new instance, alongside avro.schema and avro.codec,
+// such as provenance information. A Reader makes them available in
+// its Metadata field. Keys starting with "avro." are reserved by the
+// specification and are rejected.
+func WriterMetadata(meta map[string][]byte) WriterSetter {
+	return func(fw *Writer) error {
+		if fw.metadata == nil {
+			fw.metadata = make(map[string][]byte, len(meta))
+		}
+		for k, v := range meta {
+			if strings.HasPrefix(k, "avro.") {
+				return fmt.Errorf("metadata key is reserved: %q", k)
+			}
+			fw.metadata[k] = v
+		}
+		return nil
+	}
+}
+
 // WriterSchema is used to set the Avro schema of a new instance. If a
 // codec has already been compiled for the schema, it is faster to use
 // the UseCodec method instead of WriterSchema.
@@ -184,6 +218,7 @@ type Writer struct {
 	buffered         bool
 	dataCodec        Codec
 	err              error
+	metadata         map[string][]byte
 	toBlock          chan interface{}
 	w                io.Writer
 	writerDone       chan struct{}
@@ -243,6 +278,9 @@ func NewWriter(setters ...WriterSetter) (*Writer, error) {
 	if !IsCompressionCodecSupported(fw.CompressionCodec) {
 		return nil, &ErrWriterInit{Message: fmt.Sprintf("unsupported codec: %s", fw.CompressionCodec)}
 	}
+	if fw.CompressionCodec == CompressionBzip2 {
+		return nil, &ErrWriterInit{Message: fmt.Sprintf("codec unsupported for writing: %s", fw.CompressionCodec)}
+	}
 	if fw.dataCodec == nil {
 		return nil, &ErrWriterInit{Message: "missing schema"}
 	}
@@ -298,6 +336,9 @@ func (fw *Writer) writeHeader() (err error) {
 	}
 	// header metadata
 	hm := make(map[string]interface{})
+	for k, v := range fw.metadata {
+		hm[k] = v
+	}
 	hm["avro.schema"] = []byte(fw.dataCodec.Schema())
 	if fw.CompressionCodec != CompressionNull {
 		hm["avro.codec"] = []byte(fw.CompressionCodec)
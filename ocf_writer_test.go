@@ -190,3 +190,61 @@ func TestWriteWithSnappyCodec(t *testing.T) {
 		t.Errorf("Actual: %q; Expected: %q", actual, option1)
 	}
 }
+
+func TestWriterDeterministicOutput(t *testing.T) {
+	write := func() []byte {
+		bb := new(bytes.Buffer)
+		fw, err := NewWriter(
+			BlockSize(2),
+			Compression(CompressionSnappy),
+			WriterSchema(`"int"`),
+			Sync(defaultSync),
+			ToWriter(bb))
+		checkErrorFatal(t, err, nil)
+		for _, datum := range []int32{13, 42, 54} {
+			fw.Write(datum)
+		}
+		checkErrorFatal(t, fw.Close(), nil)
+		return bb.Bytes()
+	}
+
+	expected := write()
+	header := []byte("Obj\x01\x04\x14avro.codec\x0csnappy\x16avro.schema\x0a\x22int\x22\x00" + string(defaultSync))
+	if !bytes.HasPrefix(expected, header) {
+		t.Errorf("Actual: %#v; Expected: %#v", expected, header)
+	}
+	for i := 0; i < 20; i++ {
+		if actual := write(); !bytes.Equal(actual, expected) {
+			t.Fatalf("Actual: %#v; Expected: %#v", actual, expected)
+		}
+	}
+}
+
+func TestWriterMetadata(t *testing.T) {
+	bb := new(bytes.Buffer)
+	fw, err := NewWriter(
+		WriterSchema(`"int"`),
+		WriterMetadata(map[string][]byte{"created.by": []byte("unit test")}),
+		ToWriter(bb))
+	checkErrorFatal(t, err, nil)
+	fw.Write(int32(13))
+	checkErrorFatal(t, fw.Close(), nil)
+
+	fr, err := NewReader(FromReader(bytes.NewReader(bb.Bytes())))
+	checkErrorFatal(t, err, nil)
+	defer fr.Close()
+	if actual, expected := string(fr.Metadata["created.by"]), "unit test"; actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+	datum, err := fr.Read()
+	checkErrorFatal(t, err, nil)
+	if expected := int32(13); datum != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", datum, expected)
+	}
+
+	_, err = NewWriter(
+		WriterSchema(`"int"`),
+		WriterMetadata(map[string][]byte{"avro.schema": []byte(`"string"`)}),
+		ToWriter(new(bytes.Buffer)))
+	checkError(t, err, "metadata key is reserved")
+}
@@ -10,7 +10,11 @@ type KeyVal struct {
 	Val interface{}
 }
 
-// Define an ordered map
+// OrderedMap is a map whose entries keep their order. Codecs created
+// with DecodeRecordsAsMaps decode each record as an OrderedMap of its
+// fields in the order the schema declares them, as are records nested
+// in other records, arrays, maps, and unions, so the order survives
+// exporting the data to formats where order matters, such as CSV.
 type OrderedMap []KeyVal
 
 // Implement the json.Marshaler interface
@@ -0,0 +1,176 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+
+package goavro
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// fieldPath returns the path of the named child of a record found at
+// the specified path.
+func fieldPath(path, fieldName string) string {
+	if path == "" {
+		return fieldName
+	}
+	return path + "." + fieldName
+}
+
+// itemPath returns the path of the indexed child of an array or map
+// found at the specified path.
+func itemPath(path string, index interface{}) string {
+	return fmt.Sprintf("%s[%v]", path, index)
+}
+
+// joinPath returns the path formed by appending the relative path of a
+// descendant to the path of its ancestor.
+func joinPath(path, descendant string) string {
+	if path == "" || descendant == "" || strings.HasPrefix(descendant, "[") {
+		return path + descendant
+	}
+	return path + "." + descendant
+}
+
+// isDataPath returns true when path has more than one segment.
+func isDataPath(path string) bool {
+	return strings.ContainsAny(path, ".[")
+}
+
+// dataPathSegment is a segment of a path to a datum: either the name
+// of a record field, or the index of an array item or map value.
+type dataPathSegment struct {
+	field   string
+	index   string
+	isIndex bool
+}
+
+// parseDataPath splits a path such as "items[2].sku" into its segments.
+func parseDataPath(path string) ([]dataPathSegment, error) {
+	var segments []dataPathSegment
+	rest := path
+	for rest != "" {
+		if rest[0] == '[' {
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated index in %q", path)
+			}
+			segments = append(segments, dataPathSegment{index: rest[1:end], isIndex: true})
+			rest = rest[end+1:]
+		} else {
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty field name in %q", path)
+			}
+			segments = append(segments, dataPathSegment{field: rest[:end]})
+			rest = rest[end:]
+		}
+		if strings.HasPrefix(rest, ".") {
+			if rest = rest[1:]; rest == "" {
+				return nil, fmt.Errorf("empty field name in %q", path)
+			}
+		}
+	}
+	return segments, nil
+}
+
+// selectDataPathSegment returns the child of datum selected by
+// segment.
+func selectDataPathSegment(datum interface{}, segment dataPathSegment, path string) (interface{}, error) {
+	if !segment.isIndex {
+		record, ok := datum.(*Record)
+		if datum == nil || (ok && record == nil) {
+			// the record holding the field is absent, such as a null
+			// member of a union
+			return nil, ErrNoSuchField{field: segment.field, path: path}
+		}
+		if !ok {
+			return nil, fmt.Errorf("cannot select %q from %T in %q", segment.field, datum, path)
+		}
+		child, err := record.get(segment.field)
+		if _, ok := err.(ErrNoSuchField); ok {
+			return nil, ErrNoSuchField{field: segment.field, path: path}
+		}
+		return child, err
+	}
+	switch v := datum.(type) {
+	case []interface{}:
+		index, err := strconv.Atoi(segment.index)
+		if err != nil || index < 0 || index >= len(v) {
+			return nil, fmt.Errorf("index out of range: [%s] of %d items in %q", segment.index, len(v), path)
+		}
+		return v[index], nil
+	case map[string]interface{}:
+		child, ok := v[segment.index]
+		if !ok {
+			return nil, fmt.Errorf("no such key: [%s] in %q", segment.index, path)
+		}
+		return child, nil
+	}
+	return nil, fmt.Errorf("cannot select [%s] from %T in %q", segment.index, datum, path)
+}
+
+// getDataPath returns the datum found at path within record.
+func getDataPath(record *Record, path string) (interface{}, error) {
+	segments, err := parseDataPath(path)
+	if err != nil {
+		return nil, err
+	}
+	var datum interface{} = record
+	for _, segment := range segments {
+		if datum, err = selectDataPathSegment(datum, segment, path); err != nil {
+			return nil, err
+		}
+	}
+	return datum, nil
+}
+
+// setDataPath replaces the datum found at path within record by value.
+func setDataPath(record *Record, path string, value interface{}) error {
+	segments, err := parseDataPath(path)
+	if err != nil {
+		return err
+	}
+	var parent interface{} = record
+	last := segments[len(segments)-1]
+	for _, segment := range segments[:len(segments)-1] {
+		if parent, err = selectDataPathSegment(parent, segment, path); err != nil {
+			return err
+		}
+	}
+	if _, err = selectDataPathSegment(parent, last, path); err != nil {
+		if _, ok := parent.(map[string]interface{}); !ok || !last.isIndex {
+			return err
+		}
+		// a map value may be added
+	}
+	switch v := parent.(type) {
+	case *Record:
+		return v.set(last.field, value)
+	case []interface{}:
+		index, _ := strconv.Atoi(last.index)
+		v[index] = value
+	case map[string]interface{}:
+		v[last.index] = value
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+package goavro
+
+import (
+	"io"
+)
+
+// The functions below encode and decode individual Avro primitive
+// values using the Avro binary encoding, without a schema. They are
+// the building blocks of the binary encoding of complex values, and
+// are useful to implement custom framing, such as a length or a
+// fingerprint preceding a datum. Errors are of type *ErrEncoder or
+// *ErrDecoder, like those of a Codec.
+//
+//   if err := goavro.WriteLong(w, int64(len(payload))); err != nil {
+//       return err
+//   }
+//   if err := goavro.WriteBytes(w, payload); err != nil {
+//       return err
+//   }
+
+// WriteBoolean writes the Avro binary encoding of a boolean to w.
+func WriteBoolean(w io.Writer, value bool) error {
+	return booleanEncoder(w, value)
+}
+
+// ReadBoolean reads the Avro binary encoding of a boolean from r.
+func ReadBoolean(r io.Reader) (bool, error) {
+	datum, err := booleanDecoder(r)
+	if err != nil {
+		return false, err
+	}
+	return datum.(bool), nil
+}
+
+// WriteInt writes the zig-zag variable length encoding of an Avro int
+// to w.
+func WriteInt(w io.Writer, value int32) error {
+	return intEncoder(w, value)
+}
+
+// ReadInt reads the zig-zag variable length encoding of an Avro int
+// from r.
+func ReadInt(r io.Reader) (int32, error) {
+	datum, err := intDecoder(r)
+	if err != nil {
+		return 0, err
+	}
+	return datum.(int32), nil
+}
+
+// WriteLong writes the zig-zag variable length encoding of an Avro
+// long to w.
+func WriteLong(w io.Writer, value int64) error {
+	return longEncoder(w, value)
+}
+
+// ReadLong reads the zig-zag variable length encoding of an Avro long
+// from r.
+func ReadLong(r io.Reader) (int64, error) {
+	datum, err := longDecoder(r)
+	if err != nil {
+		return 0, err
+	}
+	return datum.(int64), nil
+}
+
+// WriteFloat writes the little-endian IEEE-754 encoding of an Avro
+// float to w.
+func WriteFloat(w io.Writer, value float32) error {
+	return floatEncoder(w, value)
+}
+
+// ReadFloat reads the little-endian IEEE-754 encoding of an Avro
+// float from r. The four bytes of 1.0 are 00 00 80 3f; a producer
+// which writes them in big-endian order instead writes 3f 80 00 00,
+// which ReadFloat decodes as about 4.6e-41.
+func ReadFloat(r io.Reader) (float32, error) {
+	datum, err := floatDecoder(r)
+	if err != nil {
+		return 0, err
+	}
+	return datum.(float32), nil
+}
+
+// WriteDouble writes the little-endian IEEE-754 encoding of an Avro
+// double to w.
+func WriteDouble(w io.Writer, value float64) error {
+	return doubleEncoder(w, value)
+}
+
+// ReadDouble reads the little-endian IEEE-754 encoding of an Avro
+// double from r. The eight bytes of 1.0 are 00 00 00 00 00 00 f0 3f.
+func ReadDouble(r io.Reader) (float64, error) {
+	datum, err := doubleDecoder(r)
+	if err != nil {
+		return 0, err
+	}
+	return datum.(float64), nil
+}
+
+// WriteBytes writes value to w as Avro bytes: its length as a long,
+// followed by the bytes themselves.
+func WriteBytes(w io.Writer, value []byte) error {
+	return bytesEncoder(w, value)
+}
+
+// ReadBytes reads Avro bytes from r: a length as a long, followed by
+// that many bytes.
+func ReadBytes(r io.Reader) ([]byte, error) {
+	datum, err := bytesDecoder(r)
+	if err != nil {
+		return nil, err
+	}
+	return datum.([]byte), nil
+}
+
+// WriteString writes value to w as an Avro string: its length in
+// bytes as a long, followed by its UTF-8 bytes.
+func WriteString(w io.Writer, value string) error {
+	return stringEncoder(w, value)
+}
+
+// ReadString reads an Avro string from r: a length in bytes as a
+// long, followed by that many bytes of UTF-8.
+func ReadString(r io.Reader) (string, error) {
+	datum, err := stringDecoder(r)
+	if err != nil {
+		return "", err
+	}
+	return datum.(string), nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+package goavro
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestPrimitivesRoundTrip(t *testing.T) {
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, WriteBoolean(bb, true), nil)
+	checkErrorFatal(t, WriteInt(bb, -3), nil)
+	checkErrorFatal(t, WriteLong(bb, 1<<40), nil)
+	checkErrorFatal(t, WriteFloat(bb, 3.5), nil)
+	checkErrorFatal(t, WriteDouble(bb, -3.5), nil)
+	checkErrorFatal(t, WriteBytes(bb, []byte("\x00\x01")), nil)
+	checkErrorFatal(t, WriteString(bb, "hello"), nil)
+
+	b, err := ReadBoolean(bb)
+	checkErrorFatal(t, err, nil)
+	if b != true {
+		t.Errorf("Actual: %#v; Expected: %#v", b, true)
+	}
+	i, err := ReadInt(bb)
+	checkErrorFatal(t, err, nil)
+	if i != -3 {
+		t.Errorf("Actual: %#v; Expected: %#v", i, -3)
+	}
+	l, err := ReadLong(bb)
+	checkErrorFatal(t, err, nil)
+	if l != 1<<40 {
+		t.Errorf("Actual: %#v; Expected: %#v", l, int64(1<<40))
+	}
+	f, err := ReadFloat(bb)
+	checkErrorFatal(t, err, nil)
+	if f != 3.5 {
+		t.Errorf("Actual: %#v; Expected: %#v", f, 3.5)
+	}
+	d, err := ReadDouble(bb)
+	checkErrorFatal(t, err, nil)
+	if d != -3.5 {
+		t.Errorf("Actual: %#v; Expected: %#v", d, -3.5)
+	}
+	someBytes, err := ReadBytes(bb)
+	checkErrorFatal(t, err, nil)
+	if !bytes.Equal(someBytes, []byte("\x00\x01")) {
+		t.Errorf("Actual: %#v; Expected: %#v", someBytes, []byte("\x00\x01"))
+	}
+	s, err := ReadString(bb)
+	checkErrorFatal(t, err, nil)
+	if s != "hello" {
+		t.Errorf("Actual: %#v; Expected: %#v", s, "hello")
+	}
+
+	_, err = ReadLong(bb)
+	checkError(t, err, "cannot decode long: EOF")
+	_, err = ReadString(bytes.NewReader([]byte("\x01")))
+	checkError(t, err, "negative length: -1")
+}
+
+func TestPrimitivesEncoding(t *testing.T) {
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, WriteLong(bb, -65), nil)
+	checkErrorFatal(t, WriteString(bb, "a"), nil)
+	expected := []byte("\x81\x01\x02a")
+	if actual := bb.Bytes(); !bytes.Equal(actual, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+}
+
+func TestPrimitivesFloatByteOrder(t *testing.T) {
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, WriteFloat(bb, 1), nil)
+	checkErrorFatal(t, WriteDouble(bb, 1), nil)
+	expected := []byte("\x00\x00\x80\x3f\x00\x00\x00\x00\x00\x00\xf0\x3f")
+	if actual := bb.Bytes(); !bytes.Equal(actual, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+
+	f, err := ReadFloat(bytes.NewReader([]byte("\x3f\x80\x00\x00")))
+	checkErrorFatal(t, err, nil)
+	if expected := math.Float32frombits(0x0000803f); f != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", f, expected)
+	}
+	d, err := ReadDouble(bytes.NewReader([]byte("\x3f\xf0\x00\x00\x00\x00\x00\x00")))
+	checkErrorFatal(t, err, nil)
+	if expected := math.Float64frombits(0x000000000000f03f); d != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", d, expected)
+	}
+
+	_, err = ReadDouble(bytes.NewReader([]byte("\x00\x00\x80\x3f")))
+	checkError(t, err, "cannot decode double")
+}
+
+func TestPrimitivesBooleanCorrupt(t *testing.T) {
+	_, err := ReadBoolean(bytes.NewReader([]byte("\x02")))
+	checkError(t, err, "cannot decode boolean: expected 1 or 0; received: 2")
+
+	// fields skipped by a projection are checked as well
+	decoder, err := NewProjectionDecoder(`{"type":"record","name":"r","fields":[{"name":"a","type":"boolean"},{"name":"b","type":"int"}]}`, []string{"b"})
+	checkErrorFatal(t, err, nil)
+	_, err = decoder.Decode(bytes.NewReader([]byte("\x02\x02")))
+	checkError(t, err, "cannot decode record (r) at a: cannot decode boolean: expected 1 or 0; received: 2")
+}
@@ -0,0 +1,92 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+package goavro
+
+import (
+	"fmt"
+	"io"
+)
+
+// NewProjectionDecoder returns a Decoder of the record schema which
+// retains only the named fields of each record it decodes. Avro binary
+// data cannot be skipped without knowing its types, so every field is
+// still read in order, but the values of the other fields, including
+// the items of their arrays and maps, are discarded as they are read
+// rather than allocated. Each datum is an OrderedMap of the named
+// fields, in schema order.
+//
+//   decoder, err := goavro.NewProjectionDecoder(someRecordSchema, []string{"id", "ts"})
+//   datum, err := decoder.Decode(r)
+func NewProjectionDecoder(schema string, fields []string) (Decoder, error) {
+	c, err := NewCodec(schema)
+	if err != nil {
+		return nil, err
+	}
+	someCodec := c.(*codec)
+	record, ok := someCodec.schemaTree().(*RecordNode)
+	if !ok {
+		return nil, newCodecBuildError("projection", "expected: record; received: %s", someCodec.Kind())
+	}
+	friendlyName := fmt.Sprintf("record (%s)", record.Name)
+
+	retain := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if _, ok := someCodec.fc[field]; !ok {
+			return nil, newCodecBuildError("projection", ErrNoSuchField{field: field})
+		}
+		retain[field] = true
+	}
+	fieldCodecs := make([]*codec, len(record.Fields))
+	for idx, field := range record.Fields {
+		if retain[field.Name] {
+			fieldCodecs[idx] = someCodec.fc[field.Name]
+		}
+	}
+
+	return projectionDecoder{&codec{
+		nm: someCodec.nm,
+		df: func(r io.Reader) (interface{}, error) {
+			projected := make(OrderedMap, 0, len(retain))
+			for idx, field := range record.Fields {
+				if fieldCodecs[idx] == nil {
+					if err := skipNode(field.Type, r); err != nil {
+						return nil, newDecoderPathError(friendlyName, field.Name, err)
+					}
+					continue
+				}
+				value, err := fieldCodecs[idx].decode(r, nil)
+				if err != nil {
+					return nil, newDecoderPathError(friendlyName, field.Name, err)
+				}
+				projected = append(projected, KeyVal{field.Name, value})
+			}
+			return projected, nil
+		},
+	}}, nil
+}
+
+// projectionDecoder is the Decoder NewProjectionDecoder returns, which
+// decodes with a codec lacking an encoder.
+type projectionDecoder struct {
+	c *codec
+}
+
+// Decode reads the next record from r, and returns the projection of it.
+func (pd projectionDecoder) Decode(r io.Reader) (interface{}, error) {
+	return pd.c.Decode(r)
+}
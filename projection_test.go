@@ -0,0 +1,75 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+package goavro
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestNewProjectionDecoder(t *testing.T) {
+	schema := `{"type":"record","name":"wide","fields":[
+{"name":"id","type":"long"},
+{"name":"tags","type":{"type":"array","items":"string"}},
+{"name":"attrs","type":{"type":"map","values":"bytes"}},
+{"name":"hash","type":{"type":"fixed","name":"md5","size":4}},
+{"name":"nested","type":{"type":"record","name":"inner","fields":[{"name":"s","type":"string"}]}},
+{"name":"maybe","type":["null","string"]},
+{"name":"ts","type":"long"}]}`
+	codec, err := NewCodec(schema)
+	checkErrorFatal(t, err, nil)
+	record, err := NewRecord(RecordSchema(schema))
+	checkErrorFatal(t, err, nil)
+	inner, err := NewRecord(RecordSchema(`{"type":"record","name":"inner","fields":[{"name":"s","type":"string"}]}`))
+	checkErrorFatal(t, err, nil)
+	checkErrorFatal(t, inner.Set("s", "deep"), nil)
+	checkErrorFatal(t, record.Set("id", int64(7)), nil)
+	checkErrorFatal(t, record.Set("tags", []interface{}{"a", "b"}), nil)
+	checkErrorFatal(t, record.Set("attrs", map[string]interface{}{"k": []byte("v")}), nil)
+	checkErrorFatal(t, record.Set("hash", Fixed{Name: "md5", Value: []byte("abcd")}), nil)
+	checkErrorFatal(t, record.Set("nested", inner), nil)
+	checkErrorFatal(t, record.Set("maybe", "x"), nil)
+	checkErrorFatal(t, record.Set("ts", int64(42)), nil)
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, codec.Encode(bb, record), nil)
+	checkErrorFatal(t, codec.Encode(bb, record), nil)
+
+	decoder, err := NewProjectionDecoder(schema, []string{"ts", "id"})
+	checkErrorFatal(t, err, nil)
+	for i := 0; i < 2; i++ {
+		datum, err := decoder.Decode(bb)
+		checkErrorFatal(t, err, nil)
+		expected := OrderedMap{{"id", int64(7)}, {"ts", int64(42)}}
+		if actual, ok := datum.(OrderedMap); !ok || len(actual) != len(expected) || actual[0] != expected[0] || actual[1] != expected[1] {
+			t.Errorf("Actual: %#v; Expected: %#v", datum, expected)
+		}
+	}
+	_, err = decoder.Decode(bb)
+	if err != io.EOF {
+		t.Errorf("Actual: %#v; Expected: %#v", err, io.EOF)
+	}
+
+	_, err = decoder.Decode(bytes.NewReader([]byte("\x0e\x02\x02")))
+	checkError(t, err, "cannot decode record (wide) at tags")
+
+	_, err = NewProjectionDecoder(schema, []string{"missing"})
+	checkError(t, err, `no such field: "missing"`)
+	_, err = NewProjectionDecoder(`"long"`, []string{"id"})
+	checkError(t, err, "expected: record; received: long")
+}
@@ -0,0 +1,205 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+package goavro
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Protocol describes an Avro protocol, as read from a .avpr document:
+// the named types it declares, and the messages that may be exchanged.
+type Protocol struct {
+	Name      string // full name of the protocol
+	Namespace string
+	Doc       string
+	Types     map[string]Codec // codecs of the declared types, by full name
+	Messages  map[string]*Message
+}
+
+// Message describes one message of a Protocol. Request encodes the
+// message parameters as a record with one field per parameter, in
+// order, or, for a message without parameters, as null, which encodes
+// to no bytes either. Errors encodes the union of "string", for
+// undeclared errors, and each declared error type.
+type Message struct {
+	Name     string
+	Doc      string
+	OneWay   bool
+	Request  Codec
+	Response Codec
+	Errors   Codec
+}
+
+// ParseProtocol parses the JSON of an Avro protocol, and returns a
+// Protocol with codecs for its types and messages. Type references in
+// messages and in the declared types resolve against the protocol
+// namespace, and against any types declared by the protocol.
+//
+//   protocol, err := goavro.ParseProtocol(avpr)
+//   if err != nil {
+//       return err
+//   }
+//   message := protocol.Messages["hello"]
+//   bb := new(bytes.Buffer)
+//   err = message.Request.Encode(bb, map[string]interface{}{"greeting": "hi"})
+func ParseProtocol(someJSONProtocol string) (*Protocol, error) {
+	var blob map[string]interface{}
+	if err := json.Unmarshal([]byte(someJSONProtocol), &blob); err != nil {
+		return nil, &ErrSchemaParse{"cannot unmarshal JSON", err}
+	}
+	protocolName, ok := blob["protocol"].(string)
+	if !ok || protocolName == "" {
+		return nil, &ErrSchemaParse{"protocol ought to have non-empty protocol name", nil}
+	}
+	namespace, _ := blob["namespace"].(string)
+	nm, err := newName(nameName(protocolName), nameNamespace(namespace))
+	if err != nil {
+		return nil, &ErrSchemaParse{"invalid protocol name", err}
+	}
+	protocol := &Protocol{
+		Name:      nm.n,
+		Namespace: nm.namespace(),
+		Types:     make(map[string]Codec),
+		Messages:  make(map[string]*Message),
+	}
+	protocol.Doc, _ = blob["doc"].(string)
+
+	var types []interface{}
+	if blobTypes, ok := blob["types"]; ok {
+		if types, ok = blobTypes.([]interface{}); !ok {
+			return nil, &ErrSchemaParse{"protocol types ought to be array", nil}
+		}
+	}
+	// declared types without a namespace of their own are in the
+	// namespace of the protocol
+	others := make([]interface{}, len(types))
+	typeNames := make([]string, len(types))
+	for idx, someType := range types {
+		typeMap, ok := someType.(map[string]interface{})
+		if !ok {
+			return nil, &ErrSchemaParse{"protocol types ought to be named type definitions", nil}
+		}
+		typeNm, err := newName(nameSchema(typeMap), nameEnclosingNamespace(protocol.Namespace))
+		if err != nil {
+			return nil, &ErrSchemaParse{"invalid protocol type", err}
+		}
+		other := make(map[string]interface{}, len(typeMap))
+		for k, v := range typeMap {
+			other[k] = v
+		}
+		other["name"] = typeNm.n
+		delete(other, "namespace")
+		others[idx] = other
+		typeNames[idx] = typeNm.n
+	}
+	for _, typeName := range typeNames {
+		typeCodec, err := newCodecWithTypes(nullNamespace, typeName, others)
+		if err != nil {
+			return nil, &ErrSchemaParse{"cannot build protocol types", err}
+		}
+		protocol.Types[typeName] = typeCodec
+	}
+
+	var messages map[string]interface{}
+	if blobMessages, ok := blob["messages"]; ok {
+		if messages, ok = blobMessages.(map[string]interface{}); !ok {
+			return nil, &ErrSchemaParse{"protocol messages ought to be object", nil}
+		}
+	}
+	messageNames := make([]string, 0, len(messages))
+	for messageName := range messages {
+		messageNames = append(messageNames, messageName)
+	}
+	sort.Strings(messageNames)
+	for _, messageName := range messageNames {
+		message, err := protocol.buildMessage(messageName, messages[messageName], others)
+		if err != nil {
+			return nil, &ErrSchemaParse{fmt.Sprintf("cannot build protocol message: %s", messageName), err}
+		}
+		protocol.Messages[messageName] = message
+	}
+	return protocol, nil
+}
+
+func (protocol *Protocol) buildMessage(messageName string, someMessage interface{}, others []interface{}) (*Message, error) {
+	blob, ok := someMessage.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("message ought to be object: %T", someMessage)
+	}
+	message := &Message{Name: messageName}
+	message.Doc, _ = blob["doc"].(string)
+	if oneWay, ok := blob["one-way"]; ok {
+		if message.OneWay, ok = oneWay.(bool); !ok {
+			return nil, fmt.Errorf("one-way ought to be boolean: %T", oneWay)
+		}
+	}
+
+	request, ok := blob["request"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("request ought to be array of parameters")
+	}
+	// the parameters are encoded like the fields of a record, named
+	// after the message, in which parameter types resolve against the
+	// protocol namespace
+	requestNm, err := newName(nameName(messageName), nameNamespace(protocol.Namespace))
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := protocol.Types[requestNm.n]; ok {
+		return nil, fmt.Errorf("message name ought not to be the name of a protocol type: %s", requestNm.n)
+	}
+	var requestSchema interface{} = map[string]interface{}{
+		"type":   "record",
+		"name":   requestNm.n,
+		"fields": request,
+	}
+	if len(request) == 0 {
+		// a record needs fields, but null encodes the same empty
+		// sequence of bytes
+		requestSchema = "null"
+	}
+	if message.Request, err = newCodecWithTypes(protocol.Namespace, requestSchema, others); err != nil {
+		return nil, err
+	}
+
+	response, ok := blob["response"]
+	if !ok {
+		return nil, fmt.Errorf("message ought to have response")
+	}
+	if message.Response, err = newCodecWithTypes(protocol.Namespace, response, others); err != nil {
+		return nil, err
+	}
+
+	errorsSchema := []interface{}{"string"}
+	if someErrors, ok := blob["errors"]; ok {
+		declared, ok := someErrors.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("errors ought to be array")
+		}
+		errorsSchema = append(errorsSchema, declared...)
+	}
+	if message.OneWay && (response != "null" || len(errorsSchema) > 1) {
+		return nil, fmt.Errorf("one-way message ought to have null response and no errors")
+	}
+	if message.Errors, err = newCodecWithTypes(protocol.Namespace, errorsSchema, others); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
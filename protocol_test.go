@@ -0,0 +1,149 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+package goavro
+
+import (
+	"bytes"
+	"testing"
+)
+
+const testProtocol = `{
+  "protocol": "HelloWorld",
+  "namespace": "com.acme",
+  "doc": "Protocol Greetings",
+  "types": [
+    {"name": "Greeting", "type": "record", "fields": [{"name": "message", "type": "string"}, {"name": "tone", "type": "Tone"}]},
+    {"name": "Tone", "type": "enum", "symbols": ["WARM", "COLD"]},
+    {"name": "Curse", "namespace": "org.other", "type": "record", "fields": [{"name": "message", "type": "string"}]}
+  ],
+  "messages": {
+    "hello": {
+      "doc": "Say hello.",
+      "request": [{"name": "greeting", "type": "Greeting"}, {"name": "times", "type": "int"}],
+      "response": "Greeting",
+      "errors": ["org.other.Curse"]
+    },
+    "ping": {"request": [], "response": "null", "one-way": true}
+  }
+}`
+
+func TestParseProtocol(t *testing.T) {
+	protocol, err := ParseProtocol(testProtocol)
+	checkErrorFatal(t, err, nil)
+	if protocol.Name != "com.acme.HelloWorld" || protocol.Namespace != "com.acme" || protocol.Doc != "Protocol Greetings" {
+		t.Errorf("Actual: %#v; Expected: %#v", protocol, "com.acme.HelloWorld")
+	}
+	for _, typeName := range []string{"com.acme.Greeting", "com.acme.Tone", "org.other.Curse"} {
+		if _, ok := protocol.Types[typeName]; !ok {
+			t.Errorf("Actual: %#v; Expected: %#v", protocol.Types, typeName)
+		}
+	}
+
+	hello := protocol.Messages["hello"]
+	if hello == nil || hello.Doc != "Say hello." || hello.OneWay {
+		t.Fatalf("Actual: %#v; Expected: %#v", hello, "hello")
+	}
+	greeting := map[string]interface{}{"message": "hi", "tone": Enum{Name: "com.acme.Tone", Value: "COLD"}}
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, hello.Request.Encode(bb, map[string]interface{}{"greeting": greeting, "times": int32(2)}), nil)
+	expected := []byte("\x04hi\x02\x04")
+	if actual := bb.Bytes(); !bytes.Equal(actual, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+
+	bb.Reset()
+	checkErrorFatal(t, hello.Response.Encode(bb, greeting), nil)
+	expected = []byte("\x04hi\x02")
+	if actual := bb.Bytes(); !bytes.Equal(actual, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+	// the response codec stands on its own
+	_, err = NewCodec(hello.Response.Schema())
+	checkErrorFatal(t, err, nil)
+
+	datum, err := hello.Errors.Decode(bytes.NewReader([]byte("\x02\x06bad")))
+	checkErrorFatal(t, err, nil)
+	if curse, ok := datum.(*Record); !ok || curse.Name != "org.other.Curse" {
+		t.Errorf("Actual: %#v; Expected: %#v", datum, "org.other.Curse")
+	}
+	datum, err = hello.Errors.Decode(bytes.NewReader([]byte("\x00\x06bad")))
+	checkErrorFatal(t, err, nil)
+	if datum != "bad" {
+		t.Errorf("Actual: %#v; Expected: %#v", datum, "bad")
+	}
+
+	ping := protocol.Messages["ping"]
+	if ping == nil || !ping.OneWay {
+		t.Errorf("Actual: %#v; Expected: %#v", ping, "one-way ping")
+	}
+}
+
+func TestParseProtocolErrors(t *testing.T) {
+	_, err := ParseProtocol(`{"namespace":"a"}`)
+	checkError(t, err, "protocol ought to have non-empty protocol name")
+	_, err = ParseProtocol(`{"protocol":"P","messages":{"m":{"request":[{"name":"a","type":"Missing"}],"response":"null"}}}`)
+	checkError(t, err, "cannot build protocol message: m")
+	_, err = ParseProtocol(`{"protocol":"P","messages":{"m":{"request":[],"response":"int","one-way":true}}}`)
+	checkError(t, err, "one-way message ought to have null response and no errors")
+	_, err = ParseProtocol(`{"protocol":"P","types":[{"type":"fixed","name":"m","size":1}],"messages":{"m":{"request":[],"response":"null"}}}`)
+	checkError(t, err, "message name ought not to be the name of a protocol type: m")
+}
+
+func TestParseProtocolErrorType(t *testing.T) {
+	curse := `{"type":"error","name":"Curse","namespace":"org.other","fields":[{"name":"message","type":"string"}]}`
+	codec, err := NewCodec(curse)
+	checkErrorFatal(t, err, nil)
+	if actual, expected := codec.(SchemaInspector).Kind(), "record"; actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, codec.Encode(bb, map[string]interface{}{"message": "bad"}), nil)
+	datum, err := codec.Decode(bb)
+	checkErrorFatal(t, err, nil)
+	if record, ok := datum.(*Record); !ok || record.Name != "org.other.Curse" {
+		t.Errorf("Actual: %#v; Expected: %#v", datum, "org.other.Curse")
+	}
+
+	protocol, err := ParseProtocol(`{
+  "protocol": "HelloWorld",
+  "namespace": "com.acme",
+  "types": [` + curse + `],
+  "messages": {
+    "hello": {"request": [{"name": "greeting", "type": "string"}], "response": "string", "errors": ["org.other.Curse"]}
+  }
+}`)
+	checkErrorFatal(t, err, nil)
+	if _, ok := protocol.Types["org.other.Curse"]; !ok {
+		t.Errorf("Actual: %#v; Expected: %#v", protocol.Types, "org.other.Curse")
+	}
+	errors := protocol.Messages["hello"].Errors
+	bb.Reset()
+	checkErrorFatal(t, errors.Encode(bb, Union("org.other.Curse", map[string]interface{}{"message": "bad"})), nil)
+	expected := []byte("\x02\x06bad")
+	if actual := bb.Bytes(); !bytes.Equal(actual, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+	datum, err = errors.Decode(bb)
+	checkErrorFatal(t, err, nil)
+	if record, ok := datum.(*Record); !ok || record.Name != "org.other.Curse" {
+		t.Errorf("Actual: %#v; Expected: %#v", datum, "org.other.Curse")
+	}
+	// the errors codec stands on its own
+	_, err = NewCodec(errors.Schema())
+	checkErrorFatal(t, err, nil)
+}
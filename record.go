@@ -19,8 +19,11 @@
 package goavro
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 )
 
@@ -37,6 +40,14 @@ func (e ErrNoSuchField) Error() string {
 	return fmt.Sprintf("no such field: %q", e.field)
 }
 
+// Is returns whether target is an ErrNoSuchField for the same field, or
+// the zero ErrNoSuchField, so that errors.Is(err, ErrNoSuchField{})
+// recognizes a missing field wherever along a path it is missing.
+func (e ErrNoSuchField) Is(target error) bool {
+	t, ok := target.(ErrNoSuchField)
+	return ok && (t.field == "" || t.field == e.field)
+}
+
 // Record is an abstract data type used to hold data corresponding to
 // an Avro record. Wherever an Avro schema specifies a record, this
 // library's Decode method will return a Record initialized to the
@@ -78,10 +89,22 @@ func (r Record) GetQualified(qualifiedName string) (interface{}, error) {
 	return field.Datum, nil
 }
 
-// Get returns the datum of the specified Record field.
+// Get returns the datum of the specified Record field. When no field
+// has the specified name, it may instead be a path to a datum nested
+// within the Record, such as "address.zip" or "items[2].sku", in which
+// each segment names a field of a record, or indexes an array or map,
+// as in the paths reported by decoding errors.
 func (r Record) Get(fieldName string) (interface{}, error) {
+	datum, err := r.get(fieldName)
+	if err != nil && isDataPath(fieldName) {
+		return getDataPath(&r, fieldName)
+	}
+	return datum, err
+}
+
+func (r Record) get(fieldName string) (interface{}, error) {
 	// qualify fieldName searches based on record namespace
-	fn, err := newName(nameName(fieldName), nameNamespace(r.n.ns))
+	fn, err := newName(nameName(fieldName), nameNamespace(r.n.namespace()))
 	if err != nil {
 		return nil, err
 	}
@@ -91,7 +114,7 @@ func (r Record) Get(fieldName string) (interface{}, error) {
 // GetFieldSchema returns the schema of the specified Record field.
 func (r Record) GetFieldSchema(fieldName string) (interface{}, error) {
 	// qualify fieldName searches based on record namespace
-	fn, err := newName(nameName(fieldName), nameNamespace(r.n.ns))
+	fn, err := newName(nameName(fieldName), nameNamespace(r.n.namespace()))
 	if err != nil {
 		return nil, err
 	}
@@ -112,10 +135,21 @@ func (r Record) SetQualified(qualifiedName string, value interface{}) error {
 	return nil
 }
 
-// Set updates the datum of the specified Record field.
+// Set updates the datum of the specified Record field. As with Get,
+// fieldName may instead be a path to a datum nested within the Record,
+// such as "address.zip" or "items[2].sku"; the records, arrays and maps
+// along the path ought to exist already.
 func (r Record) Set(fieldName string, value interface{}) error {
+	err := r.set(fieldName, value)
+	if err != nil && isDataPath(fieldName) {
+		return setDataPath(&r, fieldName, value)
+	}
+	return err
+}
+
+func (r Record) set(fieldName string, value interface{}) error {
 	// qualify fieldName searches based on record namespace
-	fn, err := newName(nameName(fieldName), nameNamespace(r.n.ns))
+	fn, err := newName(nameName(fieldName), nameNamespace(r.n.namespace()))
 	if err != nil {
 		return err
 	}
@@ -131,6 +165,82 @@ func (r Record) String() string {
 	return fmt.Sprintf("{%s: [%v]}", r.Name, strings.Join(fields, ", "))
 }
 
+// Clone returns a copy of the Record whose field values are deep
+// copies of those of r, so either may be modified without affecting
+// the other. Nested records, arrays, maps, bytes and fixed values are
+// copied; the schema is shared, as it is never modified.
+func (r *Record) Clone() *Record {
+	clone := *r
+	clone.Fields = make([]*recordField, len(r.Fields))
+	for idx, field := range r.Fields {
+		fieldClone := *field
+		fieldClone.Datum = cloneDatum(field.Datum)
+		clone.Fields[idx] = &fieldClone
+	}
+	return &clone
+}
+
+// Reset clears the value of every field of the Record, so that it may
+// be filled anew, as by Set, with the defaults of fields left unset
+// applying when it is encoded. The fields themselves are kept. There is
+// no need to Reset a Record between calls to DecodeReuse, which
+// overwrites every field, and which reuses the storage of the previous
+// field values only when they have not been Reset.
+func (r *Record) Reset() {
+	for _, field := range r.Fields {
+		field.Datum = nil
+	}
+}
+
+// cloneDatum returns a deep copy of a datum.
+func cloneDatum(datum interface{}) interface{} {
+	switch v := datum.(type) {
+	case *Record:
+		if v == nil {
+			return v
+		}
+		return v.Clone()
+	case []interface{}:
+		if v == nil {
+			return v
+		}
+		items := make([]interface{}, len(v))
+		for idx, item := range v {
+			items[idx] = cloneDatum(item)
+		}
+		return items
+	case map[string]interface{}:
+		if v == nil {
+			return v
+		}
+		values := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			values[key] = cloneDatum(value)
+		}
+		return values
+	case OrderedMap:
+		if v == nil {
+			return v
+		}
+		fields := make(OrderedMap, len(v))
+		for idx, kv := range v {
+			fields[idx] = KeyVal{kv.Key, cloneDatum(kv.Val)}
+		}
+		return fields
+	case []byte:
+		if v == nil {
+			return v
+		}
+		return append([]byte{}, v...)
+	case Fixed:
+		return Fixed{Name: v.Name, Value: cloneDatum(v.Value).([]byte)}
+	case UnionValue:
+		v.Value = cloneDatum(v.Value)
+		return v
+	}
+	return datum
+}
+
 // NewRecord will create a Record instance corresponding to the
 // specified schema.
 //
@@ -197,7 +307,7 @@ func NewRecord(setters ...RecordSetter) (*Record, error) {
 		}
 	}
 	if val, ok = record.schemaMap["aliases"]; ok {
-		record.aliases, ok = val.([]string)
+		record.aliases, ok = stringsFromArray(val)
 		if !ok {
 			return nil, newCodecBuildError("record", "aliases ought to be array of strings")
 		}
@@ -206,6 +316,169 @@ func NewRecord(setters ...RecordSetter) (*Record, error) {
 	return record, nil
 }
 
+// shallowCopy returns a copy of the record with copies of its fields,
+// which share their schemas and data with those of the record. Unlike
+// Clone, it does not copy the data, as it is used to fill records from
+// blank ones.
+func (r *Record) shallowCopy() *Record {
+	someRecord := *r
+	fields := make([]recordField, len(r.Fields))
+	someRecord.Fields = make([]*recordField, len(r.Fields))
+	for idx, field := range r.Fields {
+		fields[idx] = *field
+		someRecord.Fields[idx] = &fields[idx]
+	}
+	return &someRecord
+}
+
+// recordFromDatum returns datum as a record, when it is a *Record, or
+// a map[string]interface{} or an OrderedMap of field names to field
+// data, such as codecs created with DecodeRecordsAsMaps decode records
+// into, from which it fills a clone of the blank record of the codec.
+// It returns false for any other datum.
+func recordFromDatum(blank *Record, keys []string, datum interface{}, ignoreUnknown bool) (*Record, bool, error) {
+	switch v := datum.(type) {
+	case *Record:
+		return v, true, nil
+	case map[string]interface{}:
+		someRecord, err := recordFromMap(blank, keys, v, ignoreUnknown)
+		return someRecord, true, err
+	case OrderedMap:
+		someRecord, err := recordFromOrderedMap(blank, v, ignoreUnknown)
+		return someRecord, true, err
+	}
+	return nil, false, nil
+}
+
+// recordMapKeys returns the names of the fields of the blank record,
+// in order, by which the fields are looked up in maps of field data.
+func recordMapKeys(blank *Record) []string {
+	keys := make([]string, len(blank.Fields))
+	for idx, field := range blank.Fields {
+		keys[idx] = name{n: field.Name}.basename()
+	}
+	return keys
+}
+
+// recordFromMap returns a clone of the blank record, with field data
+// taken from the map keyed by field name, where keys are the names of
+// the fields of the blank record, as returned by recordMapKeys. Fields
+// absent from the map have no data, so their defaults apply when the
+// record is encoded. Keys naming no field of the record are an error,
+// unless ignoreUnknown is set, when they are skipped.
+func recordFromMap(blank *Record, keys []string, dict map[string]interface{}, ignoreUnknown bool) (*Record, error) {
+	someRecord := blank.shallowCopy()
+	var found int
+	for idx, key := range keys {
+		if datum, ok := dict[key]; ok {
+			someRecord.Fields[idx].Datum = datum
+			found++
+		}
+	}
+	if found == len(dict) {
+		return someRecord, nil
+	}
+	// the remaining keys are fullnames of fields, or name no field;
+	// visit them in order, so the same unknown key is always reported
+	var others []string
+nextKey:
+	for k := range dict {
+		for _, key := range keys {
+			if k == key {
+				continue nextKey
+			}
+		}
+		others = append(others, k)
+	}
+	sort.Strings(others)
+	for _, k := range others {
+		if err := someRecord.setFromMap(k, dict[k], ignoreUnknown); err != nil {
+			return nil, err
+		}
+	}
+	return someRecord, nil
+}
+
+// recordFromOrderedMap is like recordFromMap, but takes field data
+// from an OrderedMap.
+func recordFromOrderedMap(blank *Record, omap OrderedMap, ignoreUnknown bool) (*Record, error) {
+	someRecord := blank.shallowCopy()
+	for _, kv := range omap {
+		if err := someRecord.setFromMap(kv.Key, kv.Val, ignoreUnknown); err != nil {
+			return nil, err
+		}
+	}
+	return someRecord, nil
+}
+
+// setFromMap sets the datum of the named field, skipping names of no
+// field when ignoreUnknown is set.
+func (r *Record) setFromMap(fieldName string, datum interface{}, ignoreUnknown bool) error {
+	field, err := r.getField(fieldName)
+	if err != nil {
+		if ignoreUnknown {
+			return nil
+		}
+		return err
+	}
+	field.Datum = datum
+	return nil
+}
+
+// recordMemberForKeys returns the index of the member of a union which
+// ought to encode datum, a map or OrderedMap, when the union has no map
+// member. Only record members with a field named by each key of datum
+// may encode it; when there are several, the first that probe accepts
+// is chosen, so the cost of probing is paid only when the keys alone
+// cannot tell the members apart.
+func recordMemberForKeys(members []*codec, datum interface{}, ignoreUnknown bool, probe func(int) bool) (int, bool) {
+	candidate, count := -1, 0
+	for idx, c := range members {
+		if recordHasKeys(c, datum, ignoreUnknown) {
+			if count == 0 {
+				candidate = idx
+			}
+			count++
+		}
+	}
+	if count < 2 {
+		return candidate, count == 1
+	}
+	for idx, c := range members {
+		if recordHasKeys(c, datum, ignoreUnknown) && probe(idx) {
+			return idx, true
+		}
+	}
+	return -1, false
+}
+
+// recordHasKeys returns whether c is a record codec with a field named
+// by each key of datum, a map or OrderedMap, or any record codec when
+// ignoreUnknown is set.
+func recordHasKeys(c *codec, datum interface{}, ignoreUnknown bool) bool {
+	if c.fc == nil {
+		return false
+	}
+	if ignoreUnknown {
+		return true
+	}
+	switch v := datum.(type) {
+	case map[string]interface{}:
+		for key := range v {
+			if _, ok := c.fc[name{n: key}.basename()]; !ok {
+				return false
+			}
+		}
+	case OrderedMap:
+		for _, kv := range v {
+			if _, ok := c.fc[name{n: kv.Key}.basename()]; !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // RecordSetter functions are those those which are used to
 // instantiate a new Record.
 type RecordSetter func(*Record) error
@@ -301,6 +574,11 @@ func newRecordField(schema interface{}, setters ...recordFieldSetter) (*recordFi
 		}
 	}
 
+	if fieldName, ok := schemaMap["name"].(string); ok && fieldName != "" {
+		if err := checkName(fieldName); err != nil {
+			return nil, newCodecBuildError("record field", &ErrSchemaParse{"invalid field name", err})
+		}
+	}
 	n, err := newName(nameSchema(schemaMap), nameEnclosingNamespace(rf.ens))
 	if err != nil {
 		return nil, newCodecBuildError("record field", err)
@@ -333,35 +611,66 @@ func newRecordField(schema interface{}, setters ...recordFieldSetter) (*recordFi
 	if ok {
 		rf.hasDefault = true
 		switch typeName.(type) {
+		case []interface{}:
+			// the default of a union is a value of its first member
+			dv, err := nativeDefault(nil, rf.ens, typeName, val)
+			if err != nil {
+				return nil, newCodecBuildError("record field", &ErrSchemaParse{fmt.Sprintf("union default ought to match first member: %s", rf.Name), err})
+			}
+			rf.defval = dv
 		case string:
 			switch typeName {
 			case "int":
-				dv, ok := val.(float64)
-				if !ok {
+				dv, ok := integralDefault(val)
+				if !ok || dv < math.MinInt32 || dv > math.MaxInt32 {
 					return nil, newCodecBuildError("record field", "default value type mismatch: %s; expected: %s; received: %T", rf.Name, "int32", val)
 				}
 				rf.defval = int32(dv)
 			case "long":
-				dv, ok := val.(float64)
+				dv, ok := integralDefault(val)
 				if !ok {
 					return nil, newCodecBuildError("record field", "default value type mismatch: %s; expected: %s; received: %T", rf.Name, "int64", val)
 				}
-				rf.defval = int64(dv)
+				rf.defval = dv
 			case "float":
-				dv, ok := val.(float64)
+				dv, ok := floatingDefault(val)
 				if !ok {
 					return nil, newCodecBuildError("record field", "default value type mismatch: %s; expected: %s; received: %T", rf.Name, "float32", val)
 				}
 				rf.defval = float32(dv)
+			case "double":
+				dv, ok := floatingDefault(val)
+				if !ok {
+					return nil, newCodecBuildError("record field", "default value type mismatch: %s; expected: %s; received: %T", rf.Name, "float64", val)
+				}
+				rf.defval = dv
 			case "bytes":
-				dv, ok := val.(string)
+				dv, ok := bytesDefault(val)
 				if !ok {
-					return nil, newCodecBuildError("record field", "default value type mismatch: %s; expected: %s; received: %T", rf.Name, "string", val)
+					return nil, newCodecBuildError("record field", "default value type mismatch: %s; expected: %s; received: %T", rf.Name, "string of code points 0-255", val)
 				}
-				rf.defval = []byte(dv)
+				rf.defval = dv
 			default:
 				rf.defval = val
 			}
+		case map[string]interface{}:
+			fieldType := typeName.(map[string]interface{})
+			if fieldType["type"] != "fixed" {
+				rf.defval = val
+				break
+			}
+			fn, err := newName(nameSchema(fieldType), nameEnclosingNamespace(rf.ens))
+			if err != nil {
+				return nil, newCodecBuildError("record field", err)
+			}
+			dv, ok := bytesDefault(val)
+			if !ok {
+				return nil, newCodecBuildError("record field", "default value type mismatch: %s; expected: %s; received: %T", rf.Name, "string of code points 0-255", val)
+			}
+			if size, ok := fieldType["size"].(float64); ok && int(size) != len(dv) {
+				return nil, newCodecBuildError("record field", "default value size mismatch: %s; expected: %d bytes; received: %d", rf.Name, int(size), len(dv))
+			}
+			rf.defval = Fixed{Name: fn.n, Value: dv}
 		default:
 			rf.defval = val
 		}
@@ -388,7 +697,7 @@ func newRecordField(schema interface{}, setters ...recordFieldSetter) (*recordFi
 	}
 
 	if val, ok = schemaMap["aliases"]; ok {
-		rf.aliases, ok = val.([]string)
+		rf.aliases, ok = stringsFromArray(val)
 		if !ok {
 			return nil, newCodecBuildError("record field", "record field aliases ought to be array of strings")
 		}
@@ -396,3 +705,278 @@ func newRecordField(schema interface{}, setters ...recordFieldSetter) (*recordFi
 
 	return rf, nil
 }
+
+// checkRecordFields returns an error unless the fields of someRecord
+// have the names of the fields of the template record, in order, as
+// they do when both were created from the same schema.
+func checkRecordFields(template, someRecord *Record) error {
+	if len(someRecord.Fields) != len(template.Fields) {
+		return fmt.Errorf("expected: %d fields; received: %d", len(template.Fields), len(someRecord.Fields))
+	}
+	for idx, field := range someRecord.Fields {
+		if field.Name != template.Fields[idx].Name {
+			return fmt.Errorf("expected field %d: %v; received: %v", idx, template.Fields[idx].Name, field.Name)
+		}
+	}
+	return nil
+}
+
+// stringsFromArray returns the strings of a schema attribute, which
+// ought to be an array of strings, and whether it was.
+func stringsFromArray(val interface{}) ([]string, bool) {
+	switch v := val.(type) {
+	case []string:
+		return v, true
+	case []interface{}:
+		someStrings := make([]string, len(v))
+		for i, item := range v {
+			someString, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			someStrings[i] = someString
+		}
+		return someStrings, true
+	}
+	return nil, false
+}
+
+// namedDefinition is the schema of a named type, along with the
+// namespace enclosing it, which is needed to interpret default values
+// of fields of that type.
+type namedDefinition struct {
+	enclosingNamespace string
+	schema             interface{}
+}
+
+// canonical returns the Parsing Canonical Form of the definition, by
+// which two definitions of the same named type are compared.
+func (def namedDefinition) canonical() string {
+	bb := new(bytes.Buffer)
+	if err := writeCanonical(bb, def.enclosingNamespace, def.schema, make(map[string]bool)); err != nil {
+		return ""
+	}
+	return bb.String()
+}
+
+// nativeDefault converts val, a default value parsed from the JSON of
+// a schema, to the datum of the specified schema the codecs accept:
+// int32 for int, *Record for record, Enum for enum, and so on, with
+// array items and map values converted likewise. As the Avro
+// specification requires, the default of a union is a value of its
+// first member. Named types are looked up in defs; when defs is nil,
+// values of named types referred to by name are returned as parsed.
+func nativeDefault(defs map[string]namedDefinition, enclosingNamespace string, schema, val interface{}) (interface{}, error) {
+	switch schemaType := schema.(type) {
+	case string:
+		switch schemaType {
+		case "null":
+			if val != nil {
+				return nil, fmt.Errorf("expected: null; received: %T", val)
+			}
+			return nil, nil
+		case "boolean":
+			if _, ok := val.(bool); !ok {
+				return nil, fmt.Errorf("expected: boolean; received: %T", val)
+			}
+			return val, nil
+		case "int":
+			dv, ok := integralDefault(val)
+			if !ok || dv < math.MinInt32 || dv > math.MaxInt32 {
+				return nil, fmt.Errorf("expected: int32; received: %v", val)
+			}
+			return int32(dv), nil
+		case "long":
+			dv, ok := integralDefault(val)
+			if !ok {
+				return nil, fmt.Errorf("expected: int64; received: %v", val)
+			}
+			return dv, nil
+		case "float":
+			dv, ok := floatingDefault(val)
+			if !ok {
+				return nil, fmt.Errorf("expected: float32; received: %T", val)
+			}
+			return float32(dv), nil
+		case "double":
+			dv, ok := floatingDefault(val)
+			if !ok {
+				return nil, fmt.Errorf("expected: float64; received: %T", val)
+			}
+			return dv, nil
+		case "bytes":
+			dv, ok := bytesDefault(val)
+			if !ok {
+				return nil, fmt.Errorf("expected: string of code points 0-255; received: %v", val)
+			}
+			return dv, nil
+		case "string":
+			if _, ok := val.(string); !ok {
+				return nil, fmt.Errorf("expected: string; received: %T", val)
+			}
+			return val, nil
+		}
+		nm, err := newName(nameName(schemaType), nameEnclosingNamespace(enclosingNamespace))
+		if err != nil {
+			return nil, err
+		}
+		if defs == nil {
+			return val, nil
+		}
+		definition, ok := defs[nm.n]
+		if !ok {
+			return nil, fmt.Errorf("unknown type name: %s", nm.n)
+		}
+		return nativeDefault(defs, definition.enclosingNamespace, definition.schema, val)
+	case []interface{}:
+		if len(schemaType) == 0 {
+			return nil, fmt.Errorf("union ought to have one or more members")
+		}
+		return nativeDefault(defs, enclosingNamespace, schemaType[0], val)
+	case map[string]interface{}:
+		switch typeName := schemaType["type"]; typeName {
+		case "record", "error", "enum", "fixed":
+			nm, err := newName(nameSchema(schemaType), nameEnclosingNamespace(enclosingNamespace))
+			if err != nil {
+				return nil, err
+			}
+			switch typeName {
+			case "record", "error":
+				return recordDefault(defs, enclosingNamespace, schemaType, val)
+			case "enum":
+				someString, ok := val.(string)
+				if !ok {
+					return nil, fmt.Errorf("expected: symbol of %s; received: %T", nm.n, val)
+				}
+				symbols, _ := stringsFromArray(schemaType["symbols"])
+				for _, symbol := range symbols {
+					if symbol == someString {
+						return Enum{Name: nm.n, Value: someString}, nil
+					}
+				}
+				return nil, fmt.Errorf("symbol not defined: %s", someString)
+			default:
+				dv, ok := bytesDefault(val)
+				if !ok {
+					return nil, fmt.Errorf("expected: string of code points 0-255; received: %v", val)
+				}
+				if size, ok := schemaType["size"].(float64); ok && int(size) != len(dv) {
+					return nil, fmt.Errorf("expected: %d bytes; received: %d", int(size), len(dv))
+				}
+				return Fixed{Name: nm.n, Value: dv}, nil
+			}
+		case "array":
+			items, ok := val.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("expected: array; received: %T", val)
+			}
+			datum := make([]interface{}, len(items))
+			for idx, item := range items {
+				var err error
+				if datum[idx], err = nativeDefault(defs, enclosingNamespace, schemaType["items"], item); err != nil {
+					return nil, fmt.Errorf("[%d]: %v", idx, err)
+				}
+			}
+			return datum, nil
+		case "map":
+			values, ok := val.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("expected: map; received: %T", val)
+			}
+			datum := make(map[string]interface{}, len(values))
+			for k, v := range values {
+				var err error
+				if datum[k], err = nativeDefault(defs, enclosingNamespace, schemaType["values"], v); err != nil {
+					return nil, fmt.Errorf("[%s]: %v", k, err)
+				}
+			}
+			return datum, nil
+		default:
+			// {"type":"int"} and {"type":{...}} are the type they wrap
+			return nativeDefault(defs, enclosingNamespace, typeName, val)
+		}
+	}
+	return nil, fmt.Errorf("unknown schema type: %T", schema)
+}
+
+// recordDefault converts val, the default of a record field, to a
+// *Record. Fields absent from val take their own default.
+func recordDefault(defs map[string]namedDefinition, enclosingNamespace string, schema map[string]interface{}, val interface{}) (interface{}, error) {
+	values, ok := val.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected: map[string]interface{}; received: %T", val)
+	}
+	someRecord, err := NewRecord(recordSchemaRaw(schema), RecordEnclosingNamespace(enclosingNamespace))
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range someRecord.Fields {
+		basename := name{n: field.Name}.basename()
+		fieldSchema := field.schema.(map[string]interface{})
+		fieldValue, ok := values[basename]
+		if !ok {
+			if fieldValue, ok = fieldSchema["default"]; !ok {
+				return nil, fmt.Errorf("%s: field has no value and no default", basename)
+			}
+		}
+		if field.Datum, err = nativeDefault(defs, someRecord.n.namespace(), fieldSchema["type"], fieldValue); err != nil {
+			return nil, fmt.Errorf("%s: %v", basename, err)
+		}
+	}
+	return someRecord, nil
+}
+
+// integralDefault converts a JSON default value to an int64, provided
+// the value has no fractional component. Schema defaults are numbers
+// without a declared type, so `1` and `1.0` are equally acceptable.
+func integralDefault(val interface{}) (int64, bool) {
+	switch v := val.(type) {
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(v), true
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, true
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return 0, false
+		}
+		return integralDefault(f)
+	}
+	return 0, false
+}
+
+// floatingDefault converts a JSON default value to a float64, which
+// permits integral defaults such as `1` for float and double fields.
+func floatingDefault(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// bytesDefault converts a JSON default value for a bytes or fixed
+// field to a byte slice. The Avro specification encodes such defaults
+// as strings whose code points 0-255 each represent a single byte,
+// rather than as UTF-8.
+func bytesDefault(val interface{}) ([]byte, bool) {
+	someString, ok := val.(string)
+	if !ok {
+		return nil, false
+	}
+	buf := make([]byte, 0, len(someString))
+	for _, r := range someString {
+		if r > 255 {
+			return nil, false
+		}
+		buf = append(buf, byte(r))
+	}
+	return buf, true
+}
@@ -20,6 +20,8 @@ package goavro
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"testing"
 )
@@ -85,6 +87,68 @@ func TestRecordField(t *testing.T) {
 	}
 }
 
+func TestRecordFieldCoercesNumericDefaults(t *testing.T) {
+	checkDefault := func(typeName string, defval interface{}, expected interface{}) {
+		schema := map[string]interface{}{"name": "someRecordField", "type": typeName, "default": defval}
+		someRecordField, err := newRecordField(schema)
+		checkErrorFatal(t, err, nil)
+		if someRecordField.defval != expected {
+			t.Errorf("Actual: %#v; Expected: %#v", someRecordField.defval, expected)
+		}
+	}
+	checkDefault("int", float64(3), int32(3))
+	checkDefault("int", json.Number("3"), int32(3))
+	checkDefault("int", json.Number("3.0"), int32(3))
+	checkDefault("long", float64(3), int64(3))
+	checkDefault("long", json.Number("9007199254740993"), int64(9007199254740993))
+	checkDefault("float", float64(3), float32(3))
+	checkDefault("float", json.Number("3"), float32(3))
+	checkDefault("double", float64(3), float64(3))
+	checkDefault("double", json.Number("1"), float64(1))
+	checkDefault("double", json.Number("3.5"), float64(3.5))
+
+	checkDefaultError := func(typeName string, defval interface{}, expected interface{}) {
+		schema := map[string]interface{}{"name": "someRecordField", "type": typeName, "default": defval}
+		_, err := newRecordField(schema)
+		checkError(t, err, expected)
+	}
+	checkDefaultError("int", float64(3.5), "expected: int32; received: float64")
+	checkDefaultError("int", float64(1<<40), "expected: int32; received: float64")
+	checkDefaultError("long", json.Number("3.5"), "expected: int64; received: json.Number")
+	checkDefaultError("double", "3", "expected: float64; received: string")
+}
+
+func TestRecordFieldBytesDefaultsUseCodePoints(t *testing.T) {
+	var schema map[string]interface{}
+	err := json.Unmarshal([]byte(`{"name":"someRecordField","type":"bytes","default":"\u00ff\u0000a\u00e9"}`), &schema)
+	checkErrorFatal(t, err, nil)
+	someRecordField, err := newRecordField(schema)
+	checkErrorFatal(t, err, nil)
+	if actual, expected := someRecordField.defval.([]byte), []byte{0xff, 0x00, 'a', 0xe9}; !bytes.Equal(actual, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+
+	schema["default"] = "\u0100"
+	_, err = newRecordField(schema)
+	checkError(t, err, "expected: string of code points 0-255; received: string")
+
+	err = json.Unmarshal([]byte(`{"name":"someRecordField","type":{"type":"fixed","name":"two","size":2},"default":"\u00ff\u0080"}`), &schema)
+	checkErrorFatal(t, err, nil)
+	someRecordField, err = newRecordField(schema, recordFieldEnclosingNamespace("com.example"))
+	checkErrorFatal(t, err, nil)
+	someFixed, ok := someRecordField.defval.(Fixed)
+	if !ok {
+		t.Fatalf("Actual: %T; Expected: Fixed", someRecordField.defval)
+	}
+	if someFixed.Name != "com.example.two" || !bytes.Equal(someFixed.Value, []byte{0xff, 0x80}) {
+		t.Errorf("Actual: %#v; Expected: %#v", someFixed, Fixed{Name: "com.example.two", Value: []byte{0xff, 0x80}})
+	}
+
+	schema["default"] = "\u00ff"
+	_, err = newRecordField(schema)
+	checkError(t, err, "default value size mismatch: someRecordField; expected: 2 bytes; received: 1")
+}
+
 func TestRecordBailsWithoutName(t *testing.T) {
 	var recordFields []*recordField
 	{
@@ -259,3 +323,115 @@ func TestNullableStringField(t *testing.T) {
 		t.Fatalf("Expected nil, got (%T) - (%q)", nilOrString, nilOrString)
 	}
 }
+
+func TestRecordClone(t *testing.T) {
+	schema := `{"type":"record","name":"outer","fields":[
+{"name":"tags","type":{"type":"array","items":"string"}},
+{"name":"counts","type":{"type":"map","values":"long"}},
+{"name":"id","type":{"type":"fixed","name":"id","size":2}},
+{"name":"inner","type":{"type":"record","name":"inner","fields":[{"name":"blob","type":"bytes"}]}}]}`
+	codec, err := NewCodec(schema)
+	checkErrorFatal(t, err, nil)
+	datum, err := codec.Decode(bytes.NewReader([]byte("\x02\x02a\x00\x02\x02k\x02\x00ab\x04xy")))
+	checkErrorFatal(t, err, nil)
+	original := datum.(*Record)
+	before := original.String()
+
+	clone := original.Clone()
+	if actual := clone.String(); actual != before {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, before)
+	}
+
+	tags, _ := clone.Get("tags")
+	tags.([]interface{})[0] = "changed"
+	counts, _ := clone.Get("counts")
+	counts.(map[string]interface{})["k"] = int64(13)
+	id, _ := clone.Get("id")
+	id.(Fixed).Value[0] = 'z'
+	inner, _ := clone.Get("inner")
+	blob, _ := inner.(*Record).Get("blob")
+	blob.([]byte)[0] = 'z'
+	checkErrorFatal(t, inner.(*Record).Set("blob", []byte("new")), nil)
+	checkErrorFatal(t, clone.Set("tags", nil), nil)
+
+	if actual := original.String(); actual != before {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, before)
+	}
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, codec.Encode(bb, original), nil)
+	if actual, expected := bb.String(), "\x02\x02a\x00\x02\x02k\x02\x00ab\x04xy"; actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+}
+
+func TestRecordGetSetPath(t *testing.T) {
+	schema := `{"type":"record","name":"order","namespace":"com.example","fields":[
+{"name":"address","type":{"type":"record","name":"address","fields":[{"name":"zip","type":"string"}]}},
+{"name":"items","type":{"type":"array","items":{"type":"record","name":"item","fields":[{"name":"sku","type":"string"}]}}},
+{"name":"notes","type":{"type":"map","values":"string"}}]}`
+	codec, err := NewCodec(schema)
+	checkErrorFatal(t, err, nil)
+	datum, err := codec.Decode(bytes.NewReader([]byte("\x0a94105\x04\x02a\x02b\x00\x02\x02k\x02v\x00")))
+	checkErrorFatal(t, err, nil)
+	order := datum.(*Record)
+
+	for path, expected := range map[string]interface{}{
+		"address.zip":  "94105",
+		"items[1].sku": "b",
+		"notes[k]":     "v",
+	} {
+		actual, err := order.Get(path)
+		checkError(t, err, nil)
+		if actual != expected {
+			t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+		}
+	}
+
+	checkErrorFatal(t, order.Set("address.zip", "10001"), nil)
+	checkErrorFatal(t, order.Set("items[0].sku", "c"), nil)
+	checkErrorFatal(t, order.Set("notes[new]", "w"), nil)
+	first, err := order.Get("items[0]")
+	checkErrorFatal(t, err, nil)
+	checkErrorFatal(t, order.Set("items[1]", first), nil)
+	if actual, _ := order.Get("notes[new]"); actual != "w" {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, "w")
+	}
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, codec.Encode(bb, order), nil)
+	if actual, expected := bb.String(), "\x0a10001\x04\x02c\x02c\x00"; !bytes.HasPrefix([]byte(actual), []byte(expected)) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+
+	_, err = order.Get("address.city")
+	checkError(t, err, `no such field: "city" in "address.city"`)
+	if !errors.Is(err, ErrNoSuchField{}) {
+		t.Errorf("Actual: %#v; Expected: %#v", err, ErrNoSuchField{})
+	}
+	_, err = order.Get("items[2].sku")
+	checkError(t, err, `index out of range: [2] of 2 items in "items[2].sku"`)
+	_, err = order.Get("address[0]")
+	checkError(t, err, `cannot select [0] from *goavro.Record in "address[0]"`)
+	_, err = order.Get("items.sku")
+	checkError(t, err, `cannot select "sku" from []interface {} in "items.sku"`)
+	_, err = order.Get("items[0")
+	checkError(t, err, `unterminated index in "items[0"`)
+	err = order.Set("address.", "x")
+	checkError(t, err, `empty field name in "address."`)
+	err = order.Set("items[2]", "x")
+	checkError(t, err, "index out of range")
+
+	// a missing record along the path is a missing field
+	checkErrorFatal(t, order.Set("address", nil), nil)
+	_, err = order.Get("address.zip")
+	checkError(t, err, `no such field: "zip" in "address.zip"`)
+	if !errors.Is(err, ErrNoSuchField{}) {
+		t.Errorf("Actual: %#v; Expected: %#v", err, ErrNoSuchField{})
+	}
+	err = order.Set("address.zip", "x")
+	checkError(t, err, `no such field: "zip" in "address.zip"`)
+	_, err = order.Get("nosuch.zip")
+	checkError(t, err, `no such field: "nosuch" in "nosuch.zip"`)
+	if !errors.Is(err, ErrNoSuchField{}) {
+		t.Errorf("Actual: %#v; Expected: %#v", err, ErrNoSuchField{})
+	}
+}
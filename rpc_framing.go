@@ -0,0 +1,90 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+package goavro
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// FrameBufferSize is the largest buffer WriteFramed writes. Longer
+// payloads are split across several buffers.
+var FrameBufferSize = 8192
+
+// WriteFramed writes payload to w as one message of the Avro RPC
+// framing: a sequence of buffers, each preceded by its length as a
+// four-byte big-endian integer, terminated by a zero-length buffer.
+//
+//   bb := new(bytes.Buffer)
+//   if err := codec.Encode(bb, request); err != nil {
+//       return err
+//   }
+//   if err := goavro.WriteFramed(conn, bb.Bytes()); err != nil {
+//       return err
+//   }
+func WriteFramed(w io.Writer, payload []byte) error {
+	header := make([]byte, 4)
+	for len(payload) > 0 {
+		size := len(payload)
+		if FrameBufferSize > 0 && size > FrameBufferSize {
+			size = FrameBufferSize
+		}
+		binary.BigEndian.PutUint32(header, uint32(size))
+		if _, err := w.Write(header); err != nil {
+			return err
+		}
+		if _, err := w.Write(payload[:size]); err != nil {
+			return err
+		}
+		payload = payload[size:]
+	}
+	binary.BigEndian.PutUint32(header, 0)
+	_, err := w.Write(header)
+	return err
+}
+
+// ReadFramed reads one message of the Avro RPC framing from r, and
+// returns the concatenation of its buffers. It returns io.EOF when r
+// is exhausted before the first byte of a message. A message longer
+// than MaxDecodeSize is an error.
+func ReadFramed(r io.Reader) ([]byte, error) {
+	header := make([]byte, 4)
+	var payload bytes.Buffer
+	for first := true; ; first = false {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if first && err == io.EOF {
+				return nil, err
+			}
+			return nil, newReaderError("cannot read frame buffer length", err)
+		}
+		size := int64(binary.BigEndian.Uint32(header))
+		if size == 0 {
+			return payload.Bytes(), nil
+		}
+		if int64(payload.Len())+size > MaxDecodeSize {
+			return nil, newReaderError("frame message longer than MaxDecodeSize (%d)", MaxDecodeSize)
+		}
+		if _, err := io.CopyN(&payload, r, size); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, newReaderError("cannot read frame buffer", err)
+		}
+	}
+}
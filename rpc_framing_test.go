@@ -0,0 +1,65 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+package goavro
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestFramedRoundTrip(t *testing.T) {
+	defer func(size int) { FrameBufferSize = size }(FrameBufferSize)
+	FrameBufferSize = 3
+
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, WriteFramed(bb, []byte("abcdefg")), nil)
+	checkErrorFatal(t, WriteFramed(bb, nil), nil)
+	expected := []byte("\x00\x00\x00\x03abc\x00\x00\x00\x03def\x00\x00\x00\x01g\x00\x00\x00\x00\x00\x00\x00\x00")
+	if actual := bb.Bytes(); !bytes.Equal(actual, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+
+	payload, err := ReadFramed(bb)
+	checkErrorFatal(t, err, nil)
+	if !bytes.Equal(payload, []byte("abcdefg")) {
+		t.Errorf("Actual: %#v; Expected: %#v", payload, []byte("abcdefg"))
+	}
+	payload, err = ReadFramed(bb)
+	checkErrorFatal(t, err, nil)
+	if len(payload) != 0 {
+		t.Errorf("Actual: %#v; Expected: %#v", payload, []byte(nil))
+	}
+	_, err = ReadFramed(bb)
+	if err != io.EOF {
+		t.Errorf("Actual: %#v; Expected: %#v", err, io.EOF)
+	}
+}
+
+func TestReadFramedErrors(t *testing.T) {
+	_, err := ReadFramed(bytes.NewReader([]byte("\x00\x00\x00\x03ab")))
+	checkError(t, err, "cannot read frame buffer")
+	// missing terminating buffer
+	_, err = ReadFramed(bytes.NewReader([]byte("\x00\x00\x00\x01a")))
+	checkError(t, err, "cannot read frame buffer length")
+
+	defer func(size int64) { MaxDecodeSize = size }(MaxDecodeSize)
+	MaxDecodeSize = 4
+	_, err = ReadFramed(bytes.NewReader([]byte("\x00\x00\x00\x03abc\x00\x00\x00\x03def\x00\x00\x00\x00")))
+	checkError(t, err, "frame message longer than MaxDecodeSize (4)")
+}
@@ -0,0 +1,446 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+
+package goavro
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// InlinedSchema returns the codec's schema with every reference to a
+// named type replaced by that type's full definition, so the result is
+// self-contained and identical whether the original schema defined a
+// type inline or referred to it by name. Names of named types are
+// written as fullnames without a separate namespace attribute. Unlike
+// Parsing Canonical Form, which keeps only the first definition of
+// each named type, this form is intended for structural comparison,
+// and is not necessarily a schema a codec may be built from.
+func (c codec) InlinedSchema() string {
+	expanded, err := c.ExpandedSchema()
+	if err != nil {
+		return c.schema
+	}
+	return expanded
+}
+
+// ExpandedSchema returns the codec's schema fully expanded, for tools
+// which cannot resolve references to named types themselves, or an
+// error when the schema cannot be expanded. Every reference to a named
+// type is replaced by its definition, except references from within a
+// recursive type to itself, which would never end and so remain
+// references by fullname. InlinedSchema returns the same form.
+//
+//   expanded, err := codec.(goavro.SchemaInspector).ExpandedSchema()
+func (c codec) ExpandedSchema() (string, error) {
+	var schema interface{}
+	if err := json.Unmarshal([]byte(c.schema), &schema); err != nil {
+		return "", &ErrSchemaParse{"cannot unmarshal JSON", err}
+	}
+	buf, err := json.Marshal(inlineSchema(nullNamespace, schema, make(map[string]interface{})))
+	if err != nil {
+		return "", fmt.Errorf("cannot marshal schema: %v", err)
+	}
+	return string(buf), nil
+}
+
+// FieldCodec returns a Codec for the field of a record at the specified
+// path, for instance "address.zip", so the field may be encoded and
+// decoded on its own, such as when writing columnar output. Each
+// segment of the path names a field of a record; paths do not descend
+// through arrays, maps or unions. The Schema of the returned Codec is
+// the inlined schema of the field.
+func (c codec) FieldCodec(path string) (Codec, error) {
+	var schema interface{}
+	if err := json.Unmarshal([]byte(c.InlinedSchema()), &schema); err != nil {
+		return nil, newCodecBuildError("field codec", err)
+	}
+	current := &c
+	for _, segment := range strings.Split(path, ".") {
+		if current.fc == nil {
+			return nil, newCodecBuildError("field codec", "cannot select %q from %s in %q", segment, current.schemaName(), path)
+		}
+		child, ok := current.fc[segment]
+		if !ok {
+			return nil, newCodecBuildError("field codec", ErrNoSuchField{field: segment, path: path})
+		}
+		recordSchema, _ := schema.(map[string]interface{})
+		fields, _ := recordSchema["fields"].([]interface{})
+		for _, field := range fields {
+			if fieldMap, _ := field.(map[string]interface{}); fieldMap["name"] == segment {
+				schema = fieldMap["type"]
+				break
+			}
+		}
+		current = child
+	}
+	fieldCodec := *current
+	buf, err := json.Marshal(schema)
+	if err != nil {
+		return nil, newCodecBuildError("field codec", err)
+	}
+	fieldCodec.schema = string(buf)
+	fieldCodec.original = ""
+	fieldCodec.tree = nil
+	fieldCodec.opts = c.opts
+	fieldCodec.twin = new(twinCodec)
+	return &fieldCodec, nil
+}
+
+// Field returns a Codec for the named field of a record codec, so
+// fields may be encoded and decoded individually, such as when writing
+// a subset of the fields of a record. Unlike FieldCodec, name is the
+// name of a field of this record, and not a path.
+//
+//   nameCodec, err := userCodec.(goavro.SchemaInspector).Field("name")
+//   if err != nil {
+//       return err
+//   }
+//   err = nameCodec.Encode(w, user.Name)
+func (c codec) Field(name string) (Codec, error) {
+	if c.fc == nil {
+		return nil, newCodecBuildError("field codec", "cannot select %q from %s", name, c.schemaName())
+	}
+	if _, ok := c.fc[name]; !ok {
+		return nil, newCodecBuildError("field codec", ErrNoSuchField{field: name})
+	}
+	return c.FieldCodec(name)
+}
+
+// SchemaEquals returns true when the two schemas describe the same
+// data, regardless of formatting, the order of JSON object members,
+// whether named types are defined inline or referenced, and whether
+// names are given as fullnames or inherit their namespace. A primitive
+// type written as {"type":"int"} equals "int". Attributes which do not
+// affect the encoding, such as doc, aliases and default, are ignored.
+// Record fields are matched by name, regardless of their order, so
+// two equal schemas need not encode data alike; compare the
+// fingerprints of their Parsing Canonical Forms when they must. An
+// error is returned when either schema is invalid.
+func SchemaEquals(a, b string) (bool, error) {
+	left, err := canonicalInlinedSchema(a)
+	if err != nil {
+		return false, err
+	}
+	right, err := canonicalInlinedSchema(b)
+	if err != nil {
+		return false, err
+	}
+	return left == right, nil
+}
+
+// canonicalInlinedSchema returns the inlined schema, keeping only the
+// attributes retained by Parsing Canonical Form.
+func canonicalInlinedSchema(someJSONSchema string) (string, error) {
+	c, err := NewCodec(someJSONSchema)
+	if err != nil {
+		return "", err
+	}
+	var schema interface{}
+	if err = json.Unmarshal([]byte(c.(*codec).InlinedSchema()), &schema); err != nil {
+		return "", &ErrSchemaParse{"cannot unmarshal JSON", err}
+	}
+	buf, err := json.Marshal(canonicalSchema(schema))
+	if err != nil {
+		return "", &ErrSchemaParse{"cannot marshal JSON", err}
+	}
+	return string(buf), nil
+}
+
+func canonicalSchema(schema interface{}) interface{} {
+	switch schemaType := schema.(type) {
+	case []interface{}:
+		members := make([]interface{}, len(schemaType))
+		for idx, member := range schemaType {
+			members[idx] = canonicalSchema(member)
+		}
+		return members
+	case map[string]interface{}:
+		if typeName, ok := schemaType["type"].(string); ok && isPrimitiveTypeName(typeName) {
+			return typeName
+		}
+		canonical := make(map[string]interface{})
+		for _, key := range []string{"name", "type", "symbols", "size"} {
+			if v, ok := schemaType[key]; ok {
+				canonical[key] = v
+			}
+		}
+		if items, ok := schemaType["items"]; ok {
+			canonical["items"] = canonicalSchema(items)
+		}
+		if values, ok := schemaType["values"]; ok {
+			canonical["values"] = canonicalSchema(values)
+		}
+		if fields, ok := schemaType["fields"].([]interface{}); ok {
+			// fields are matched by name, so list them in name order
+			names := make([]string, 0, len(fields))
+			fieldTypes := make(map[string]interface{}, len(fields))
+			for _, field := range fields {
+				fieldMap, _ := field.(map[string]interface{})
+				fieldName, _ := fieldMap["name"].(string)
+				names = append(names, fieldName)
+				fieldTypes[fieldName] = fieldMap["type"]
+			}
+			sort.Strings(names)
+			canonicalFields := make([]interface{}, len(names))
+			for idx, fieldName := range names {
+				canonicalFields[idx] = map[string]interface{}{
+					"name": fieldName,
+					"type": canonicalSchema(fieldTypes[fieldName]),
+				}
+			}
+			canonical["fields"] = canonicalFields
+		}
+		return canonical
+	}
+	return schema
+}
+
+// strictJSON returns text with its comments, and its commas which
+// precede the end of an object or array, replaced by spaces, leaving
+// newlines alone so the offsets and line numbers of syntax errors
+// still refer to text. The contents of strings are left alone.
+func strictJSON(text string) string {
+	buf := []byte(text)
+	blank := func(from, to int) {
+		for i := from; i < to; i++ {
+			if buf[i] != '\n' {
+				buf[i] = ' '
+			}
+		}
+	}
+	// comments first, so a comma followed by one is seen as trailing
+	for i := 0; i < len(buf); i++ {
+		switch {
+		case buf[i] == '"':
+			i = endOfJSONString(buf, i)
+		case buf[i] == '/' && i+1 < len(buf) && buf[i+1] == '/':
+			end := i + 2
+			for end < len(buf) && buf[end] != '\n' {
+				end++
+			}
+			blank(i, end)
+			i = end
+		case buf[i] == '/' && i+1 < len(buf) && buf[i+1] == '*':
+			end := strings.Index(string(buf[i+2:]), "*/")
+			if end == -1 {
+				return string(buf) // unterminated, for the parser to report
+			}
+			end += i + 4
+			blank(i, end)
+			i = end - 1
+		}
+	}
+	// a comma is trailing when it follows a value, and precedes the end
+	var previous byte
+	for i := 0; i < len(buf); i++ {
+		switch buf[i] {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '"':
+			i = endOfJSONString(buf, i)
+		case ',':
+			next := i + 1
+			for next < len(buf) && strings.IndexByte(" \t\r\n", buf[next]) != -1 {
+				next++
+			}
+			if next < len(buf) && (buf[next] == '}' || buf[next] == ']') && strings.IndexByte("[{,:", previous) == -1 {
+				buf[i] = ' '
+				continue
+			}
+		}
+		previous = buf[i]
+	}
+	return string(buf)
+}
+
+// endOfJSONString returns the index of the quote which ends the JSON
+// string starting at the quote at start, or the index of the last byte
+// when the string is unterminated.
+func endOfJSONString(buf []byte, start int) int {
+	for i := start + 1; i < len(buf); i++ {
+		switch buf[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return len(buf) - 1
+}
+
+func isPrimitiveTypeName(typeName string) bool {
+	switch typeName {
+	case "null", "boolean", "int", "long", "float", "double", "bytes", "string":
+		return true
+	}
+	return false
+}
+
+// inlineSchema returns a copy of schema in which references to named
+// types defined in the schema are replaced by their definitions.
+// Defined maps fullnames to expanded definitions, and to nil while a
+// definition is still being expanded; recursive references remain
+// references.
+func inlineSchema(enclosingNamespace string, schema interface{}, defined map[string]interface{}) interface{} {
+	switch schemaType := schema.(type) {
+	case string:
+		if isPrimitiveTypeName(schemaType) {
+			return schemaType
+		}
+		nm, err := newName(nameName(schemaType), nameEnclosingNamespace(enclosingNamespace))
+		if err != nil {
+			return schemaType
+		}
+		if definition := defined[nm.n]; definition != nil {
+			return definition
+		}
+		return nm.n
+	case []interface{}:
+		members := make([]interface{}, len(schemaType))
+		for idx, member := range schemaType {
+			members[idx] = inlineSchema(enclosingNamespace, member, defined)
+		}
+		return members
+	case map[string]interface{}:
+		inlined := make(map[string]interface{}, len(schemaType))
+		for k, v := range schemaType {
+			inlined[k] = v
+		}
+		typeName, _ := schemaType["type"].(string)
+		switch typeName {
+		case "record", "error", "enum", "fixed":
+			nm, err := newName(nameSchema(schemaType), nameEnclosingNamespace(enclosingNamespace))
+			if err != nil {
+				return inlined
+			}
+			inlined["name"] = nm.n
+			delete(inlined, "namespace")
+			if nm.namespace() == nullNamespace && enclosingNamespace != nullNamespace {
+				// keep a type in the null namespace out of the enclosing one
+				inlined["namespace"] = nullNamespace
+			}
+			if fields, ok := schemaType["fields"].([]interface{}); ok {
+				defined[nm.n] = nil
+				inlinedFields := make([]interface{}, len(fields))
+				for idx, field := range fields {
+					inlinedFields[idx] = field
+					if fieldMap, ok := field.(map[string]interface{}); ok {
+						inlinedField := make(map[string]interface{}, len(fieldMap))
+						for k, v := range fieldMap {
+							inlinedField[k] = v
+						}
+						inlinedField["type"] = inlineSchema(nm.namespace(), fieldMap["type"], defined)
+						inlinedFields[idx] = inlinedField
+					}
+				}
+				inlined["fields"] = inlinedFields
+			}
+			defined[nm.n] = inlined
+			// references by alias are inlined as well
+			aliases, _ := namedAliases(nm, schemaType)
+			for _, alias := range aliases {
+				defined[alias] = inlined
+			}
+		case "array":
+			inlined["items"] = inlineSchema(enclosingNamespace, schemaType["items"], defined)
+		case "map":
+			inlined["values"] = inlineSchema(enclosingNamespace, schemaType["values"], defined)
+		default:
+			if !isPrimitiveTypeName(typeName) {
+				// {"type":"someName"} and {"type":{...}} are the type they wrap
+				return inlineSchema(enclosingNamespace, schemaType["type"], defined)
+			}
+		}
+		return inlined
+	}
+	return schema
+}
+
+// embedNamedTypes returns a copy of schema in which the first
+// reference to each type in external is replaced by its definition,
+// and any later definition of an already present type is replaced by
+// a reference to it, so the result is self-contained. References are
+// written as full names, so the result does not depend on the
+// enclosing namespace.
+func embedNamedTypes(enclosingNamespace string, schema interface{}, external map[string]interface{}, embedded map[string]bool) interface{} {
+	switch schemaType := schema.(type) {
+	case string:
+		if isPrimitiveTypeName(schemaType) {
+			return schemaType
+		}
+		nm, err := newName(nameName(schemaType), nameEnclosingNamespace(enclosingNamespace))
+		if err != nil {
+			return schemaType
+		}
+		if definition := external[nm.n]; definition != nil && !embedded[nm.n] {
+			return embedNamedTypes(nullNamespace, definition, external, embedded)
+		}
+		return nm.n
+	case []interface{}:
+		members := make([]interface{}, len(schemaType))
+		for idx, member := range schemaType {
+			members[idx] = embedNamedTypes(enclosingNamespace, member, external, embedded)
+		}
+		return members
+	case map[string]interface{}:
+		embeddedSchema := make(map[string]interface{}, len(schemaType))
+		for k, v := range schemaType {
+			embeddedSchema[k] = v
+		}
+		typeName, _ := schemaType["type"].(string)
+		switch typeName {
+		case "record", "error", "enum", "fixed":
+			nm, err := newName(nameSchema(schemaType), nameEnclosingNamespace(enclosingNamespace))
+			if err != nil {
+				return embeddedSchema
+			}
+			if embedded[nm.n] {
+				return nm.n
+			}
+			embedded[nm.n] = true
+			if fields, ok := schemaType["fields"].([]interface{}); ok {
+				embeddedFields := make([]interface{}, len(fields))
+				for idx, field := range fields {
+					embeddedFields[idx] = field
+					if fieldMap, ok := field.(map[string]interface{}); ok {
+						embeddedField := make(map[string]interface{}, len(fieldMap))
+						for k, v := range fieldMap {
+							embeddedField[k] = v
+						}
+						embeddedField["type"] = embedNamedTypes(nm.namespace(), fieldMap["type"], external, embedded)
+						embeddedFields[idx] = embeddedField
+					}
+				}
+				embeddedSchema["fields"] = embeddedFields
+			}
+		case "array":
+			embeddedSchema["items"] = embedNamedTypes(enclosingNamespace, schemaType["items"], external, embedded)
+		case "map":
+			embeddedSchema["values"] = embedNamedTypes(enclosingNamespace, schemaType["values"], external, embedded)
+		default:
+			if !isPrimitiveTypeName(typeName) {
+				embeddedSchema["type"] = embedNamedTypes(enclosingNamespace, schemaType["type"], external, embedded)
+			}
+		}
+		return embeddedSchema
+	}
+	return schema
+}
@@ -0,0 +1,173 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+
+package goavro
+
+import (
+	"encoding/json"
+)
+
+// RecordSchemaBuilder builds the JSON schema of a record one field at a
+// time, as an alternative to writing the schema by hand.
+//
+//   address := goavro.NewRecordSchema("Address", "com.example").
+//       AddField("street", "string").
+//       AddField("zip", goavro.UnionSchema("null", "string"), goavro.FieldDefault(nil))
+//   schema := goavro.NewRecordSchema("Person", "com.example").
+//       AddField("name", "string").
+//       AddField("address", address).
+//       AddField("phones", goavro.ArraySchema("string")).
+//       Build()
+//   codec, err := goavro.NewCodec(schema)
+//
+// The type of a field is a string naming a primitive or a previously
+// defined named type, a nested RecordSchemaBuilder, or the value
+// returned by ArraySchema, MapSchema, UnionSchema, EnumSchema or
+// FixedSchema. Builders do not check the schema; NewCodec does.
+type RecordSchemaBuilder struct {
+	name      string
+	namespace string
+	doc       string
+	fields    []interface{}
+}
+
+// SchemaFieldSetter functions set optional attributes of a field added
+// by RecordSchemaBuilder.AddField.
+type SchemaFieldSetter func(map[string]interface{})
+
+// FieldDefault sets the default value of a field, expressed as it
+// would be in a JSON schema, for instance nil for a null default.
+func FieldDefault(value interface{}) SchemaFieldSetter {
+	return func(field map[string]interface{}) {
+		field["default"] = value
+	}
+}
+
+// FieldDoc sets the documentation of a field.
+func FieldDoc(doc string) SchemaFieldSetter {
+	return func(field map[string]interface{}) {
+		field["doc"] = doc
+	}
+}
+
+// FieldAliases sets the aliases of a field.
+func FieldAliases(aliases ...string) SchemaFieldSetter {
+	return func(field map[string]interface{}) {
+		field["aliases"] = aliases
+	}
+}
+
+// NewRecordSchema returns a RecordSchemaBuilder for a record with the
+// specified name and namespace. An empty namespace leaves the record in
+// the namespace of its enclosing schema, if any.
+func NewRecordSchema(name, namespace string) *RecordSchemaBuilder {
+	return &RecordSchemaBuilder{name: name, namespace: namespace}
+}
+
+// Doc sets the documentation of the record.
+func (b *RecordSchemaBuilder) Doc(doc string) *RecordSchemaBuilder {
+	b.doc = doc
+	return b
+}
+
+// AddField appends a field with the specified name and type to the
+// record.
+func (b *RecordSchemaBuilder) AddField(name string, typeSchema interface{}, setters ...SchemaFieldSetter) *RecordSchemaBuilder {
+	field := map[string]interface{}{"name": name, "type": builtSchema(typeSchema)}
+	for _, setter := range setters {
+		setter(field)
+	}
+	b.fields = append(b.fields, field)
+	return b
+}
+
+// Build returns the JSON schema of the record. It returns an empty
+// string when a field default cannot be marshaled as JSON, which
+// NewCodec rejects.
+func (b *RecordSchemaBuilder) Build() string {
+	buf, err := json.Marshal(b.schema())
+	if err != nil {
+		return ""
+	}
+	return string(buf)
+}
+
+func (b *RecordSchemaBuilder) schema() map[string]interface{} {
+	schema := map[string]interface{}{"type": "record", "name": b.name, "fields": b.fields}
+	if b.namespace != "" {
+		schema["namespace"] = b.namespace
+	}
+	if b.doc != "" {
+		schema["doc"] = b.doc
+	}
+	if b.fields == nil {
+		schema["fields"] = []interface{}{}
+	}
+	return schema
+}
+
+// ArraySchema returns the schema of an array whose items have the
+// specified type, for use with RecordSchemaBuilder.AddField.
+func ArraySchema(items interface{}) interface{} {
+	return map[string]interface{}{"type": "array", "items": builtSchema(items)}
+}
+
+// MapSchema returns the schema of a map whose values have the specified
+// type, for use with RecordSchemaBuilder.AddField.
+func MapSchema(values interface{}) interface{} {
+	return map[string]interface{}{"type": "map", "values": builtSchema(values)}
+}
+
+// UnionSchema returns the schema of a union of the specified types, for
+// use with RecordSchemaBuilder.AddField.
+func UnionSchema(members ...interface{}) interface{} {
+	union := make([]interface{}, len(members))
+	for i, member := range members {
+		union[i] = builtSchema(member)
+	}
+	return union
+}
+
+// EnumSchema returns the schema of an enum with the specified name,
+// namespace and symbols, for use with RecordSchemaBuilder.AddField.
+func EnumSchema(name, namespace string, symbols ...string) interface{} {
+	schema := map[string]interface{}{"type": "enum", "name": name, "symbols": symbols}
+	if namespace != "" {
+		schema["namespace"] = namespace
+	}
+	return schema
+}
+
+// FixedSchema returns the schema of a fixed with the specified name,
+// namespace and size, for use with RecordSchemaBuilder.AddField.
+func FixedSchema(name, namespace string, size int) interface{} {
+	schema := map[string]interface{}{"type": "fixed", "name": name, "size": size}
+	if namespace != "" {
+		schema["namespace"] = namespace
+	}
+	return schema
+}
+
+// builtSchema returns the schema of a field type, expanding nested
+// record builders.
+func builtSchema(typeSchema interface{}) interface{} {
+	if b, ok := typeSchema.(*RecordSchemaBuilder); ok {
+		return b.schema()
+	}
+	return typeSchema
+}
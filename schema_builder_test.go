@@ -0,0 +1,67 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+
+package goavro
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRecordSchemaBuilder(t *testing.T) {
+	address := NewRecordSchema("Address", "").
+		AddField("street", "string").
+		AddField("zip", UnionSchema("null", "string"), FieldDefault(nil))
+	schema := NewRecordSchema("Person", "com.example").
+		Doc("someone").
+		AddField("name", "string", FieldDoc("full name"), FieldAliases("fullName")).
+		AddField("address", address).
+		AddField("phones", ArraySchema("string")).
+		AddField("scores", MapSchema("double")).
+		AddField("suit", EnumSchema("Suit", "", "HEARTS", "SPADES")).
+		AddField("id", FixedSchema("Id", "", 4)).
+		AddField("previous", UnionSchema("null", "Address"), FieldDefault(nil)).
+		Build()
+
+	expected := `{"doc":"someone","fields":[{"aliases":["fullName"],"doc":"full name","name":"name","type":"string"},{"name":"address","type":{"fields":[{"name":"street","type":"string"},{"default":null,"name":"zip","type":["null","string"]}],"name":"Address","type":"record"}},{"name":"phones","type":{"items":"string","type":"array"}},{"name":"scores","type":{"type":"map","values":"double"}},{"name":"suit","type":{"name":"Suit","symbols":["HEARTS","SPADES"],"type":"enum"}},{"name":"id","type":{"name":"Id","size":4,"type":"fixed"}},{"default":null,"name":"previous","type":["null","Address"]}],"name":"Person","namespace":"com.example","type":"record"}`
+	if schema != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", schema, expected)
+	}
+
+	codec, err := NewCodec(schema)
+	checkErrorFatal(t, err, nil)
+	record, err := NewRecord(RecordSchema(schema))
+	checkErrorFatal(t, err, nil)
+	address2, err := NewRecord(RecordSchema(address.Build()), RecordEnclosingNamespace("com.example"))
+	checkErrorFatal(t, err, nil)
+	checkErrorFatal(t, address2.Set("street", "Main"), nil)
+	checkErrorFatal(t, record.Set("name", "Alice"), nil)
+	checkErrorFatal(t, record.Set("address", address2), nil)
+	checkErrorFatal(t, record.Set("phones", []interface{}{"555"}), nil)
+	checkErrorFatal(t, record.Set("scores", map[string]interface{}{}), nil)
+	checkErrorFatal(t, record.Set("suit", Enum{"com.example.Suit", "SPADES"}), nil)
+	checkErrorFatal(t, record.Set("id", Fixed{"com.example.Id", []byte("abcd")}), nil)
+	checkErrorFatal(t, codec.Encode(new(bytes.Buffer), record), nil)
+}
+
+func TestRecordSchemaBuilderEmptyRecord(t *testing.T) {
+	schema := NewRecordSchema("Empty", "").Build()
+	if expected := `{"fields":[],"name":"Empty","type":"record"}`; schema != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", schema, expected)
+	}
+}
@@ -0,0 +1,268 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+
+package goavro
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCodecInlinedSchema(t *testing.T) {
+	codec, err := NewCodec(`{"type":"record","name":"user","namespace":"com.example","fields":[
+{"name":"home","type":{"type":"record","name":"address","fields":[{"name":"city","type":"string"}]}},
+{"name":"work","type":["null","address"]},
+{"name":"visits","type":{"type":"array","items":"com.example.address"}},
+{"name":"id","type":{"type":"fixed","name":"id","namespace":"org.other","size":4}},
+{"name":"ids","type":{"type":"map","values":"org.other.id"}}]}`)
+	checkErrorFatal(t, err, nil)
+
+	address := `{"fields":[{"name":"city","type":"string"}],"name":"com.example.address","type":"record"}`
+	id := `{"name":"org.other.id","size":4,"type":"fixed"}`
+	expected := `{"fields":[` +
+		`{"name":"home","type":` + address + `},` +
+		`{"name":"work","type":["null",` + address + `]},` +
+		`{"name":"visits","type":{"items":` + address + `,"type":"array"}},` +
+		`{"name":"id","type":` + id + `},` +
+		`{"name":"ids","type":{"type":"map","values":` + id + `}}],` +
+		`"name":"com.example.user","type":"record"}`
+	if actual := codec.(SchemaInspector).InlinedSchema(); actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+}
+
+func TestCodecInlinedSchemaIgnoresReferenceStyle(t *testing.T) {
+	referenced, err := NewCodec(`{"type":"record","name":"pair","fields":[
+{"name":"left","type":{"type":"enum","name":"side","symbols":["L","R"]}},
+{"name":"right","type":"side"}]}`)
+	checkErrorFatal(t, err, nil)
+	wrapped, err := NewCodec(`{"type":"record","name":"pair","fields":[
+{"name":"left","type":{"type":"enum","name":"side","symbols":["L","R"]}},
+{"name":"right","type":{"type":"side"}}]}`)
+	checkErrorFatal(t, err, nil)
+	if referenced.(SchemaInspector).InlinedSchema() != wrapped.(SchemaInspector).InlinedSchema() {
+		t.Errorf("Actual: %#v; Expected: %#v", wrapped.(SchemaInspector).InlinedSchema(), referenced.(SchemaInspector).InlinedSchema())
+	}
+}
+
+func TestCodecExpandedSchemaKeepsRecursiveReferences(t *testing.T) {
+	// NewCodec cannot yet build recursive types, but their schemas are
+	// expanded all the same.
+	schema := `{"type":"record","name":"node","namespace":"com.example","fields":[
+{"name":"label","type":{"type":"enum","name":"kind","symbols":["A","B"]}},
+{"name":"other","type":"kind"},
+{"name":"next","type":["null","node"]},
+{"name":"children","type":{"type":"array","items":"node"}}]}`
+
+	kind := `{"name":"com.example.kind","symbols":["A","B"],"type":"enum"}`
+	expected := `{"fields":[` +
+		`{"name":"label","type":` + kind + `},` +
+		`{"name":"other","type":` + kind + `},` +
+		`{"name":"next","type":["null","com.example.node"]},` +
+		`{"name":"children","type":{"items":"com.example.node","type":"array"}}],` +
+		`"name":"com.example.node","type":"record"}`
+	actual, err := (codec{schema: schema}).ExpandedSchema()
+	checkErrorFatal(t, err, nil)
+	if actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+	if inlined := (codec{schema: schema}).InlinedSchema(); inlined != actual {
+		t.Errorf("Actual: %#v; Expected: %#v", inlined, actual)
+	}
+
+	_, err = (codec{schema: "{"}).ExpandedSchema()
+	checkError(t, err, "cannot unmarshal JSON")
+
+	c, err := NewCodec(`{"type":"record","name":"pair","fields":[
+{"name":"left","type":{"type":"fixed","name":"id","size":2}},
+{"name":"right","type":"id"}]}`)
+	checkErrorFatal(t, err, nil)
+	actual, err = c.(SchemaInspector).ExpandedSchema()
+	checkErrorFatal(t, err, nil)
+	id := `{"name":"id","size":2,"type":"fixed"}`
+	expected = `{"fields":[{"name":"left","type":` + id + `},{"name":"right","type":` + id + `}],"name":"pair","type":"record"}`
+	if actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+}
+
+func TestCodecFieldCodec(t *testing.T) {
+	codec, err := NewCodec(`{"type":"record","name":"user","namespace":"com.example","fields":[
+{"name":"name","type":"string"},
+{"name":"home","type":{"type":"record","name":"address","fields":[{"name":"zip","type":"int"},{"name":"color","type":{"type":"enum","name":"color","symbols":["RED"]}}]}},
+{"name":"work","type":"address"},
+{"name":"tags","type":{"type":"array","items":"string"}}]}`)
+	checkErrorFatal(t, err, nil)
+
+	zip, err := codec.(SchemaInspector).FieldCodec("work.zip")
+	checkErrorFatal(t, err, nil)
+	if actual, expected := zip.Schema(), `"int"`; actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, zip.Encode(bb, int32(3)), nil)
+	datum, err := zip.Decode(bb)
+	checkErrorFatal(t, err, nil)
+	if datum != int32(3) {
+		t.Errorf("Actual: %#v; Expected: %#v", datum, int32(3))
+	}
+
+	color, err := codec.(SchemaInspector).FieldCodec("work.color")
+	checkErrorFatal(t, err, nil)
+	if actual, expected := color.Schema(), `{"name":"com.example.color","symbols":["RED"],"type":"enum"}`; actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+	// the schema of a field codec is self-contained
+	_, err = NewCodec(color.Schema())
+	checkError(t, err, nil)
+
+	_, err = codec.(SchemaInspector).FieldCodec("home.city")
+	checkError(t, err, `no such field: "city" in "home.city"`)
+	_, err = codec.(SchemaInspector).FieldCodec("tags.length")
+	checkError(t, err, `cannot select "length" from array in "tags.length"`)
+}
+
+func TestCodecField(t *testing.T) {
+	codec, err := NewCodec(`{"type":"record","name":"user","namespace":"com.example","fields":[
+{"name":"name","type":"string"},
+{"name":"home","type":{"type":"record","name":"address","fields":[{"name":"zip","type":"int"}]}}]}`)
+	checkErrorFatal(t, err, nil)
+
+	nameCodec, err := codec.(SchemaInspector).Field("name")
+	checkErrorFatal(t, err, nil)
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, nameCodec.Encode(bb, "Alice"), nil)
+	if actual, expected := bb.String(), "\x0aAlice"; actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+
+	home, err := codec.(SchemaInspector).Field("home")
+	checkErrorFatal(t, err, nil)
+	if actual, expected := home.Schema(), `{"fields":[{"name":"zip","type":"int"}],"name":"com.example.address","type":"record"}`; actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+	zip, err := home.(SchemaInspector).Field("zip")
+	checkErrorFatal(t, err, nil)
+	datum, err := zip.Decode(bytes.NewReader([]byte("\x02")))
+	checkErrorFatal(t, err, nil)
+	if expected := int32(1); datum != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", datum, expected)
+	}
+
+	_, err = codec.(SchemaInspector).Field("home.zip")
+	checkError(t, err, `no such field: "home.zip"`)
+	_, err = codec.(SchemaInspector).Field("age")
+	checkError(t, err, `no such field: "age"`)
+	if _, ok := err.(*ErrCodecBuild).Err.(ErrNoSuchField); !ok {
+		t.Errorf("Actual: %T; Expected: %T", err.(*ErrCodecBuild).Err, ErrNoSuchField{})
+	}
+	_, err = nameCodec.(SchemaInspector).Field("length")
+	checkError(t, err, `cannot select "length" from string`)
+}
+
+func TestCodecJSONFieldCodec(t *testing.T) {
+	codec, err := NewJSONCodec(`{"type":"record","name":"user","fields":[
+{"name":"home","type":{"type":"record","name":"address","fields":[{"name":"zip","type":"int"}]}}]}`)
+	checkErrorFatal(t, err, nil)
+
+	home, err := codec.(SchemaInspector).FieldCodec("home")
+	checkErrorFatal(t, err, nil)
+	checkError(t, home.(Validator).Validate("13"), "received: string")
+
+	zip, err := codec.(SchemaInspector).FieldCodec("home.zip")
+	checkErrorFatal(t, err, nil)
+	checkError(t, zip.(Validator).Validate(int32(3)), nil)
+	checkError(t, zip.(Validator).Validate("13"), "expected: int32; received: string")
+
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, home.(StructCodec).EncodeStruct(bb, struct{ Zip int32 }{Zip: 3}), nil)
+	if actual, expected := bb.String(), `{"zip":3}`; actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+}
+
+func TestSchemaEquals(t *testing.T) {
+	cases := []struct {
+		a, b  string
+		equal bool
+	}{
+		{`"int"`, `{"type":"int"}`, true},
+		{`"int"`, `"long"`, false},
+		{`{"type":"array","items":"int"}`, `{ "items" : {"type":"int"}, "type" : "array" }`, true},
+		{
+			`{"type":"record","name":"com.example.user","doc":"x","fields":[{"name":"home","type":{"type":"record","name":"address","fields":[{"name":"zip","type":"int","default":1}]}},{"name":"work","type":"address"}]}`,
+			`{"type":"record","name":"user","namespace":"com.example","fields":[{"name":"home","type":{"type":"record","name":"com.example.address","fields":[{"name":"zip","type":"int"}]}},{"name":"work","type":{"type":"record","name":"address","namespace":"com.example","fields":[{"name":"zip","type":"int"}]}}]}`,
+			true,
+		},
+		{
+			`{"type":"record","name":"r","fields":[{"name":"a","type":"int"},{"name":"b","type":"int"}]}`,
+			`{"type":"record","name":"r","fields":[{"name":"b","type":"int"},{"name":"a","type":"int"}]}`,
+			true,
+		},
+		{
+			`{"type":"record","name":"r","fields":[{"name":"a","type":"int"},{"name":"b","type":"int"}]}`,
+			`{"type":"record","name":"r","fields":[{"name":"b","type":"int"},{"name":"a","type":"long"}]}`,
+			false,
+		},
+		{`{"type":"enum","name":"e","symbols":["A","B"]}`, `{"type":"enum","name":"e","symbols":["B","A"]}`, false},
+		{`{"type":"fixed","name":"f","size":4}`, `{"type":"fixed","name":"g","size":4}`, false},
+	}
+	for _, c := range cases {
+		equal, err := SchemaEquals(c.a, c.b)
+		checkErrorFatal(t, err, nil)
+		if equal != c.equal {
+			t.Errorf("%s; %s; Actual: %#v; Expected: %#v", c.a, c.b, equal, c.equal)
+		}
+	}
+
+	_, err := SchemaEquals(`"int"`, `{"type":"nope"}`)
+	checkError(t, err, "unknown type name: nope")
+}
+
+func TestNewCodecLenient(t *testing.T) {
+	lenient := `{
+  // a user of the system
+  "type": "record",
+  "name": "user", /* the record name */
+  "doc": "ids // are /* not */ comments here, ]",
+  "fields": [
+    {"name": "id", "type": "long",},
+    {"name": "tags", "type": {"type": "array", "items": "string"}, "default": ["a",],}, // trailing
+  ],
+}`
+	_, err := NewCodec(lenient)
+	checkError(t, err, "cannot unmarshal JSON")
+
+	codec, err := NewCodecLenient(lenient)
+	checkErrorFatal(t, err, nil)
+	expected := `{"doc":"ids // are /* not */ comments here, ]","fields":[{"name":"id","type":"long"},{"default":["a"],"name":"tags","type":{"items":"string","type":"array"}}],"name":"user","type":"record"}`
+	if actual := codec.Schema(); actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+	if actual := codec.(SchemaInspector).OriginalSchema(); actual != lenient {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, lenient)
+	}
+
+	_, err = NewCodecLenient(`"a \"quoted\" // string"`)
+	checkError(t, err, `a \"quoted\" // string`)
+
+	_, err = NewCodecLenient(`{"type": "int" /* unterminated`)
+	checkError(t, err, "cannot unmarshal JSON")
+	_, err = NewCodecLenient(`[,]`)
+	checkError(t, err, "cannot unmarshal JSON")
+}
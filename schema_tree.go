@@ -0,0 +1,300 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+
+package goavro
+
+import (
+	"encoding/json"
+)
+
+// SchemaNode is a node of the structured representation of a schema
+// returned by SchemaTree. Its concrete type is one of *PrimitiveNode,
+// *RecordNode, *EnumNode, *FixedNode, *ArrayNode, *MapNode or
+// *UnionNode, and Kind returns the corresponding Avro type name, such
+// as "record", "union" or "long". The Props of a node, and of a record
+// field, hold the attributes the Avro specification does not define,
+// such as {"pii": true}, and are nil when there are none.
+//
+//   switch node := codec.SchemaTree().(type) {
+//   case *goavro.RecordNode:
+//       for _, field := range node.Fields {
+//           fmt.Println(field.Name, field.Type.Kind())
+//       }
+//   }
+type SchemaNode interface {
+	Kind() string
+}
+
+// PrimitiveNode describes a primitive type, such as "int" or "string".
+type PrimitiveNode struct {
+	Type        string
+	LogicalType string
+	Props       map[string]interface{}
+}
+
+// Kind returns the name of the primitive type.
+func (n *PrimitiveNode) Kind() string { return n.Type }
+
+// RecordNode describes a record. Name is the fullname of the record.
+// Every reference to the record within a schema yields the same
+// *RecordNode.
+type RecordNode struct {
+	Name        string
+	Namespace   string
+	Doc         string
+	Aliases     []string
+	LogicalType string
+	Fields      []*FieldNode
+	Props       map[string]interface{}
+}
+
+// Kind returns "record".
+func (n *RecordNode) Kind() string { return "record" }
+
+// FieldNode describes a field of a record. Default is only meaningful
+// when HasDefault is true, because a field may default to null.
+type FieldNode struct {
+	Name       string
+	Doc        string
+	Aliases    []string
+	Type       SchemaNode
+	Default    interface{}
+	HasDefault bool
+	Order      string
+	Props      map[string]interface{}
+}
+
+// EnumNode describes an enum. Name is the fullname of the enum.
+// Default is the symbol readers use for symbols of a writer's enum
+// they lack, or empty when the enum has no default.
+type EnumNode struct {
+	Name        string
+	Namespace   string
+	Doc         string
+	Aliases     []string
+	LogicalType string
+	Symbols     []string
+	Default     string
+	Props       map[string]interface{}
+}
+
+// Kind returns "enum".
+func (n *EnumNode) Kind() string { return "enum" }
+
+// FixedNode describes a fixed. Name is the fullname of the fixed.
+type FixedNode struct {
+	Name        string
+	Namespace   string
+	Doc         string
+	Aliases     []string
+	LogicalType string
+	Size        int
+	Props       map[string]interface{}
+}
+
+// Kind returns "fixed".
+func (n *FixedNode) Kind() string { return "fixed" }
+
+// ArrayNode describes an array.
+type ArrayNode struct {
+	Items       SchemaNode
+	LogicalType string
+	Props       map[string]interface{}
+}
+
+// Kind returns "array".
+func (n *ArrayNode) Kind() string { return "array" }
+
+// MapNode describes a map.
+type MapNode struct {
+	Values      SchemaNode
+	LogicalType string
+	Props       map[string]interface{}
+}
+
+// Kind returns "map".
+func (n *MapNode) Kind() string { return "map" }
+
+// UnionNode describes a union.
+type UnionNode struct {
+	Members []SchemaNode
+}
+
+// Kind returns "union".
+func (n *UnionNode) Kind() string { return "union" }
+
+// SchemaTree returns a structured representation of the codec's
+// schema, so that tools may navigate the schema without parsing its
+// JSON. References to named types resolve to the node of their
+// definition.
+func (c codec) SchemaTree() SchemaNode {
+	var schema interface{}
+	if err := json.Unmarshal([]byte(c.schema), &schema); err != nil {
+		return nil
+	}
+	return schemaNode(nullNamespace, schema, make(map[string]SchemaNode))
+}
+
+// schemaTree returns the tree NewCodec stored for the codec, or parses
+// the schema when the codec was built some other way.
+func (c codec) schemaTree() SchemaNode {
+	if c.tree != nil {
+		return c.tree
+	}
+	return c.SchemaTree()
+}
+
+// Kind returns the Avro type of the top level of the codec's schema,
+// such as "record", "array", "union", or "int", without parsing the
+// schema again.
+func (c codec) Kind() string {
+	if tree := c.schemaTree(); tree != nil {
+		return tree.Kind()
+	}
+	return c.schemaName()
+}
+
+// Name returns the fullname of the codec's schema, and true, when the
+// top level of the schema is a named type: a record, enum, or fixed.
+// Otherwise it returns an empty string and false.
+func (c codec) Name() (string, bool) {
+	switch c.Kind() {
+	case "record", "enum", "fixed":
+		return c.nm.n, true
+	}
+	return "", false
+}
+
+// schemaNode returns the node for schema, which has already been
+// validated by building a codec from it. Defined maps fullnames to the
+// nodes of named types.
+func schemaNode(enclosingNamespace string, schema interface{}, defined map[string]SchemaNode) SchemaNode {
+	switch schemaType := schema.(type) {
+	case string:
+		if isPrimitiveTypeName(schemaType) {
+			return &PrimitiveNode{Type: schemaType}
+		}
+		nm, err := newName(nameName(schemaType), nameEnclosingNamespace(enclosingNamespace))
+		if err != nil {
+			return nil
+		}
+		return defined[nm.n]
+	case []interface{}:
+		union := &UnionNode{Members: make([]SchemaNode, len(schemaType))}
+		for idx, member := range schemaType {
+			union.Members[idx] = schemaNode(enclosingNamespace, member, defined)
+		}
+		return union
+	case map[string]interface{}:
+		logicalType, _ := schemaType["logicalType"].(string)
+		typeName, ok := schemaType["type"].(string)
+		if !ok {
+			return schemaNode(enclosingNamespace, schemaType["type"], defined)
+		}
+		switch typeName {
+		case "array":
+			return &ArrayNode{Items: schemaNode(enclosingNamespace, schemaType["items"], defined), LogicalType: logicalType, Props: schemaProps(schemaType)}
+		case "map":
+			return &MapNode{Values: schemaNode(enclosingNamespace, schemaType["values"], defined), LogicalType: logicalType, Props: schemaProps(schemaType)}
+		case "record", "error", "enum", "fixed":
+			// handled below
+		default:
+			if isPrimitiveTypeName(typeName) {
+				return &PrimitiveNode{Type: typeName, LogicalType: logicalType, Props: schemaProps(schemaType)}
+			}
+			return schemaNode(enclosingNamespace, typeName, defined)
+		}
+		nm, err := newName(nameSchema(schemaType), nameEnclosingNamespace(enclosingNamespace))
+		if err != nil {
+			return nil
+		}
+		doc, _ := schemaType["doc"].(string)
+		aliases, _ := stringsFromArray(schemaType["aliases"])
+		// references by alias resolve to the node as well
+		aliasNames, _ := namedAliases(nm, schemaType)
+		define := func(node SchemaNode) {
+			defined[nm.n] = node
+			for _, alias := range aliasNames {
+				defined[alias] = node
+			}
+		}
+		switch typeName {
+		case "enum":
+			symbols, _ := stringsFromArray(schemaType["symbols"])
+			node := &EnumNode{Name: nm.n, Namespace: nm.namespace(), Doc: doc, Aliases: aliases, LogicalType: logicalType, Symbols: symbols, Props: schemaProps(schemaType)}
+			node.Default, _ = schemaType["default"].(string)
+			define(node)
+			return node
+		case "fixed":
+			size, _ := schemaType["size"].(float64)
+			node := &FixedNode{Name: nm.n, Namespace: nm.namespace(), Doc: doc, Aliases: aliases, LogicalType: logicalType, Size: int(size), Props: schemaProps(schemaType)}
+			define(node)
+			return node
+		}
+		node := &RecordNode{Name: nm.n, Namespace: nm.namespace(), Doc: doc, Aliases: aliases, LogicalType: logicalType, Props: schemaProps(schemaType)}
+		define(node) // before fields, so recursive references resolve
+		fields, _ := schemaType["fields"].([]interface{})
+		for _, field := range fields {
+			fieldMap, _ := field.(map[string]interface{})
+			fieldNode := &FieldNode{Type: schemaNode(nm.namespace(), fieldMap["type"], defined)}
+			fieldNode.Name, _ = fieldMap["name"].(string)
+			fieldNode.Doc, _ = fieldMap["doc"].(string)
+			fieldNode.Aliases, _ = stringsFromArray(fieldMap["aliases"])
+			fieldNode.Order, _ = fieldMap["order"].(string)
+			fieldNode.Default, fieldNode.HasDefault = fieldMap["default"]
+			fieldNode.Props = schemaProps(fieldMap)
+			node.Fields = append(node.Fields, fieldNode)
+		}
+		return node
+	}
+	return nil
+}
+
+// reservedAttributes are the schema and field attributes defined by the
+// Avro specification, which are not reported as Props.
+var reservedAttributes = map[string]bool{
+	"aliases":     true,
+	"default":     true,
+	"doc":         true,
+	"fields":      true,
+	"items":       true,
+	"logicalType": true,
+	"name":        true,
+	"namespace":   true,
+	"order":       true,
+	"size":        true,
+	"symbols":     true,
+	"type":        true,
+	"values":      true,
+}
+
+// schemaProps returns the attributes of a schema or field which are not
+// defined by the Avro specification, or nil when there are none.
+func schemaProps(schemaMap map[string]interface{}) map[string]interface{} {
+	var props map[string]interface{}
+	for k, v := range schemaMap {
+		if reservedAttributes[k] {
+			continue
+		}
+		if props == nil {
+			props = make(map[string]interface{})
+		}
+		props[k] = v
+	}
+	return props
+}
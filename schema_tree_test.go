@@ -0,0 +1,146 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+
+package goavro
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSchemaTree(t *testing.T) {
+	c, err := NewCodec(`{"type":"record","name":"user","namespace":"com.example","doc":"a user","fields":[
+		{"name":"id","type":{"type":"long","logicalType":"timestamp-millis"}},
+		{"name":"nick","aliases":["handle"],"type":["null","string"],"default":null},
+		{"name":"color","type":{"type":"enum","name":"color","symbols":["RED","GREEN"]}},
+		{"name":"favorite","type":"color"},
+		{"name":"hash","type":{"type":"fixed","name":"md5","namespace":"org.hash","size":16}},
+		{"name":"tags","type":{"type":"array","items":"string"}},
+		{"name":"scores","type":{"type":"map","values":"double"}}]}`)
+	checkErrorFatal(t, err, nil)
+
+	record, ok := c.(SchemaInspector).SchemaTree().(*RecordNode)
+	if !ok {
+		t.Fatalf("Actual: %#v; Expected: %#v", c.(SchemaInspector).SchemaTree(), "*RecordNode")
+	}
+	if record.Name != "com.example.user" || record.Namespace != "com.example" || record.Doc != "a user" || record.Kind() != "record" {
+		t.Errorf("Actual: %#v; Expected: %#v", record, "com.example.user")
+	}
+	var kinds []string
+	for _, field := range record.Fields {
+		kinds = append(kinds, field.Type.Kind())
+	}
+	if expected := []string{"long", "union", "enum", "enum", "fixed", "array", "map"}; !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", kinds, expected)
+	}
+
+	if actual, expected := record.Fields[0].Type.(*PrimitiveNode).LogicalType, "timestamp-millis"; actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+	nick := record.Fields[1]
+	if !nick.HasDefault || nick.Default != nil || !reflect.DeepEqual(nick.Aliases, []string{"handle"}) {
+		t.Errorf("Actual: %#v; Expected: %#v", nick, "nullable field with null default")
+	}
+	if actual, expected := nick.Type.(*UnionNode).Members[1].Kind(), "string"; actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+	color := record.Fields[2].Type.(*EnumNode)
+	if color.Name != "com.example.color" || !reflect.DeepEqual(color.Symbols, []string{"RED", "GREEN"}) {
+		t.Errorf("Actual: %#v; Expected: %#v", color, "com.example.color")
+	}
+	if record.Fields[3].Type != SchemaNode(color) {
+		t.Errorf("Actual: %#v; Expected: %#v", record.Fields[3].Type, color)
+	}
+	if hash := record.Fields[4].Type.(*FixedNode); hash.Name != "org.hash.md5" || hash.Size != 16 {
+		t.Errorf("Actual: %#v; Expected: %#v", hash, "org.hash.md5")
+	}
+	if actual, expected := record.Fields[5].Type.(*ArrayNode).Items.Kind(), "string"; actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+	if actual, expected := record.Fields[6].Type.(*MapNode).Values.Kind(), "double"; actual != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+	if record.Fields[0].HasDefault {
+		t.Errorf("Actual: %#v; Expected: %#v", record.Fields[0].HasDefault, false)
+	}
+}
+
+func TestSchemaTreeProps(t *testing.T) {
+	c, err := NewCodec(`{"type":"record","name":"user","doc":"a user","owner":"team-a","fields":[
+		{"name":"email","type":"string","doc":"contact address","pii":true,"default":""},
+		{"name":"hash","type":{"type":"fixed","name":"md5","size":16,"doc":"digest","algorithm":"md5"}},
+		{"name":"level","type":{"type":"enum","name":"level","symbols":["LOW"],"ui":{"color":"red"}}},
+		{"name":"scores","type":{"type":"array","items":{"type":"int","unit":"points"},"max":10}},
+		{"name":"n","type":"int"}]}`)
+	checkErrorFatal(t, err, nil)
+	record := c.(SchemaInspector).SchemaTree().(*RecordNode)
+
+	if expected := map[string]interface{}{"owner": "team-a"}; !reflect.DeepEqual(record.Props, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", record.Props, expected)
+	}
+	email := record.Fields[0]
+	if email.Doc != "contact address" || !reflect.DeepEqual(email.Props, map[string]interface{}{"pii": true}) {
+		t.Errorf("Actual: %#v; Expected: %#v", email, "documented pii field")
+	}
+	hash := record.Fields[1].Type.(*FixedNode)
+	if hash.Doc != "digest" || !reflect.DeepEqual(hash.Props, map[string]interface{}{"algorithm": "md5"}) {
+		t.Errorf("Actual: %#v; Expected: %#v", hash, "documented fixed")
+	}
+	level := record.Fields[2].Type.(*EnumNode)
+	if expected := map[string]interface{}{"ui": map[string]interface{}{"color": "red"}}; !reflect.DeepEqual(level.Props, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", level.Props, expected)
+	}
+	scores := record.Fields[3].Type.(*ArrayNode)
+	if expected := map[string]interface{}{"max": float64(10)}; !reflect.DeepEqual(scores.Props, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", scores.Props, expected)
+	}
+	if expected := map[string]interface{}{"unit": "points"}; !reflect.DeepEqual(scores.Items.(*PrimitiveNode).Props, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", scores.Items.(*PrimitiveNode).Props, expected)
+	}
+	if props := record.Fields[4].Props; props != nil {
+		t.Errorf("Actual: %#v; Expected: %#v", props, nil)
+	}
+}
+
+func TestCodecKindAndName(t *testing.T) {
+	cases := []struct {
+		schema string
+		kind   string
+		name   string
+	}{
+		{`"int"`, "int", ""},
+		{`{"type":"long","logicalType":"timestamp-millis"}`, "long", ""},
+		{`{"type":"array","items":"string"}`, "array", ""},
+		{`{"type":"map","values":"int"}`, "map", ""},
+		{`["null","string"]`, "union", ""},
+		{`{"type":"record","name":"r","namespace":"com.example","fields":[{"name":"f","type":"int"}]}`, "record", "com.example.r"},
+		{`{"type":"enum","name":"e","symbols":["A","B"]}`, "enum", "e"},
+		{`{"type":"fixed","name":"com.example.md5","size":16}`, "fixed", "com.example.md5"},
+	}
+	for _, c := range cases {
+		codec, err := NewCodec(c.schema)
+		checkErrorFatal(t, err, nil)
+		if actual := codec.(SchemaInspector).Kind(); actual != c.kind {
+			t.Errorf("Actual: %#v; Expected: %#v", actual, c.kind)
+		}
+		name, ok := codec.(SchemaInspector).Name()
+		if name != c.name || ok != (c.name != "") {
+			t.Errorf("Actual: %#v, %#v; Expected: %#v", name, ok, c.name)
+		}
+	}
+}
@@ -0,0 +1,452 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+
+package goavro
+
+import (
+	"io"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// structTag is the key of the struct field tag used to name the Avro
+// record field corresponding to a Go struct field, for instance
+// `avro:"user_name"`. A tag of `avro:"-"` causes the struct field to be
+// ignored.
+const structTag = "avro"
+
+// DecodeInto will read from the specified io.Reader, and store the
+// next datum from the stream into the value pointed to by out.
+//
+// Records are stored into structs by matching each record field to
+// the exported struct field whose `avro` tag names it, or failing
+// that, whose name matches it without regard to case. Arrays are
+// stored into slices, maps into maps with string keys, and a union of
+// null and another type into a pointer, which is nil when the datum is
+// null. Enums are stored into strings, and fixed values into byte
+// slices or arrays.
+//
+//   type User struct {
+//       Name    string  `avro:"name"`
+//       Email   *string `avro:"email"` // ["null","string"]
+//       Friends []string
+//   }
+//
+//   var user User
+//   if err := codec.DecodeInto(r, &user); err != nil {
+//       return err
+//   }
+func (c codec) DecodeInto(r io.Reader, out interface{}) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return newDecoderError(c.schemaName(), "expected: non-nil pointer; received: %T", out)
+	}
+	datum, err := c.Decode(r)
+	if err != nil {
+		return err
+	}
+	if err = storeNative(rv.Elem(), datum); err != nil {
+		if ed, ok := err.(*ErrDecoder); ok {
+			ed.SchemaName = c.schemaName()
+		}
+		return err
+	}
+	return nil
+}
+
+// structFieldIndex returns the index of the field of the specified
+// struct type that corresponds to the named record field, or -1 when
+// there is none.
+func structFieldIndex(t reflect.Type, recordFieldName string) int {
+	fold := -1
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue // unexported
+		}
+		tag := sf.Tag.Get(structTag)
+		if tag == "-" {
+			continue
+		}
+		if tag != "" {
+			if tag == recordFieldName {
+				return i
+			}
+			continue
+		}
+		if sf.Name == recordFieldName {
+			return i
+		}
+		if fold == -1 && strings.EqualFold(sf.Name, recordFieldName) {
+			fold = i
+		}
+	}
+	return fold
+}
+
+// storeNative stores the datum returned by a decoder into the
+// specified settable value.
+func storeNative(dst reflect.Value, datum interface{}) error {
+	if uv, ok := datum.(UnionValue); ok && dst.Type() != reflect.TypeOf(uv) {
+		datum = uv.Value
+	}
+	if datum == nil {
+		switch dst.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+			dst.Set(reflect.Zero(dst.Type()))
+			return nil
+		}
+		return newDecoderError("native", "cannot store null into %s", dst.Type())
+	}
+	if dv := reflect.ValueOf(datum); dv.Type().AssignableTo(dst.Type()) {
+		dst.Set(dv)
+		return nil
+	}
+	if dst.Kind() == reflect.Ptr {
+		elem := reflect.New(dst.Type().Elem())
+		if err := storeNative(elem.Elem(), datum); err != nil {
+			return err
+		}
+		dst.Set(elem)
+		return nil
+	}
+
+	switch v := datum.(type) {
+	case *Record:
+		if dst.Kind() != reflect.Struct {
+			break
+		}
+		for _, field := range v.Fields {
+			basename := name{n: field.Name}.basename()
+			idx := structFieldIndex(dst.Type(), basename)
+			if idx == -1 {
+				continue
+			}
+			if err := storeNative(dst.Field(idx), field.Datum); err != nil {
+				return newDecoderPathError("record ("+v.Name+")", basename, err)
+			}
+		}
+		return nil
+	case OrderedMap:
+		if dst.Kind() != reflect.Struct {
+			break
+		}
+		for _, kv := range v {
+			idx := structFieldIndex(dst.Type(), kv.Key)
+			if idx == -1 {
+				continue
+			}
+			if err := storeNative(dst.Field(idx), kv.Val); err != nil {
+				return newDecoderPathError("record", kv.Key, err)
+			}
+		}
+		return nil
+	case []interface{}:
+		if dst.Kind() != reflect.Slice {
+			break
+		}
+		slice := reflect.MakeSlice(dst.Type(), len(v), len(v))
+		for idx, item := range v {
+			if err := storeNative(slice.Index(idx), item); err != nil {
+				return newDecoderPathError("array", itemPath("", idx), err)
+			}
+		}
+		dst.Set(slice)
+		return nil
+	case map[string]interface{}:
+		if dst.Kind() != reflect.Map || dst.Type().Key().Kind() != reflect.String {
+			break
+		}
+		dict := reflect.MakeMap(dst.Type())
+		for k, item := range v {
+			value := reflect.New(dst.Type().Elem()).Elem()
+			if err := storeNative(value, item); err != nil {
+				return newDecoderPathError("map", itemPath("", k), err)
+			}
+			dict.SetMapIndex(reflect.ValueOf(k).Convert(dst.Type().Key()), value)
+		}
+		dst.Set(dict)
+		return nil
+	case Enum:
+		if dst.Kind() == reflect.String {
+			dst.SetString(v.Value)
+			return nil
+		}
+	case Fixed:
+		return storeNative(dst, v.Value)
+	case []byte:
+		if dst.Kind() == reflect.Array && dst.Type().Elem().Kind() == reflect.Uint8 && dst.Len() == len(v) {
+			reflect.Copy(dst, reflect.ValueOf(v))
+			return nil
+		}
+	case bool:
+		if dst.Kind() == reflect.Bool {
+			dst.SetBool(v)
+			return nil
+		}
+	case string:
+		if dst.Kind() == reflect.String {
+			dst.SetString(v)
+			return nil
+		}
+	case int32:
+		return storeNativeInt(dst, int64(v), datum)
+	case int64:
+		return storeNativeInt(dst, v, datum)
+	case float32:
+		return storeNativeFloat(dst, float64(v), datum)
+	case float64:
+		return storeNativeFloat(dst, v, datum)
+	}
+	return newDecoderError("native", "cannot store %T into %s", datum, dst.Type())
+}
+
+func storeNativeInt(dst reflect.Value, someInt int64, datum interface{}) error {
+	switch dst.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if dst.OverflowInt(someInt) {
+			return newDecoderError("native", "value %d overflows %s", someInt, dst.Type())
+		}
+		dst.SetInt(someInt)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if someInt < 0 || dst.OverflowUint(uint64(someInt)) {
+			return newDecoderError("native", "value %d overflows %s", someInt, dst.Type())
+		}
+		dst.SetUint(uint64(someInt))
+		return nil
+	case reflect.Float32, reflect.Float64:
+		dst.SetFloat(float64(someInt))
+		return nil
+	}
+	return newDecoderError("native", "cannot store %T into %s", datum, dst.Type())
+}
+
+func storeNativeFloat(dst reflect.Value, someFloat float64, datum interface{}) error {
+	switch dst.Kind() {
+	case reflect.Float32, reflect.Float64:
+		dst.SetFloat(someFloat)
+		return nil
+	}
+	return newDecoderError("native", "cannot store %T into %s", datum, dst.Type())
+}
+
+// nativeDatum converts a datum returned by a decoder into plain Go
+// values: records become maps keyed by field name, enums their symbol,
+// and fixed values their bytes.
+func nativeDatum(datum interface{}) interface{} {
+	switch v := datum.(type) {
+	case *Record:
+		fields := make(map[string]interface{}, len(v.Fields))
+		for _, field := range v.Fields {
+			fields[name{n: field.Name}.basename()] = nativeDatum(field.Datum)
+		}
+		return fields
+	case OrderedMap:
+		fields := make(OrderedMap, len(v))
+		for idx, kv := range v {
+			fields[idx] = KeyVal{kv.Key, nativeDatum(kv.Val)}
+		}
+		return fields
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for idx, item := range v {
+			items[idx] = nativeDatum(item)
+		}
+		return items
+	case map[string]interface{}:
+		values := make(map[string]interface{}, len(v))
+		for k, value := range v {
+			values[k] = nativeDatum(value)
+		}
+		return values
+	case Enum:
+		return v.Value
+	case Fixed:
+		return v.Value
+	case UnionValue:
+		return nativeDatum(v.Value)
+	}
+	return datum
+}
+
+// EncodeStruct will write the Avro binary encoding of the specified Go
+// value to the io.Writer, converting it to the datum the codec would
+// otherwise expect to receive.
+//
+// Structs are converted into records using the same field matching as
+// DecodeInto. Record fields without a corresponding struct field are
+// encoded using their default, and a struct type missing any field that
+// lacks a default is rejected the first time it is encoded. A nil
+// pointer encodes as null for unions that include null, strings encode
+// as enum symbols, and byte slices or arrays as fixed values.
+//
+//   user := User{Name: "Homer", Friends: []string{"Lenny", "Carl"}}
+//   if err := codec.EncodeStruct(w, user); err != nil {
+//       return err
+//   }
+func (c codec) EncodeStruct(w io.Writer, in interface{}) error {
+	datum, err := c.nf(reflect.ValueOf(in))
+	if err != nil {
+		ee, ok := err.(*ErrEncoder)
+		if !ok {
+			ee = newEncoderError(c.schemaName(), err)
+		}
+		if ee.SchemaName == "" {
+			ee.SchemaName = c.schemaName()
+		}
+		return ee
+	}
+	return c.Encode(w, datum)
+}
+
+// indirectNative follows pointers and interfaces to the value they
+// refer to, returning the zero Value when any of them is nil.
+func indirectNative(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// nativeMemberNames returns the names of the union members, in order of
+// preference, which hold a numeric value of the Go kind of v without
+// loss, as Encode prefers them for a datum of that kind.
+func nativeMemberNames(v reflect.Value) []string {
+	switch indirectNative(v).Kind() {
+	case reflect.Float32:
+		return []string{"float32", "float64"}
+	case reflect.Float64:
+		return []string{"float64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return []string{"int32", "int64"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return []string{"int64"}
+	}
+	return nil
+}
+
+func nativeInterface(v reflect.Value) interface{} {
+	if !v.IsValid() || !v.CanInterface() {
+		return nil
+	}
+	return v.Interface()
+}
+
+func nativeTypeName(v reflect.Value) string {
+	if !v.IsValid() {
+		return "nil"
+	}
+	return v.Type().String()
+}
+
+func nativeBytes(v reflect.Value) ([]byte, bool) {
+	if !v.IsValid() || (v.Kind() != reflect.Slice && v.Kind() != reflect.Array) || v.Type().Elem().Kind() != reflect.Uint8 {
+		return nil, false
+	}
+	if v.Kind() == reflect.Slice {
+		return v.Bytes(), true
+	}
+	buf := make([]byte, v.Len())
+	reflect.Copy(reflect.ValueOf(buf), v)
+	return buf, true
+}
+
+func nativeInt(v reflect.Value) (int64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(v.Uint()), true
+	}
+	return 0, false
+}
+
+func nullNative(v reflect.Value) (interface{}, error) {
+	if v = indirectNative(v); v.IsValid() {
+		return nil, newEncoderError("null", "expected: nil; received: %s", v.Type())
+	}
+	return nil, nil
+}
+
+func booleanNative(v reflect.Value) (interface{}, error) {
+	if v = indirectNative(v); !v.IsValid() || v.Kind() != reflect.Bool {
+		return nil, newEncoderError("boolean", "expected: bool; received: %s", nativeTypeName(v))
+	}
+	return v.Bool(), nil
+}
+
+func intNative(v reflect.Value) (interface{}, error) {
+	v = indirectNative(v)
+	if !v.IsValid() {
+		return nil, newEncoderError("int", "expected: integer; received: nil")
+	}
+	someInt, ok := nativeInt(v)
+	if !ok || someInt < math.MinInt32 || someInt > math.MaxInt32 {
+		return nil, newEncoderError("int", "expected: integer in int32 range; received: %s", v.Type())
+	}
+	return int32(someInt), nil
+}
+
+func longNative(v reflect.Value) (interface{}, error) {
+	v = indirectNative(v)
+	if !v.IsValid() {
+		return nil, newEncoderError("long", "expected: integer; received: nil")
+	}
+	someInt, ok := nativeInt(v)
+	if !ok {
+		return nil, newEncoderError("long", "expected: integer in int64 range; received: %s", v.Type())
+	}
+	return someInt, nil
+}
+
+func floatNative(v reflect.Value) (interface{}, error) {
+	if v = indirectNative(v); !v.IsValid() || (v.Kind() != reflect.Float32 && v.Kind() != reflect.Float64) {
+		return nil, newEncoderError("float", "expected: float; received: %s", nativeTypeName(v))
+	}
+	return float32(v.Float()), nil
+}
+
+func doubleNative(v reflect.Value) (interface{}, error) {
+	if v = indirectNative(v); !v.IsValid() || (v.Kind() != reflect.Float32 && v.Kind() != reflect.Float64) {
+		return nil, newEncoderError("double", "expected: float; received: %s", nativeTypeName(v))
+	}
+	return v.Float(), nil
+}
+
+func bytesNative(v reflect.Value) (interface{}, error) {
+	buf, ok := nativeBytes(indirectNative(v))
+	if !ok {
+		return nil, newEncoderError("bytes", "expected: []byte; received: %s", nativeTypeName(v))
+	}
+	return buf, nil
+}
+
+func stringNative(v reflect.Value) (interface{}, error) {
+	if v = indirectNative(v); !v.IsValid() || v.Kind() != reflect.String {
+		return nil, newEncoderError("string", "expected: string; received: %s", nativeTypeName(v))
+	}
+	return v.String(), nil
+}
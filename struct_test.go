@@ -0,0 +1,241 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+
+package goavro
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestCodecDecodeIntoStruct(t *testing.T) {
+	type address struct {
+		City string `avro:"city"`
+		Zip  int    `avro:"zip"`
+	}
+	type user struct {
+		Name    string            `avro:"name"`
+		Email   *string           `avro:"email"`
+		Age     int64             // matched without regard to case
+		Tags    []string          `avro:"tags"`
+		Scores  map[string]int32  `avro:"scores"`
+		Home    address           `avro:"home"`
+		Work    *address          `avro:"work"`
+		Color   string            `avro:"color"`
+		Ignored string            `avro:"-"`
+		Extra   map[string]string `avro:"extra"`
+	}
+	schema := `{"type":"record","name":"user","fields":[
+{"name":"name","type":"string"},
+{"name":"email","type":["null","string"]},
+{"name":"age","type":"long"},
+{"name":"tags","type":{"type":"array","items":"string"}},
+{"name":"scores","type":{"type":"map","values":"int"}},
+{"name":"home","type":{"type":"record","name":"address","fields":[{"name":"city","type":"string"},{"name":"zip","type":"int"}]}},
+{"name":"work","type":["null","address"]},
+{"name":"color","type":{"type":"enum","name":"color","symbols":["RED","GREEN"]}}]}`
+	codec, err := NewCodec(schema)
+	checkErrorFatal(t, err, nil)
+
+	home, err := NewRecord(RecordSchema(`{"type":"record","name":"address","fields":[{"name":"city","type":"string"},{"name":"zip","type":"int"}]}`))
+	checkErrorFatal(t, err, nil)
+	home.Set("city", "Springfield")
+	home.Set("zip", int32(12345))
+
+	rec, err := NewRecord(RecordSchema(schema))
+	checkErrorFatal(t, err, nil)
+	rec.Set("name", "Homer")
+	rec.Set("email", "homer@example.com")
+	rec.Set("age", int64(39))
+	rec.Set("tags", []interface{}{"dad", "safety"})
+	rec.Set("scores", map[string]interface{}{"bowling": int32(200)})
+	rec.Set("home", home)
+	rec.Set("work", nil)
+	rec.Set("color", Enum{Name: "color", Value: "GREEN"})
+
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, codec.Encode(bb, rec), nil)
+
+	var u user
+	u.Ignored = "untouched"
+	u.Work = &address{City: "stale"}
+	checkErrorFatal(t, codec.(StructCodec).DecodeInto(bb, &u), nil)
+
+	if u.Name != "Homer" || u.Email == nil || *u.Email != "homer@example.com" || u.Age != 39 {
+		t.Errorf("Actual: %#v", u)
+	}
+	if len(u.Tags) != 2 || u.Tags[0] != "dad" || u.Tags[1] != "safety" {
+		t.Errorf("Actual: %#v; Expected: %#v", u.Tags, []string{"dad", "safety"})
+	}
+	if u.Scores["bowling"] != 200 {
+		t.Errorf("Actual: %#v; Expected: %#v", u.Scores["bowling"], 200)
+	}
+	if u.Home.City != "Springfield" || u.Home.Zip != 12345 {
+		t.Errorf("Actual: %#v", u.Home)
+	}
+	if u.Work != nil {
+		t.Errorf("Actual: %#v; Expected: %#v", u.Work, nil)
+	}
+	if u.Color != "GREEN" {
+		t.Errorf("Actual: %#v; Expected: %#v", u.Color, "GREEN")
+	}
+	if u.Ignored != "untouched" {
+		t.Errorf("Actual: %#v; Expected: %#v", u.Ignored, "untouched")
+	}
+}
+
+func TestCodecDecodeIntoErrors(t *testing.T) {
+	codec, err := NewCodec(`{"type":"record","name":"r","fields":[{"name":"n","type":"int"}]}`)
+	checkErrorFatal(t, err, nil)
+
+	var s struct {
+		N int8 `avro:"n"`
+	}
+	err = codec.(StructCodec).DecodeInto(bytes.NewReader([]byte("\x02")), s)
+	checkError(t, err, "expected: non-nil pointer")
+
+	// 300 zig-zag encoded
+	err = codec.(StructCodec).DecodeInto(bytes.NewReader([]byte("\xd8\x04")), &s)
+	checkError(t, err, "at n: cannot decode native: value 300 overflows int8")
+
+	var b struct {
+		N bool `avro:"n"`
+	}
+	err = codec.(StructCodec).DecodeInto(bytes.NewReader([]byte("\x02")), &b)
+	checkError(t, err, "at n: cannot decode native: cannot store int32 into bool")
+}
+
+func TestCodecEncodeStruct(t *testing.T) {
+	type address struct {
+		City string `avro:"city"`
+	}
+	type user struct {
+		Name   string           `avro:"name"`
+		Email  *string          `avro:"email"`
+		Age    int              // matched without regard to case
+		Tags   []string         `avro:"tags"`
+		Scores map[string]int32 `avro:"scores"`
+		Work   *address         `avro:"work"`
+		Color  string           `avro:"color"`
+		Hash   [2]byte          `avro:"hash"`
+	}
+	schema := `{"type":"record","name":"user","fields":[
+{"name":"name","type":"string"},
+{"name":"email","type":["null","string"]},
+{"name":"age","type":"long"},
+{"name":"tags","type":{"type":"array","items":"string"}},
+{"name":"scores","type":{"type":"map","values":"int"}},
+{"name":"work","type":["null",{"type":"record","name":"address","fields":[{"name":"city","type":"string"}]}]},
+{"name":"color","type":{"type":"enum","name":"color","symbols":["RED","GREEN"]}},
+{"name":"hash","type":{"type":"fixed","name":"hash","size":2}},
+{"name":"active","type":"boolean","default":true}]}`
+	codec, err := NewCodec(schema)
+	checkErrorFatal(t, err, nil)
+
+	email := "homer@example.com"
+	in := user{
+		Name:   "Homer",
+		Email:  &email,
+		Age:    39,
+		Tags:   []string{"dad"},
+		Scores: map[string]int32{"bowling": 200},
+		Color:  "GREEN",
+		Hash:   [2]byte{0xca, 0xfe},
+	}
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, codec.(StructCodec).EncodeStruct(bb, &in), nil)
+
+	datum, err := codec.Decode(bb)
+	checkErrorFatal(t, err, nil)
+	expected := map[string]interface{}{
+		"name":   "Homer",
+		"email":  "homer@example.com",
+		"age":    int64(39),
+		"tags":   []interface{}{"dad"},
+		"scores": map[string]interface{}{"bowling": int32(200)},
+		"work":   nil,
+		"color":  "GREEN",
+		"hash":   []byte{0xca, 0xfe},
+		"active": true,
+	}
+	if actual := nativeDatum(datum); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+
+	// round trip through DecodeInto
+	in.Work = &address{City: "Springfield"}
+	bb.Reset()
+	checkErrorFatal(t, codec.(StructCodec).EncodeStruct(bb, in), nil)
+	var out user
+	checkErrorFatal(t, codec.(StructCodec).DecodeInto(bb, &out), nil)
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("Actual: %#v; Expected: %#v", out, in)
+	}
+}
+
+func TestCodecEncodeStructUnionNumbers(t *testing.T) {
+	type numbers struct {
+		Ratio float64 `avro:"ratio"`
+		Small float32 `avro:"small"`
+		Count int64   `avro:"count"`
+		Index int16   `avro:"index"`
+	}
+	codec, err := NewCodec(`{"type":"record","name":"numbers","fields":[
+{"name":"ratio","type":["null","float","double"]},
+{"name":"small","type":["null","double","float"]},
+{"name":"count","type":["int","long"]},
+{"name":"index","type":["long","int"]}]}`)
+	checkErrorFatal(t, err, nil)
+
+	in := numbers{Ratio: 0.1, Small: 0.5, Count: 1 << 40, Index: 7}
+	bb := new(bytes.Buffer)
+	checkErrorFatal(t, codec.(StructCodec).EncodeStruct(bb, in), nil)
+	// each value encodes as the member of its own kind, without loss
+	expected := new(bytes.Buffer)
+	checkErrorFatal(t, codec.Encode(expected, map[string]interface{}{"ratio": 0.1, "small": float32(0.5), "count": int64(1 << 40), "index": int32(7)}), nil)
+	if !bytes.Equal(bb.Bytes(), expected.Bytes()) {
+		t.Errorf("Actual: %#v; Expected: %#v", bb.Bytes(), expected.Bytes())
+	}
+	var out numbers
+	checkErrorFatal(t, codec.(StructCodec).DecodeInto(bb, &out), nil)
+	if out != in {
+		t.Errorf("Actual: %#v; Expected: %#v", out, in)
+	}
+}
+
+func TestCodecEncodeStructErrors(t *testing.T) {
+	codec, err := NewCodec(`{"type":"record","name":"r","fields":[{"name":"n","type":"int"},{"name":"e","type":{"type":"enum","name":"e","symbols":["A"]}}]}`)
+	checkErrorFatal(t, err, nil)
+
+	var missing struct {
+		N int `avro:"n"`
+	}
+	err = codec.(StructCodec).EncodeStruct(new(bytes.Buffer), missing)
+	checkError(t, err, "has no field for e, which has no default")
+
+	type wide struct {
+		N int64  `avro:"n"`
+		E string `avro:"e"`
+	}
+	err = codec.(StructCodec).EncodeStruct(new(bytes.Buffer), wide{N: 1 << 40, E: "A"})
+	checkError(t, err, "cannot encode record (r) at n: cannot encode int: expected: integer in int32 range")
+
+	err = codec.(StructCodec).EncodeStruct(new(bytes.Buffer), wide{N: 1, E: "B"})
+	checkError(t, err, "at e: cannot encode enum (e): symbol not defined: B")
+}
@@ -0,0 +1,373 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+
+package goavro
+
+import (
+	"io"
+	"strings"
+)
+
+// Transcode reads data encoded with the from Codec, the writer's
+// schema, from r until it is exhausted, and writes each datum to w
+// encoded with the to Codec, the reader's schema, resolving each datum
+// between the two schemas as the Avro specification describes. Record
+// fields are matched by name or by the aliases of the reader's field;
+// fields the reader lacks are dropped, and fields the writer lacks take
+// the reader's default. Numbers are promoted, and strings and bytes
+// interchanged, as the reader's schema requires. An error is returned
+// before anything is read when the reader's schema cannot read data
+// written with the writer's schema.
+//
+//   err := goavro.Transcode(oldData, oldCodec, newCodec, newData)
+func Transcode(r io.Reader, from, to Codec, w io.Writer) error {
+	readerSchema, err := inlinedSchemaTree(to.Schema())
+	if err != nil {
+		return err
+	}
+	writerSchema, err := inlinedSchemaTree(from.Schema())
+	if err != nil {
+		return err
+	}
+	// symbols the reader lacks are reported when encountered
+	if ok, reasons := checkSchemaCompatibility(readerSchema, writerSchema, true); !ok {
+		return newCodecBuildError("transcode", "reader schema cannot read writer schema: %s", strings.Join(reasons, "; "))
+	}
+	reader := codec{schema: to.Schema()}.SchemaTree()
+	tc := &transcoder{writerEnums: make(map[string][]string)}
+	tc.collectEnums(codec{schema: from.Schema()}.SchemaTree(), make(map[SchemaNode]bool))
+	for {
+		datum, err := from.Decode(r)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		resolved, err := tc.resolveDatum(reader, datum)
+		if err != nil {
+			return err
+		}
+		if err = to.Encode(w, resolved); err != nil {
+			return err
+		}
+	}
+}
+
+// transcoder resolves data decoded with a writer's schema to a
+// reader's schema.
+type transcoder struct {
+	writerEnums map[string][]string // symbols of the writer's enums by fullname
+}
+
+// collectEnums records the symbols of the enums of the writer's schema.
+func (tc *transcoder) collectEnums(node SchemaNode, visited map[SchemaNode]bool) {
+	if node == nil || visited[node] {
+		return
+	}
+	visited[node] = true
+	switch n := node.(type) {
+	case *EnumNode:
+		tc.writerEnums[n.Name] = n.Symbols
+	case *RecordNode:
+		for _, field := range n.Fields {
+			tc.collectEnums(field.Type, visited)
+		}
+	case *ArrayNode:
+		tc.collectEnums(n.Items, visited)
+	case *MapNode:
+		tc.collectEnums(n.Values, visited)
+	case *UnionNode:
+		for _, member := range n.Members {
+			tc.collectEnums(member, visited)
+		}
+	}
+}
+
+// resolveDatum returns datum, decoded with the writer's schema, as the
+// reader's schema, described by node, would have decoded it.
+func (tc *transcoder) resolveDatum(node SchemaNode, datum interface{}) (interface{}, error) {
+	switch n := node.(type) {
+	case *UnionNode:
+		return tc.resolveUnionDatum(n, datum)
+	case *PrimitiveNode:
+		if resolved, ok := promoteDatum(n.Type, datum, true); ok {
+			return resolved, nil
+		}
+		return nil, newEncoderError(n.Type, "cannot resolve %T", datum)
+	case *RecordNode:
+		return tc.resolveRecordDatum(n, datum)
+	case *EnumNode:
+		return tc.resolveEnumDatum(n, datum)
+	case *FixedNode:
+		switch v := datum.(type) {
+		case Fixed:
+			return Fixed{Name: n.Name, Value: v.Value}, nil
+		case []byte:
+			return Fixed{Name: n.Name, Value: v}, nil
+		}
+		return nil, newEncoderError(n.Name, "cannot resolve %T", datum)
+	case *ArrayNode:
+		items, ok := datum.([]interface{})
+		if !ok {
+			return nil, newEncoderError("array", "cannot resolve %T", datum)
+		}
+		resolved := make([]interface{}, len(items))
+		for idx, item := range items {
+			value, err := tc.resolveDatum(n.Items, item)
+			if err != nil {
+				return nil, newEncoderPathError("array", itemPath("", idx), err)
+			}
+			resolved[idx] = value
+		}
+		return resolved, nil
+	case *MapNode:
+		values, ok := datum.(map[string]interface{})
+		if !ok {
+			return nil, newEncoderError("map", "cannot resolve %T", datum)
+		}
+		resolved := make(map[string]interface{}, len(values))
+		for key, item := range values {
+			value, err := tc.resolveDatum(n.Values, item)
+			if err != nil {
+				return nil, newEncoderPathError("map", itemPath("", key), err)
+			}
+			resolved[key] = value
+		}
+		return resolved, nil
+	}
+	return nil, newEncoderError("transcode", "unknown schema node: %T", node)
+}
+
+// resolveEnumDatum returns the symbol of the writer's enum datum, or
+// the reader's default when the reader's enum lacks the symbol.
+func (tc *transcoder) resolveEnumDatum(n *EnumNode, datum interface{}) (interface{}, error) {
+	someEnum, ok := datum.(Enum)
+	if !ok {
+		return nil, newEncoderError(n.Name, "cannot resolve %T", datum)
+	}
+	for _, symbol := range n.Symbols {
+		if symbol == someEnum.Value {
+			return Enum{n.Name, symbol}, nil
+		}
+	}
+	if n.Default != "" {
+		return Enum{n.Name, n.Default}, nil
+	}
+	index := -1
+	for idx, symbol := range tc.writerEnums[someEnum.Name] {
+		if symbol == someEnum.Value {
+			index = idx
+		}
+	}
+	return nil, newEncoderError(n.Name, "writer symbol %q at index %d of enum %s is not one of reader symbols, and the reader has no default: %s", someEnum.Value, index, someEnum.Name, strings.Join(n.Symbols, ", "))
+}
+
+// resolveUnionDatum resolves datum to the first member of the reader's
+// union which takes it as is, or else to the first which takes it
+// after promotion, and returns it as a UnionValue naming that member.
+func (tc *transcoder) resolveUnionDatum(n *UnionNode, datum interface{}) (interface{}, error) {
+	if uv, ok := datum.(UnionValue); ok {
+		datum = uv.Value
+	}
+	if datum == nil {
+		for _, member := range n.Members {
+			if member.Kind() == "null" {
+				// a bare nil would let a record field take its default
+				return Union("null", nil), nil
+			}
+		}
+		return nil, newEncoderError("union", "cannot resolve null")
+	}
+	for _, exact := range []bool{true, false} {
+		for _, member := range n.Members {
+			if !memberTakes(member, datum, exact) {
+				continue
+			}
+			resolved, err := tc.resolveDatum(member, datum)
+			if err != nil {
+				return nil, err
+			}
+			return Union(unionNodeTypeName(member), resolved), nil
+		}
+	}
+	return nil, newEncoderError("union", "no member of reader union resolves %T", datum)
+}
+
+// memberTakes returns whether member of a reader's union matches the
+// writer's datum, either exactly or, when exact is false, after
+// promotion.
+func memberTakes(member SchemaNode, datum interface{}, exact bool) bool {
+	switch n := member.(type) {
+	case *PrimitiveNode:
+		_, ok := promoteDatum(n.Type, datum, !exact)
+		return ok
+	case *RecordNode:
+		var writerName string
+		switch v := datum.(type) {
+		case *Record:
+			writerName = v.Name
+		case map[string]interface{}, OrderedMap:
+			return true
+		default:
+			return false
+		}
+		return namedNodeMatches(n.Name, n.Aliases, writerName)
+	case *EnumNode:
+		v, ok := datum.(Enum)
+		return ok && namedNodeMatches(n.Name, n.Aliases, v.Name)
+	case *FixedNode:
+		v, ok := datum.(Fixed)
+		return ok && namedNodeMatches(n.Name, n.Aliases, v.Name)
+	case *ArrayNode:
+		_, ok := datum.([]interface{})
+		return ok
+	case *MapNode:
+		_, ok := datum.(map[string]interface{})
+		return ok
+	}
+	return false
+}
+
+// namedNodeMatches returns whether a named type of the reader, with
+// the specified fullname and aliases, matches the writer's fullname.
+func namedNodeMatches(readerName string, aliases []string, writerName string) bool {
+	if readerName == writerName {
+		return true
+	}
+	for _, alias := range aliases {
+		nm, err := newName(nameName(alias), nameEnclosingNamespace(name{n: readerName}.namespace()))
+		if err == nil && nm.n == writerName {
+			return true
+		}
+	}
+	return false
+}
+
+// unionNodeTypeName returns the name by which Union selects member.
+func unionNodeTypeName(member SchemaNode) string {
+	switch n := member.(type) {
+	case *RecordNode:
+		return n.Name
+	case *EnumNode:
+		return n.Name
+	case *FixedNode:
+		return n.Name
+	}
+	return member.Kind()
+}
+
+// promoteDatum returns datum as the primitive type typeName, allowing
+// the promotions of the Avro specification when promote is true, and
+// whether datum is of or promotes to that type.
+func promoteDatum(typeName string, datum interface{}, promote bool) (interface{}, bool) {
+	switch typeName {
+	case "null":
+		return nil, datum == nil
+	case "boolean":
+		v, ok := datum.(bool)
+		return v, ok
+	case "int":
+		v, ok := datum.(int32)
+		return v, ok
+	case "long":
+		switch v := datum.(type) {
+		case int64:
+			return v, true
+		case int32:
+			return int64(v), promote
+		}
+	case "float":
+		switch v := datum.(type) {
+		case float32:
+			return v, true
+		case int32:
+			return float32(v), promote
+		case int64:
+			return float32(v), promote
+		}
+	case "double":
+		switch v := datum.(type) {
+		case float64:
+			return v, true
+		case int32:
+			return float64(v), promote
+		case int64:
+			return float64(v), promote
+		case float32:
+			return float64(v), promote
+		}
+	case "bytes":
+		switch v := datum.(type) {
+		case []byte:
+			return v, true
+		case string:
+			return []byte(v), promote
+		}
+	case "string":
+		switch v := datum.(type) {
+		case string:
+			return v, true
+		case []byte:
+			return string(v), promote
+		}
+	}
+	return nil, false
+}
+
+// resolveRecordDatum returns the fields of the writer's record datum
+// which the reader's record has, keyed by the reader's field names, so
+// that the reader's defaults apply to the rest when it is encoded. A
+// writer field holding null is carried through as null, and not
+// replaced by the reader's default.
+func (tc *transcoder) resolveRecordDatum(n *RecordNode, datum interface{}) (interface{}, error) {
+	writerFields := make(map[string]interface{})
+	switch v := datum.(type) {
+	case *Record:
+		for _, field := range v.Fields {
+			writerFields[name{n: field.Name}.basename()] = field.Datum
+		}
+	case OrderedMap:
+		for _, kv := range v {
+			writerFields[kv.Key] = kv.Val
+		}
+	case map[string]interface{}:
+		writerFields = v
+	default:
+		return nil, newEncoderError(n.Name, "cannot resolve %T", datum)
+	}
+	resolved := make(map[string]interface{}, len(n.Fields))
+	for _, field := range n.Fields {
+		value, ok := writerFields[field.Name]
+		for _, alias := range field.Aliases {
+			if ok {
+				break
+			}
+			value, ok = writerFields[alias]
+		}
+		if !ok {
+			continue // reader default applies
+		}
+		resolvedValue, err := tc.resolveDatum(field.Type, value)
+		if err != nil {
+			return nil, newEncoderPathError(n.Name, field.Name, err)
+		}
+		resolved[field.Name] = resolvedValue
+	}
+	return resolved, nil
+}
@@ -0,0 +1,150 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+
+package goavro
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestTranscode(t *testing.T) {
+	writer, err := NewCodec(`{"type":"record","name":"user","fields":[
+{"name":"id","type":"int"},
+{"name":"name","type":"string"},
+{"name":"rank","type":["null","int"]},
+{"name":"color","type":{"type":"enum","name":"color","symbols":["RED","GREEN"]}},
+{"name":"dropped","type":"string"}]}`)
+	checkErrorFatal(t, err, nil)
+	reader, err := NewCodec(`{"type":"record","name":"user","fields":[
+{"name":"id","type":"long"},
+{"name":"full_name","type":"bytes","aliases":["name"]},
+{"name":"rank","type":["null","double"]},
+{"name":"color","type":{"type":"enum","name":"color","symbols":["BLUE","GREEN","RED"]}},
+{"name":"score","type":"double","default":1.5}]}`)
+	checkErrorFatal(t, err, nil)
+
+	written := new(bytes.Buffer)
+	for _, fields := range []map[string]interface{}{
+		{"id": int32(1), "name": "Alice", "rank": int32(3), "color": "RED", "dropped": "x"},
+		{"id": int32(2), "name": "Bob", "rank": nil, "color": "GREEN", "dropped": "y"},
+	} {
+		checkErrorFatal(t, writer.Encode(written, fields), nil)
+	}
+
+	transcoded := new(bytes.Buffer)
+	checkErrorFatal(t, Transcode(written, writer, reader, transcoded), nil)
+
+	expected := []map[string]interface{}{
+		{"id": int64(1), "full_name": []byte("Alice"), "rank": float64(3), "color": Enum{"color", "RED"}, "score": 1.5},
+		{"id": int64(2), "full_name": []byte("Bob"), "rank": nil, "color": Enum{"color", "GREEN"}, "score": 1.5},
+	}
+	for _, fields := range expected {
+		datum, err := reader.Decode(transcoded)
+		checkErrorFatal(t, err, nil)
+		for name, value := range fields {
+			actual, err := datum.(*Record).Get(name)
+			checkErrorFatal(t, err, nil)
+			if !reflect.DeepEqual(actual, value) {
+				t.Errorf("%s: Actual: %#v; Expected: %#v", name, actual, value)
+			}
+		}
+	}
+	if transcoded.Len() != 0 {
+		t.Errorf("Actual: %#v; Expected: %#v", transcoded.Len(), 0)
+	}
+}
+
+func TestTranscodeUnions(t *testing.T) {
+	writer, err := NewCodec(`["int","string",{"type":"array","items":"int"}]`)
+	checkErrorFatal(t, err, nil)
+	reader, err := NewCodec(`["string","float","long",{"type":"array","items":"double"}]`)
+	checkErrorFatal(t, err, nil)
+
+	written := new(bytes.Buffer)
+	for _, datum := range []interface{}{int32(7), "seven", []interface{}{int32(1), int32(2)}} {
+		checkErrorFatal(t, writer.Encode(written, datum), nil)
+	}
+	transcoded := new(bytes.Buffer)
+	checkErrorFatal(t, Transcode(written, writer, reader, transcoded), nil)
+
+	// an int resolves to the first member it promotes to
+	for _, expected := range []interface{}{float32(7), "seven", []interface{}{float64(1), float64(2)}} {
+		datum, err := reader.Decode(transcoded)
+		checkErrorFatal(t, err, nil)
+		if !reflect.DeepEqual(datum, expected) {
+			t.Errorf("Actual: %#v; Expected: %#v", datum, expected)
+		}
+	}
+}
+
+func TestTranscodeNullWithReaderDefault(t *testing.T) {
+	writer, err := NewCodec(`{"type":"record","name":"r","fields":[{"name":"a","type":["null","int"]}]}`)
+	checkErrorFatal(t, err, nil)
+	reader, err := NewCodec(`{"type":"record","name":"r","fields":[{"name":"a","type":["int","null"],"default":5},{"name":"b","type":["int","null"],"default":5}]}`)
+	checkErrorFatal(t, err, nil)
+
+	transcoded := new(bytes.Buffer)
+	checkErrorFatal(t, Transcode(bytes.NewReader([]byte("\x00")), writer, reader, transcoded), nil)
+	datum, err := reader.Decode(transcoded)
+	checkErrorFatal(t, err, nil)
+	// the null the writer wrote is kept; only the absent field defaults
+	if actual, _ := datum.(*Record).Get("a"); actual != nil {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, nil)
+	}
+	if actual, _ := datum.(*Record).Get("b"); actual != int32(5) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, int32(5))
+	}
+}
+
+func TestTranscodeIncompatible(t *testing.T) {
+	writer, err := NewCodec(`{"type":"record","name":"r","fields":[{"name":"a","type":"int"}]}`)
+	checkErrorFatal(t, err, nil)
+	reader, err := NewCodec(`{"type":"record","name":"r","fields":[{"name":"b","type":"int"}]}`)
+	checkErrorFatal(t, err, nil)
+	err = Transcode(bytes.NewReader([]byte("\x02")), writer, reader, new(bytes.Buffer))
+	checkError(t, err, "reader schema cannot read writer schema")
+}
+
+func TestTranscodeEnumSymbols(t *testing.T) {
+	writer, err := NewCodec(`{"type":"enum","name":"color","symbols":["RED","GREEN","PURPLE"]}`)
+	checkErrorFatal(t, err, nil)
+	reader, err := NewCodec(`{"type":"enum","name":"color","symbols":["GREEN","RED"]}`)
+	checkErrorFatal(t, err, nil)
+	withDefault, err := NewCodec(`{"type":"enum","name":"color","symbols":["GREEN","RED","OTHER"],"default":"OTHER"}`)
+	checkErrorFatal(t, err, nil)
+
+	// symbols both enums have resolve, whatever their index
+	transcoded := new(bytes.Buffer)
+	checkErrorFatal(t, Transcode(bytes.NewReader([]byte("\x00\x02")), writer, reader, transcoded), nil)
+	if actual, expected := transcoded.Bytes(), []byte("\x02\x00"); !bytes.Equal(actual, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+
+	// a symbol the reader lacks is an error only when encountered
+	err = Transcode(bytes.NewReader([]byte("\x04")), writer, reader, new(bytes.Buffer))
+	checkError(t, err, `writer symbol "PURPLE" at index 2 of enum color is not one of reader symbols, and the reader has no default: GREEN, RED`)
+
+	// unless the reader has a default
+	transcoded.Reset()
+	checkErrorFatal(t, Transcode(bytes.NewReader([]byte("\x04")), writer, withDefault, transcoded), nil)
+	if actual, expected := transcoded.Bytes(), []byte("\x04"); !bytes.Equal(actual, expected) {
+		t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2015 LinkedIn Corp. Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except in
+// compliance with the License.  You may obtain a copy of the License
+// at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.Copyright [201X] LinkedIn Corp. Licensed under the Apache
+// License, Version 2.0 (the "License"); you may not use this file
+// except in compliance with the License.  You may obtain a copy of
+// the License at http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+
+package goavro
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"reflect"
+)
+
+// ErrValidation is returned when a datum does not conform to the
+// schema of a Codec. Path identifies the offending portion of the
+// datum, for instance `address.lines[2]`, and is empty when the top
+// level datum itself is at fault.
+type ErrValidation struct {
+	Path    string
+	Message string
+	Err     error
+}
+
+func (e ErrValidation) Error() string {
+	message := "invalid datum"
+	if e.Path != "" {
+		message += " at " + e.Path
+	}
+	message += ": " + e.Message
+	if e.Err == nil {
+		return message
+	}
+	return message + ": " + e.Err.Error()
+}
+
+func newValidationError(path, dataType string, a ...interface{}) *ErrValidation {
+	var err error
+	var format, message string
+	var ok bool
+	if len(a) == 0 {
+		return &ErrValidation{path, dataType + ": no reason given", nil}
+	}
+	// if last item is error: save it
+	if err, ok = a[len(a)-1].(error); ok {
+		a = a[:len(a)-1] // pop it
+	}
+	// if items left, first ought to be format string
+	if len(a) > 0 {
+		if format, ok = a[0].(string); ok {
+			a = a[1:] // unshift
+			message = fmt.Sprintf(format, a...)
+		}
+	}
+	if message != "" {
+		message = ": " + message
+	}
+	return &ErrValidation{path, dataType + message, err}
+}
+
+// unionMemberDatum returns the value a pointer datum points to, or nil
+// for a nil pointer, so that optional values held in pointers, such as
+// *int64, encode as the corresponding member of a union, or as null.
+// *Record is itself a datum and is returned unchanged, unless it is nil.
+func unionMemberDatum(datum interface{}) interface{} {
+	if someRecord, ok := datum.(*Record); ok {
+		if someRecord == nil {
+			return nil
+		}
+		return datum
+	}
+	if someRat, ok := datum.(*big.Rat); ok && someRat != nil {
+		return datum // resolved to a decimal member by validator
+	}
+	v := reflect.ValueOf(datum)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+		if _, ok := v.Interface().(*Record); ok {
+			return unionMemberDatum(v.Interface())
+		}
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
+
+// unionMemberName returns the name used to resolve which union member
+// ought to process the specified datum.
+func unionMemberName(datum interface{}) string {
+	switch datum.(type) {
+	case map[string]interface{}:
+		return "map"
+	case []interface{}:
+		return "array"
+	case nil:
+		return "null"
+	case Enum:
+		return datum.(Enum).Name
+	case Fixed:
+		return datum.(Fixed).Name
+	case *Record:
+		return datum.(*Record).Name
+	default:
+		return reflect.TypeOf(datum).String()
+	}
+}
+
+// unionMemberAlternates returns the names of the union members, in
+// order of preference, which may encode datum when no member is named
+// after its Go type, such as a long member for an int, or an array
+// member for a []string.
+func unionMemberAlternates(datum interface{}) []string {
+	switch v := datum.(type) {
+	case int:
+		return []string{"int64", "int32"}
+	case int8, int16:
+		return []string{"int32", "int64"}
+	case int32:
+		return []string{"int64"}
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return []string{"int64", "int32", "float64", "float32"}
+		}
+		return []string{"float64", "float32"}
+	}
+	if _, _, ok := typedSlice(datum); ok {
+		return []string{"array"}
+	}
+	return nil
+}
+
+// validatorFromEncoder returns a validator which checks datum by
+// encoding it to ioutil.Discard, so the validator of a codec which
+// holds no other codecs accepts exactly the data its encoder accepts.
+func validatorFromEncoder(ef encoderFunction) validatorFunction {
+	return func(path string, datum interface{}) error {
+		err := ef(ioutil.Discard, datum)
+		if err == nil {
+			return nil
+		}
+		if encoderError, ok := err.(*ErrEncoder); ok {
+			return &ErrValidation{path, encoderError.Message, encoderError.Err}
+		}
+		return &ErrValidation{path, err.Error(), nil}
+	}
+}